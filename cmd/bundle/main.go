@@ -0,0 +1,140 @@
+// Command bundle đóng gói thư mục assets thành 1 file zip để phát hành cùng game.
+// Các ảnh PNG nằm ngay trong assets/images được xếp sẵn vào atlas (assets/atlas),
+// game mở bundle bằng cờ -assets sẽ dùng luôn atlas đó thay vì xếp lúc chạy.
+//
+//	go run ./cmd/bundle -out assets.zip
+//	go run . -assets assets.zip
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"pixcel-game/systems"
+)
+
+// atlasDir là thư mục của atlas trong bundle, khớp với render.AtlasIndexPath
+const atlasDir = "assets/atlas"
+
+func main() {
+	root := flag.String("root", ".", "thư mục chứa thư mục assets")
+	out := flag.String("out", "assets.zip", "file bundle cần ghi")
+	size := flag.Int("atlas", systems.DefaultAtlasSize, "cạnh tối đa (pixel) của 1 trang atlas")
+	flag.Parse()
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	files, atlas, err := writeBundle(f, os.DirFS(*root), *size)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		log.Fatalf("%s: %v", *out, err)
+	}
+
+	fmt.Printf("bundle:    %s\n", *out)
+	fmt.Printf("file:      %d\n", files)
+	fmt.Printf("atlas:     %d ảnh, %d trang\n", len(atlas.Entries), len(atlas.Pages))
+}
+
+// writeBundle ghi thư mục assets của fsys thành zip vào w: các ảnh xếp được vào atlas
+// (cạnh trang tối đa size) thay cho file PNG gốc, mọi file khác chép nguyên.
+// Trả về số file đã chép và atlas đã xếp.
+func writeBundle(w io.Writer, fsys fs.FS, size int) (int, *systems.Atlas, error) {
+	packed, err := packableImages(fsys)
+	if err != nil {
+		return 0, nil, err
+	}
+	atlas := systems.PackAtlas(packed, size)
+
+	zw := zip.NewWriter(w)
+	files := 0
+	err = fs.WalkDir(fsys, "assets", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		// Ảnh đã vào atlas và thư mục atlas cũ không cần chép lại
+		if _, ok := packed[p]; ok || strings.HasPrefix(p, atlasDir+"/") {
+			return nil
+		}
+		contents, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		files++
+		return writeFile(zw, p, contents)
+	})
+	if err == nil {
+		err = writeAtlas(zw, atlas)
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	return files, atlas, err
+}
+
+// packableImages đọc các ảnh PNG nằm ngay trong assets/images (sprite, đạn, tileset),
+// tra theo đường dẫn. Ảnh strip trong thư mục con được sheet JSON ghép lúc chạy nên giữ nguyên.
+func packableImages(fsys fs.FS) (map[string]image.Image, error) {
+	matches, err := fs.Glob(fsys, "assets/images/*.png")
+	if err != nil {
+		return nil, err
+	}
+	images := map[string]image.Image{}
+	for _, p := range matches {
+		f, err := fsys.Open(p)
+		if err != nil {
+			return nil, err
+		}
+		img, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		images[p] = img
+	}
+	return images, nil
+}
+
+// writeAtlas ghi các trang atlas và file index vào bundle
+func writeAtlas(zw *zip.Writer, atlas *systems.Atlas) error {
+	index := atlas.Index(func(i int) string {
+		return fmt.Sprintf("atlas_%d.png", i)
+	})
+	for i, page := range atlas.Pages {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, page); err != nil {
+			return err
+		}
+		if err := writeFile(zw, path.Join(atlasDir, index.Pages[i]), buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	contents, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(zw, path.Join(atlasDir, "atlas.json"), contents)
+}
+
+func writeFile(zw *zip.Writer, name string, contents []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(contents)
+	return err
+}
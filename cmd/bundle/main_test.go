@@ -0,0 +1,109 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"image"
+	_ "image/png"
+	"io/fs"
+	"os"
+	"path"
+	"testing"
+
+	"pixcel-game/game"
+	"pixcel-game/systems"
+)
+
+// TestBundleResolvesSprites đóng gói thư mục assets của repo rồi mở bundle như game (-assets bundle.zip):
+// mọi sprite của player, quái, boss và đạn phải tìm được trong bundle, qua sheet hoặc qua atlas dựng sẵn
+func TestBundleResolvesSprites(t *testing.T) {
+	var buf bytes.Buffer
+	files, atlas, err := writeBundle(&buf, os.DirFS("../.."), systems.DefaultAtlasSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files == 0 || len(atlas.Entries) == 0 {
+		t.Fatalf("bundle có %d file, atlas %d ảnh", files, len(atlas.Entries))
+	}
+	bundle, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	game.SetAssetFS(bundle)
+	defer game.SetAssetFS(nil)
+
+	// Atlas dựng sẵn: index hợp lệ, mọi ảnh nằm gọn trong trang của nó
+	contents, err := fs.ReadFile(bundle, path.Join(atlasDir, "atlas.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index systems.AtlasIndex
+	if err := json.Unmarshal(contents, &index); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	pages := make([]image.Rectangle, len(index.Pages))
+	for i, name := range index.Pages {
+		f, err := bundle.Open(path.Join(atlasDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, _, err := image.DecodeConfig(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		pages[i] = image.Rect(0, 0, cfg.Width, cfg.Height)
+	}
+	for id, e := range index.Entries {
+		if !e.Rect().In(pages[e.Page]) {
+			t.Errorf("ảnh %q %v nằm ngoài trang %d %v", id, e.Rect(), e.Page, pages[e.Page])
+		}
+		if _, err := fs.Stat(bundle, id); err == nil {
+			t.Errorf("ảnh %q đã vào atlas nhưng vẫn bị chép vào bundle", id)
+		}
+	}
+
+	archetypes, err := game.LoadArchetypes("assets/data/enemies.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bosses, err := game.LoadBosses("assets/data/bosses.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	used := map[string]string{
+		game.SpritePlayer:     "player",
+		game.SpriteEnemy:      "quái mặc định",
+		game.SpriteProjectile: "đạn",
+		game.SpritePotion:     "bình máu",
+	}
+	for id, a := range archetypes {
+		used[a.Sprite] = "quái " + id
+		if a.Weapon != nil {
+			used[a.Weapon.Sprite] = "đạn của quái " + id
+		}
+	}
+	for id, b := range bosses {
+		used[b.Sprite] = "boss " + id
+	}
+
+	// Giống loadSpriteImage của game: sheet JSON hoặc file Aseprite, không thì <sprite>.png
+	sprites := game.NewSpriteLibrary()
+	for sprite, owner := range used {
+		img, err := sprites.Load("assets/images", sprite)
+		if err != nil {
+			t.Errorf("sprite %q của %s: %v", sprite, owner, err)
+			continue
+		}
+		if img != nil {
+			continue
+		}
+		if _, ok := index.Entries[path.Join("assets/images", sprite+".png")]; !ok {
+			t.Errorf("sprite %q của %s không có trong bundle", sprite, owner)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Command replay chạy lại file replay mà không cần cửa sổ hay GPU,
+// dùng để QA kiểm tra bug report và xác nhận bản sửa trên CI.
+//
+//	go run ./cmd/replay -replay bug.rpl
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"pixcel-game/game"
+)
+
+func main() {
+	replayPath := flag.String("replay", "", "file replay cần chạy lại")
+	mapPath := flag.String("map", "", "map dùng để chạy (mặc định lấy từ replay)")
+	enemiesPath := flag.String("enemies", "", "file định nghĩa các loại quái (mặc định lấy từ replay)")
+	bossesPath := flag.String("bosses", "", "file định nghĩa các boss (mặc định lấy từ replay)")
+	wavesPath := flag.String("waves", "", "file kịch bản wave (mặc định lấy từ replay)")
+	chapterPath := flag.String("chapter", "", "file chapter dùng để chạy (mặc định lấy từ replay)")
+	imagesDir := flag.String("images", "assets/images", "thư mục sprite (sheet quyết định khung va chạm của nhân vật)")
+	flag.Parse()
+
+	if *replayPath == "" {
+		log.Fatal("cần truyền -replay")
+	}
+
+	replay, err := game.LoadReplay(*replayPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *mapPath == "" {
+		*mapPath = replay.Map
+	}
+
+	tilemap, err := game.LoadTilemap(*mapPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Mặc định dùng dữ liệu lưu trong replay, flag để thử replay với dữ liệu đã sửa.
+	// Replay bản cũ chưa lưu dữ liệu thì đọc các file trong assets.
+	var data game.GameData
+	if replay.Data != nil {
+		data = *replay.Data
+	}
+	for _, f := range []struct {
+		path      *string
+		name, def string
+		dst       *[]byte
+	}{
+		{enemiesPath, "quái", "assets/data/enemies.json", &data.Enemies},
+		{bossesPath, "boss", "assets/data/bosses.json", &data.Bosses},
+		{wavesPath, "wave", "assets/data/waves.json", &data.Waves},
+	} {
+		if *f.path == "" && replay.Data == nil {
+			*f.path = f.def
+		}
+		if *f.path == "" {
+			*f.path = fmt.Sprintf("%s (dữ liệu %s)", *replayPath, f.name)
+			continue
+		}
+		if *f.dst, err = game.ReadAsset(*f.path); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Dữ liệu trống = lúc ghi world dùng quái, boss mặc định và wave endless
+	var archetypes map[string]*game.EnemyArchetype
+	if data.Enemies != nil {
+		if archetypes, err = game.ParseArchetypes(*enemiesPath, data.Enemies); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var bosses map[string]*game.BossDef
+	if data.Bosses != nil {
+		if bosses, err = game.ParseBosses(*bossesPath, data.Bosses); err != nil {
+			log.Fatal(err)
+		}
+		if err := game.ValidateMinions(bosses, archetypes); err != nil {
+			log.Fatalf("%s: %v", *bossesPath, err)
+		}
+	}
+
+	var waveScript *game.WaveScript
+	if data.Waves != nil {
+		if waveScript, err = game.ParseWaveScript(*wavesPath, data.Waves); err != nil {
+			log.Fatal(err)
+		}
+		if err := waveScript.Validate(archetypes, bosses); err != nil {
+			log.Fatalf("%s: %v", *wavesPath, err)
+		}
+	}
+
+	// Mặc định dùng chapter lưu trong replay, -chapter để thử replay với chapter đã sửa
+	var chapter *game.ChapterDef
+	if *chapterPath != "" {
+		chapter, err = game.LoadChapter(*chapterPath)
+	} else {
+		chapter, err = replay.LoadChapter()
+	}
+	if err == nil && chapter != nil {
+		err = chapter.Validate(archetypes, bosses)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Chỉ cần hitbox và clip của sprite, ảnh không được dùng
+	sprites := game.NewSpriteLibrary()
+	load := func(sprite string) {
+		if _, err := sprites.Load(*imagesDir, sprite); err != nil {
+			log.Fatal(err)
+		}
+	}
+	load(game.SpritePlayer)
+	load(game.SpriteEnemy)
+	for _, id := range game.SortedArchetypeIDs(archetypes) {
+		load(archetypes[id].Sprite)
+	}
+	for _, id := range game.SortedBossIDs(bosses) {
+		load(bosses[id].Sprite)
+	}
+
+	w := replay.NewWorld(tilemap, sprites)
+	w.SetArchetypes(archetypes)
+	w.SetBosses(bosses)
+	w.SetWaveScript(waveScript)
+	w.SetChapter(chapter)
+	replay.Run(w)
+
+	fmt.Printf("seed:      %d\n", w.Seed)
+	fmt.Printf("bước:      %d (%.1f giây)\n", w.Clock.Frame, w.Clock.Time)
+	fmt.Printf("wave:      %d\n", w.Wave.CurrentWave)
+	if chapter != nil {
+		fmt.Printf("phòng:     %d/%d\n", w.Room+1, len(chapter.Rooms))
+		fmt.Printf("quái hạ:   %d\n", w.Stats.Kills)
+	}
+	fmt.Printf("HP:        %.1f / %.1f\n", w.Player.Health, w.Player.MaxHealth)
+	fmt.Printf("vị trí:    (%.1f, %.1f)\n", w.Player.X, w.Player.Y)
+	fmt.Printf("quái còn:  %d\n", len(w.Enemies))
+	fmt.Printf("kỹ năng:   %d\n", len(w.Player.Skills))
+}
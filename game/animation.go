@@ -0,0 +1,276 @@
+package game
+
+import "math"
+
+// Tên các clip của nhân vật. Clip theo hướng có dạng "walk_left" (xem DirectionalClip).
+const (
+	ClipIdle   = "idle"
+	ClipWalk   = "walk"
+	ClipAttack = "attack"
+	ClipHurt   = "hurt"
+	ClipDeath  = "death"
+)
+
+// Facing là hướng nhân vật đang quay mặt
+type Facing int
+
+const (
+	FacingDown Facing = iota
+	FacingUp
+	FacingLeft
+	FacingRight
+)
+
+// String trả về tên hướng, dùng làm hậu tố tên clip
+func (f Facing) String() string {
+	switch f {
+	case FacingUp:
+		return "up"
+	case FacingLeft:
+		return "left"
+	case FacingRight:
+		return "right"
+	default:
+		return "down"
+	}
+}
+
+// FacingFrom chọn hướng theo vector (dx, dy): trục nào dài hơn thì quay theo trục đó
+func FacingFrom(dx, dy float64, current Facing) Facing {
+	switch {
+	case dx == 0 && dy == 0:
+		return current
+	case math.Abs(dx) > math.Abs(dy):
+		if dx < 0 {
+			return FacingLeft
+		}
+		return FacingRight
+	case dy < 0:
+		return FacingUp
+	default:
+		return FacingDown
+	}
+}
+
+// DirectionalClip trả về tên clip của hành động theo hướng, vd "walk_left"
+func DirectionalClip(action string, f Facing) string {
+	return action + "_" + f.String()
+}
+
+// AnimFrame là 1 frame của animation: vùng (pixel) trên spritesheet và thời gian hiển thị
+type AnimFrame struct {
+	X, Y     int
+	W, H     int
+	Duration float64 // Giây
+}
+
+// Animation là 1 clip có tên: chuỗi frame chạy lặp hoặc dừng ở frame cuối
+type Animation struct {
+	Name   string
+	Frames []AnimFrame
+	Loop   bool
+}
+
+// Duration trả về tổng thời gian 1 lượt của clip (giây)
+func (a *Animation) Duration() float64 {
+	total := 0.0
+	for _, f := range a.Frames {
+		total += f.Duration
+	}
+	return total
+}
+
+// FrameAt trả về chỉ số frame tại thời điểm t (giây) kể từ lúc clip bắt đầu
+func (a *Animation) FrameAt(t float64) int {
+	total := a.Duration()
+	if len(a.Frames) == 0 || total <= 0 {
+		return 0
+	}
+	if a.Loop {
+		t = math.Mod(t, total)
+	} else if t >= total {
+		return len(a.Frames) - 1
+	}
+	for i, f := range a.Frames {
+		if t < f.Duration {
+			return i
+		}
+		t -= f.Duration
+	}
+	return len(a.Frames) - 1
+}
+
+// ClipSet là bộ clip của 1 spritesheet, tra theo tên clip
+type ClipSet map[string]*Animation
+
+// Animator chạy clip cho 1 entity. Animator chỉ giữ tên clip và thời gian,
+// nên bộ clip có thể dùng chung cho mọi entity cùng spritesheet.
+type Animator struct {
+	Clips   ClipSet
+	Current string  // Tên clip đang chạy
+	Time    float64 // Thời gian (giây) kể từ lúc clip bắt đầu
+}
+
+// NewAnimator tạo animator chạy clip idle
+func NewAnimator(clips ClipSet) *Animator {
+	a := &Animator{Clips: clips}
+	a.Play(ClipIdle)
+	return a
+}
+
+// Play chuyển sang clip có tên name. Clip đang chạy thì chạy tiếp, không bắt đầu lại.
+// Clip không tồn tại thì giữ nguyên clip cũ.
+func (a *Animator) Play(name string) {
+	if name == a.Current {
+		return
+	}
+	if _, ok := a.Clips[name]; !ok {
+		return
+	}
+	a.Current = name
+	a.Time = 0
+}
+
+// PlayDirectional chạy clip của hành động theo hướng ("walk_left"),
+// không có thì dùng clip chung của hành động ("walk"), rồi tới idle theo hướng
+func (a *Animator) PlayDirectional(action string, f Facing) {
+	for _, name := range []string{DirectionalClip(action, f), action, DirectionalClip(ClipIdle, f), ClipIdle} {
+		if _, ok := a.Clips[name]; ok {
+			a.Play(name)
+			return
+		}
+	}
+}
+
+// Update tua animation thêm dt giây
+func (a *Animator) Update(dt float64) {
+	a.Time += dt
+}
+
+// Clip trả về clip đang chạy (nil nếu bộ clip rỗng)
+func (a *Animator) Clip() *Animation {
+	return a.Clips[a.Current]
+}
+
+// Frame trả về frame cần vẽ. ok = false khi không có clip nào.
+func (a *Animator) Frame() (AnimFrame, bool) {
+	clip := a.Clip()
+	if clip == nil || len(clip.Frames) == 0 {
+		return AnimFrame{}, false
+	}
+	return clip.Frames[clip.FrameAt(a.Time)], true
+}
+
+// Finished kiểm tra clip không lặp đã chạy hết chưa (clip lặp thì không bao giờ hết)
+func (a *Animator) Finished() bool {
+	clip := a.Clip()
+	return clip == nil || (!clip.Loop && a.Time >= clip.Duration())
+}
+
+// Remaining trả về thời gian còn lại của clip không lặp (clip lặp trả về 0)
+func (a *Animator) Remaining() float64 {
+	clip := a.Clip()
+	if clip == nil || clip.Loop {
+		return 0
+	}
+	return max(clip.Duration()-a.Time, 0)
+}
+
+// Thời gian mỗi frame của bộ clip nhân vật mặc định (giây)
+const (
+	walkFrameTime = 0.15
+	attackTime    = 0.2
+	hurtTime      = 0.2
+	deathTime     = 0.6
+)
+
+// CharacterClips tạo bộ clip cho spritesheet nhân vật 4 cột x 7 hàng (ninja.png, skeleton.png),
+// mỗi ô size x size pixel. Cột là hướng (xuống, lên, trái, phải); hàng 0-3 là 4 bước đi
+// (hàng 0 cũng là dáng đứng yên), hàng 4 là đòn đánh, hàng 5 (dáng nhảy) dùng làm dáng bị đánh,
+// ô đầu của hàng 6 là dáng chết.
+func CharacterClips(size int) ClipSet {
+	cell := func(col, row int, d float64) AnimFrame {
+		return AnimFrame{X: col * size, Y: row * size, W: size, H: size, Duration: d}
+	}
+
+	clips := ClipSet{}
+	add := func(name string, loop bool, frames ...AnimFrame) {
+		clips[name] = &Animation{Name: name, Frames: frames, Loop: loop}
+	}
+	for col, f := range []Facing{FacingDown, FacingUp, FacingLeft, FacingRight} {
+		add(DirectionalClip(ClipIdle, f), true, cell(col, 0, 1))
+		add(DirectionalClip(ClipWalk, f), true,
+			cell(col, 0, walkFrameTime), cell(col, 1, walkFrameTime),
+			cell(col, 2, walkFrameTime), cell(col, 3, walkFrameTime))
+		add(DirectionalClip(ClipAttack, f), false, cell(col, 4, attackTime))
+		add(DirectionalClip(ClipHurt, f), false, cell(col, 5, hurtTime))
+	}
+	add(ClipIdle, true, cell(0, 0, 1))
+	add(ClipDeath, false, cell(0, 6, deathTime))
+	return clips
+}
+
+// characterClips là bộ clip dùng chung cho player và quái (cùng bố cục spritesheet 16x16)
+var characterClips = CharacterClips(16)
+
+// Action là trạng thái hành động của nhân vật, quyết định clip được chạy
+type Action struct {
+	Facing      Facing
+	FacingLeft  bool    // Lần quay ngang gần nhất là sang trái (dùng để lật strip chỉ vẽ hướng phải)
+	Moving      bool    // Đã di chuyển trong bước mô phỏng này
+	AttackTimer float64 // Thời gian còn lại của dáng đánh
+	HurtTimer   float64 // Thời gian còn lại của dáng bị đánh
+
+	newAttack bool // Vừa ra đòn mới, clip đánh đã chạy hết thì chạy lại từ đầu
+}
+
+// Face quay mặt theo hướng (dx, dy)
+func (s *Action) Face(dx, dy float64) {
+	s.Facing = FacingFrom(dx, dy, s.Facing)
+	if dx != 0 {
+		s.FacingLeft = dx < 0
+	}
+}
+
+// Attacked bật dáng đánh, quay mặt về phía mục tiêu (dx, dy)
+func (s *Action) Attacked(dx, dy float64) {
+	s.Face(dx, dy)
+	s.AttackTimer = attackTime
+	s.newAttack = true
+}
+
+// Hurt bật dáng bị đánh
+func (s *Action) Hurt() {
+	s.HurtTimer = hurtTime
+}
+
+// animate đếm ngược các dáng tạm thời rồi chọn clip theo thứ tự ưu tiên:
+// chết > bị đánh > đánh > đi > đứng yên. Clip đánh và bị đánh dài hơn thời gian mặc định
+// (vd clip từ file Aseprite) được giữ tới khi chạy hết.
+func (s *Action) animate(anim *Animator, dt float64, alive bool) {
+	s.AttackTimer = max(s.AttackTimer-dt, 0)
+	s.HurtTimer = max(s.HurtTimer-dt, 0)
+	if anim == nil {
+		return
+	}
+
+	switch {
+	case !alive:
+		anim.Play(ClipDeath)
+	case s.HurtTimer > 0:
+		anim.PlayDirectional(ClipHurt, s.Facing)
+		s.HurtTimer = max(s.HurtTimer, anim.Remaining())
+	case s.AttackTimer > 0:
+		anim.PlayDirectional(ClipAttack, s.Facing)
+		if s.newAttack && anim.Finished() {
+			anim.Time = 0
+		}
+		s.AttackTimer = max(s.AttackTimer, anim.Remaining())
+	case s.Moving:
+		anim.PlayDirectional(ClipWalk, s.Facing)
+	default:
+		anim.PlayDirectional(ClipIdle, s.Facing)
+	}
+	s.newAttack = false
+	anim.Update(dt)
+}
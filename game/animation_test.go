@@ -0,0 +1,155 @@
+package game
+
+import "testing"
+
+func TestAnimationFrameAt(t *testing.T) {
+	frames := []AnimFrame{{Duration: 0.25}, {Duration: 0.5}, {Duration: 0.25}}
+	loop := &Animation{Name: "loop", Frames: frames, Loop: true}
+	once := &Animation{Name: "once", Frames: frames}
+	tests := []struct {
+		clip *Animation
+		t    float64
+		want int
+	}{
+		{loop, 0, 0},
+		{loop, 0.2, 0},
+		{loop, 0.25, 1},
+		{loop, 0.7, 1},
+		{loop, 0.75, 2},
+		{loop, 1, 0},
+		{loop, 1.3, 1},
+		{loop, 2.9, 2},
+		{once, 0, 0},
+		{once, 0.5, 1},
+		{once, 0.8, 2},
+		{once, 1, 2},
+		{once, 5, 2},
+		{&Animation{Loop: true}, 1, 0},
+		{&Animation{Frames: []AnimFrame{{}, {}}, Loop: true}, 1, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.clip.FrameAt(tt.t); got != tt.want {
+			t.Errorf("%s (%d frame): FrameAt(%v) = %d, muốn %d", tt.clip.Name, len(tt.clip.Frames), tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestAnimatorPlay(t *testing.T) {
+	anim := NewAnimator(CharacterClips(16))
+	if anim.Current != ClipIdle {
+		t.Fatalf("clip ban đầu = %q, muốn %q", anim.Current, ClipIdle)
+	}
+
+	walk := DirectionalClip(ClipWalk, FacingLeft)
+	anim.Play(walk)
+	anim.Update(0.2)
+	if frame, _ := anim.Frame(); anim.Current != walk || frame != anim.Clips[walk].Frames[1] {
+		t.Errorf("sau 0.2s của %s: clip %q, frame %+v", walk, anim.Current, frame)
+	}
+	// Chạy lại clip đang chạy không bắt đầu lại, clip không tồn tại thì giữ clip cũ
+	anim.Play(walk)
+	anim.Play("khong_co")
+	if anim.Current != walk || anim.Time != 0.2 {
+		t.Errorf("clip %q, thời gian %v; muốn %q, 0.2", anim.Current, anim.Time, walk)
+	}
+	// Đổi clip thì chạy từ đầu
+	anim.Play(ClipDeath)
+	if anim.Time != 0 || anim.Finished() {
+		t.Errorf("clip chết vừa bắt đầu: thời gian %v, hết = %v", anim.Time, anim.Finished())
+	}
+	anim.Update(deathTime)
+	if !anim.Finished() || anim.Remaining() != 0 {
+		t.Errorf("clip chết đã chạy %v giây: hết = %v, còn %v", anim.Time, anim.Finished(), anim.Remaining())
+	}
+
+	// PlayDirectional: clip theo hướng > clip chung > idle theo hướng > idle
+	clips := ClipSet{
+		ClipIdle:                              {Name: ClipIdle, Loop: true},
+		DirectionalClip(ClipIdle, FacingUp):   {Name: "idle_up", Loop: true},
+		ClipWalk:                              {Name: ClipWalk, Loop: true},
+		DirectionalClip(ClipWalk, FacingLeft): {Name: "walk_left", Loop: true},
+	}
+	tests := []struct {
+		action string
+		facing Facing
+		want   string
+	}{
+		{ClipWalk, FacingLeft, "walk_left"},
+		{ClipWalk, FacingRight, ClipWalk},
+		{ClipAttack, FacingUp, "idle_up"},
+		{ClipAttack, FacingDown, ClipIdle},
+	}
+	for _, tt := range tests {
+		a := NewAnimator(clips)
+		a.PlayDirectional(tt.action, tt.facing)
+		if a.Current != tt.want {
+			t.Errorf("PlayDirectional(%s, %s) = %q, muốn %q", tt.action, tt.facing, a.Current, tt.want)
+		}
+	}
+}
+
+func TestActionAnimate(t *testing.T) {
+	const dt = 1.0 / 60
+	anim := NewAnimator(CharacterClips(16))
+	var s Action
+
+	s.Moving = true
+	s.Face(-1, 0)
+	s.animate(anim, dt, true)
+	if want := DirectionalClip(ClipWalk, FacingLeft); anim.Current != want {
+		t.Errorf("đang đi: clip %q, muốn %q", anim.Current, want)
+	}
+
+	// Đánh được ưu tiên hơn đi, bị đánh được ưu tiên hơn đánh
+	s.Attacked(0, 1)
+	s.animate(anim, dt, true)
+	if want := DirectionalClip(ClipAttack, FacingDown); anim.Current != want {
+		t.Errorf("vừa ra đòn: clip %q, muốn %q", anim.Current, want)
+	}
+	s.Hurt()
+	s.animate(anim, dt, true)
+	if want := DirectionalClip(ClipHurt, FacingDown); anim.Current != want {
+		t.Errorf("bị đánh: clip %q, muốn %q", anim.Current, want)
+	}
+
+	// Dáng đánh giữ tới hết đòn rồi quay lại đi
+	for s.HurtTimer > 0 {
+		s.animate(anim, dt, true)
+	}
+	s.Attacked(0, 1)
+	for i := 0; s.AttackTimer > 0; i++ {
+		s.animate(anim, dt, true)
+		if s.AttackTimer > 0 && anim.Current != DirectionalClip(ClipAttack, FacingDown) {
+			t.Fatalf("frame %d của đòn đánh: clip %q", i, anim.Current)
+		}
+	}
+	s.animate(anim, dt, true)
+	if want := DirectionalClip(ClipWalk, FacingDown); anim.Current != want {
+		t.Errorf("hết đòn đánh: clip %q, muốn %q", anim.Current, want)
+	}
+
+	// Đòn mới khi clip đánh đã chạy hết thì chạy lại từ đầu
+	s.Attacked(0, 1)
+	s.animate(anim, dt, true)
+	anim.Time = attackTime
+	s.Attacked(0, 1)
+	s.animate(anim, dt, true)
+	if anim.Time != dt {
+		t.Errorf("đòn mới: clip đánh chạy tiếp từ %v, muốn bắt đầu lại", anim.Time-dt)
+	}
+
+	// Chết được ưu tiên hơn mọi dáng
+	s.Hurt()
+	s.animate(anim, dt, false)
+	if anim.Current != ClipDeath {
+		t.Errorf("đã chết: clip %q, muốn %q", anim.Current, ClipDeath)
+	}
+
+	// Không có animator thì vẫn đếm ngược
+	s = Action{}
+	s.Attacked(1, 0)
+	s.animate(nil, attackTime, true)
+	if s.AttackTimer != 0 || s.Facing != FacingRight || s.FacingLeft {
+		t.Errorf("không có animator: %+v", s)
+	}
+}
@@ -0,0 +1,226 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Các loại vật phẩm có thể rơi ra khi quái chết
+const (
+	DropPotion = "potion"
+)
+
+// EnemyTimings là các mốc thời gian (giây) trong chu kỳ AI của quái
+type EnemyTimings struct {
+	SpawnDelay     float64 `json:"spawnDelay"`     // Thời gian đứng yên sau khi sinh ra
+	Rest           float64 `json:"rest"`           // Thời gian nghỉ giữa 2 lần lao tới
+	Dash           float64 `json:"dash"`           // Thời gian lao tới
+	DashMultiplier float64 `json:"dashMultiplier"` // Hệ số tốc độ khi lao tới
+	Wander         float64 `json:"wander"`         // Thời gian đi 1 hướng trước khi đổi (wanderer)
+}
+
+// DropEntry là 1 dòng trong bảng rơi đồ: vật phẩm và xác suất rơi
+type DropEntry struct {
+	Item   string  `json:"item"`
+	Chance float64 `json:"chance"` // 0..1
+}
+
+// EnemyArchetype là định nghĩa 1 loại quái, đọc từ file dữ liệu
+type EnemyArchetype struct {
+	ID            string       `json:"id"`
+	Sprite        string       `json:"sprite"`
+	Width         float64      `json:"width"`
+	Height        float64      `json:"height"`
+	MaxHealth     float64      `json:"maxHealth"`
+	Speed         float64      `json:"speed"`         // px mỗi giây
+	ContactDamage float64      `json:"contactDamage"` // Sát thương mỗi giây khi chạm vào player
+	FollowDist    float64      `json:"followDist"`    // Khoảng cách bắt đầu đuổi theo player
+	PreferredDist float64      `json:"preferredDist"` // Khoảng cách muốn giữ với player (kiter)
+	Behavior      string       `json:"behavior"`
+	Timings       EnemyTimings `json:"timings"`
+	Drops         []DropEntry  `json:"drops"`
+	Weapon        *WeaponDef   `json:"weapon"`      // Vũ khí bắn xa (nil nếu chỉ đánh cận chiến)
+	SpawnWeight   float64      `json:"spawnWeight"` // Trọng số khi chọn ngẫu nhiên loại quái để spawn
+}
+
+// enemyFile là cấu trúc file định nghĩa quái
+type enemyFile struct {
+	Enemies []*EnemyArchetype `json:"enemies"`
+}
+
+// DefaultArchetypeID là loại quái dùng khi không có dữ liệu nào khác
+const DefaultArchetypeID = "skeleton"
+
+// DefaultArchetype trả về loại quái mặc định (skeleton lao tới), dùng khi không có file dữ liệu
+func DefaultArchetype() *EnemyArchetype {
+	return &EnemyArchetype{
+		ID:            DefaultArchetypeID,
+		Sprite:        SpriteEnemy,
+		Width:         16,
+		Height:        16,
+		MaxHealth:     30,
+		Speed:         72,
+		ContactDamage: 300,
+		FollowDist:    400,
+		PreferredDist: 160,
+		Behavior:      BehaviorDasher,
+		Timings: EnemyTimings{
+			SpawnDelay:     1.0,
+			Rest:           0.8,
+			Dash:           1.2,
+			DashMultiplier: 1.8,
+			Wander:         1.5,
+		},
+		Drops:       []DropEntry{{Item: DropPotion, Chance: 0.3}},
+		SpawnWeight: 1,
+	}
+}
+
+// LoadArchetypes đọc file định nghĩa quái (JSON) và trả về map theo ID
+func LoadArchetypes(path string) (map[string]*EnemyArchetype, error) {
+	contents, err := ReadAsset(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseArchetypes(path, contents)
+}
+
+// ParseArchetypes đọc định nghĩa quái từ nội dung JSON đã có sẵn (ví dụ lưu trong replay),
+// path chỉ dùng để báo lỗi
+func ParseArchetypes(path string, contents []byte) (map[string]*EnemyArchetype, error) {
+	var file enemyFile
+	if err := json.Unmarshal(contents, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	archetypes := make(map[string]*EnemyArchetype, len(file.Enemies))
+	for i, a := range file.Enemies {
+		if a.ID == "" {
+			return nil, fmt.Errorf("%s: quái thứ %d thiếu id", path, i)
+		}
+		if _, ok := archetypes[a.ID]; ok {
+			return nil, fmt.Errorf("%s: trùng id quái %q", path, a.ID)
+		}
+		a.applyDefaults()
+		if err := a.validate(); err != nil {
+			return nil, fmt.Errorf("%s: quái %q: %w", path, a.ID, err)
+		}
+		archetypes[a.ID] = a
+	}
+	return archetypes, nil
+}
+
+// applyDefaults điền giá trị mặc định cho các trường bị bỏ trống
+func (a *EnemyArchetype) applyDefaults() {
+	def := DefaultArchetype()
+	if a.Sprite == "" {
+		a.Sprite = def.Sprite
+	}
+	if a.Width == 0 {
+		a.Width = def.Width
+	}
+	if a.Height == 0 {
+		a.Height = def.Height
+	}
+	if a.Behavior == "" {
+		a.Behavior = def.Behavior
+	}
+	if a.Timings.Rest == 0 {
+		a.Timings.Rest = def.Timings.Rest
+	}
+	if a.Timings.Dash == 0 {
+		a.Timings.Dash = def.Timings.Dash
+	}
+	if a.Timings.DashMultiplier == 0 {
+		a.Timings.DashMultiplier = def.Timings.DashMultiplier
+	}
+	if a.Timings.Wander == 0 {
+		a.Timings.Wander = def.Timings.Wander
+	}
+	if a.FollowDist == 0 {
+		a.FollowDist = def.FollowDist
+	}
+	if a.PreferredDist == 0 {
+		a.PreferredDist = def.PreferredDist
+	}
+	if a.Weapon != nil {
+		a.Weapon.applyDefaults()
+	}
+}
+
+func (a *EnemyArchetype) validate() error {
+	if a.MaxHealth <= 0 {
+		return fmt.Errorf("maxHealth phải > 0")
+	}
+	if a.Speed < 0 {
+		return fmt.Errorf("speed không được âm")
+	}
+	if a.Width <= 0 || a.Height <= 0 {
+		return fmt.Errorf("width và height phải > 0")
+	}
+	if a.ContactDamage < 0 {
+		return fmt.Errorf("contactDamage không được âm")
+	}
+	if a.FollowDist <= 0 || a.PreferredDist <= 0 {
+		return fmt.Errorf("followDist và preferredDist phải > 0")
+	}
+	t := a.Timings
+	if t.SpawnDelay < 0 {
+		return fmt.Errorf("timings.spawnDelay không được âm")
+	}
+	if t.Rest <= 0 || t.Dash <= 0 || t.DashMultiplier <= 0 || t.Wander <= 0 {
+		return fmt.Errorf("timings.rest, dash, dashMultiplier và wander phải > 0")
+	}
+	if _, ok := behaviorFactories[a.Behavior]; !ok {
+		return fmt.Errorf("behavior không hợp lệ %q", a.Behavior)
+	}
+	if a.Weapon != nil {
+		if err := a.Weapon.validate(); err != nil {
+			return fmt.Errorf("weapon: %w", err)
+		}
+	}
+	for _, d := range a.Drops {
+		if d.Item != DropPotion {
+			return fmt.Errorf("vật phẩm không hợp lệ %q", d.Item)
+		}
+		if d.Chance < 0 || d.Chance > 1 {
+			return fmt.Errorf("chance của %q phải trong khoảng 0..1", d.Item)
+		}
+	}
+	return nil
+}
+
+// NewEnemy tạo quái thuộc loại này tại vị trí (x, y)
+func (a *EnemyArchetype) NewEnemy(x, y float64) *Enemy {
+	e := NewEnemy(a.Sprite, x, y, a.MaxHealth, a.Speed, a.ContactDamage, a.FollowDist)
+	e.Archetype = a
+	e.Width = a.Width
+	e.Height = a.Height
+	e.SpawnTimer = a.Timings.SpawnDelay
+	e.Behavior = NewBehavior(a)
+	e.Weapon = a.Weapon
+	if a.Weapon != nil {
+		e.FireTimer = a.Weapon.Cooldown
+	}
+	return e
+}
+
+// hasArchetype cho biết archetypes có loại quái id (map rỗng chỉ có loại mặc định)
+func hasArchetype(archetypes map[string]*EnemyArchetype, id string) bool {
+	if len(archetypes) == 0 {
+		return id == DefaultArchetypeID
+	}
+	_, ok := archetypes[id]
+	return ok
+}
+
+// SortedArchetypeIDs trả về ID các loại quái theo thứ tự alphabet (để random xác định)
+func SortedArchetypeIDs(archetypes map[string]*EnemyArchetype) []string {
+	ids := make([]string, 0, len(archetypes))
+	for id := range archetypes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,57 @@
+package game
+
+import (
+	"testing"
+)
+
+// enemyFileWith tạo file quái có 1 loại quái hợp lệ cộng thêm các trường fields (JSON)
+func enemyFileWith(tb testing.TB, fields string) string {
+	tb.Helper()
+	if fields != "" {
+		fields = ", " + fields
+	}
+	return writeAsset(tb, "enemies.json", `{"enemies": [{"id": "a", "maxHealth": 10`+fields+`}]}`)
+}
+
+func TestLoadArchetypesRejects(t *testing.T) {
+	tests := []struct {
+		fields string
+		ok     bool
+	}{
+		{``, true},
+		{`"behavior": "chaser", "contactDamage": 0`, true},
+		{`"width": -4`, false},
+		{`"height": -1`, false},
+		{`"contactDamage": -5`, false},
+		{`"followDist": -10`, false},
+		{`"preferredDist": -10`, false},
+		{`"timings": {"spawnDelay": -1}`, false},
+		{`"timings": {"rest": -0.5}`, false},
+		{`"timings": {"dash": -1}`, false},
+		{`"timings": {"dashMultiplier": -2}`, false},
+		{`"timings": {"wander": -1}`, false},
+		{`"weapon": {"damage": 5}`, true},
+		{`"weapon": {"damage": 5, "cooldown": -1}`, false},
+		{`"weapon": {"damage": 5, "speed": -150}`, false},
+		{`"weapon": {"damage": 5, "range": -300}`, false},
+		{`"weapon": {"damage": 5, "pattern": "spread", "spread": -30}`, false},
+	}
+	for _, tt := range tests {
+		if _, err := LoadArchetypes(enemyFileWith(t, tt.fields)); (err == nil) != tt.ok {
+			t.Errorf("%s: lỗi = %v, muốn ok = %v", tt.fields, err, tt.ok)
+		}
+	}
+}
+
+// TestArchetypeFollowDistDefault: quái đuổi theo bỏ trống followDist vẫn phải thấy player
+func TestArchetypeFollowDistDefault(t *testing.T) {
+	for _, behavior := range []string{BehaviorChaser, BehaviorDasher} {
+		archetypes, err := LoadArchetypes(enemyFileWith(t, `"behavior": "`+behavior+`", "speed": 50`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := archetypes["a"].FollowDist, DefaultArchetype().FollowDist; got != want {
+			t.Errorf("%s: followDist = %v, muốn mặc định %v", behavior, got, want)
+		}
+	}
+}
@@ -0,0 +1,508 @@
+package game
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"strings"
+)
+
+// Các loại chunk trong file .aseprite được đọc (các loại khác bị bỏ qua)
+const (
+	aseChunkOldPalette = 0x0004
+	aseChunkLayer      = 0x2004
+	aseChunkCel        = 0x2005
+	aseChunkTags       = 0x2018
+	aseChunkPalette    = 0x2019
+)
+
+// Loại cel
+const (
+	aseCelRaw        = 0
+	aseCelLinked     = 1
+	aseCelCompressed = 2
+)
+
+// Giới hạn khi giải mã, để file hỏng không làm game cấp phát quá nhiều bộ nhớ
+const (
+	asePaletteMax   = 256     // File indexed chỉ có tối đa 256 màu
+	aseCelMaxPixels = 1 << 24 // Số pixel tối đa của 1 cel (64 MB ở 32 bit)
+)
+
+// Cờ của layer
+const (
+	aseLayerVisible   = 1
+	aseLayerReference = 64
+)
+
+// Hướng chạy của tag
+const (
+	aseTagForward = iota
+	aseTagReverse
+	aseTagPingPong
+	aseTagPingPongReverse
+)
+
+// AsepriteLayer là 1 layer của file .aseprite
+type AsepriteLayer struct {
+	Name    string
+	Visible bool // Đã tính cả layer cha (layer trong group ẩn cũng bị ẩn)
+	Group   bool
+	Level   int // Độ sâu trong cây group (0 = gốc)
+	Opacity uint8
+}
+
+// AsepriteCel là ảnh của 1 layer trong 1 frame, đặt tại (X, Y) trên canvas
+type AsepriteCel struct {
+	Layer   int
+	X, Y    int
+	Opacity uint8
+	Image   *image.NRGBA
+}
+
+// AsepriteFrame là 1 frame: thời gian hiển thị và các cel
+type AsepriteFrame struct {
+	Duration float64 // Giây
+	Cels     []*AsepriteCel
+}
+
+// AsepriteTag là 1 tag: đoạn frame [From, To] có tên, vd "Walk", "Attack01"
+type AsepriteTag struct {
+	Name      string
+	From, To  int
+	Direction int // aseTagForward, aseTagReverse, aseTagPingPong, aseTagPingPongReverse
+	Repeat    int // Số lần lặp, 0 = lặp mãi
+}
+
+// AsepriteFile là nội dung file .aseprite/.ase đã giải mã
+type AsepriteFile struct {
+	Width, Height int
+	Layers        []AsepriteLayer
+	Frames        []AsepriteFrame
+	Tags          []AsepriteTag
+
+	depth            int // Số bit mỗi pixel: 32 (RGBA), 16 (grayscale) hoặc 8 (indexed)
+	transparentIndex uint8
+	palette          []color.NRGBA
+	layerOpacity     bool // Cờ header: opacity của layer có hiệu lực
+}
+
+// LoadAseprite đọc file .aseprite/.ase
+func LoadAseprite(path string) (*AsepriteFile, error) {
+	contents, err := ReadAsset(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := ParseAseprite(contents)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return file, nil
+}
+
+// ParseAseprite giải mã nội dung file .aseprite theo đặc tả định dạng của Aseprite
+func ParseAseprite(data []byte) (*AsepriteFile, error) {
+	r := &aseReader{data: data}
+	r.u32() // Kích thước file
+	if magic := r.u16(); magic != 0xA5E0 {
+		return nil, fmt.Errorf("không phải file aseprite (magic %#x)", magic)
+	}
+	frames := int(r.u16())
+	f := &AsepriteFile{
+		Width:  int(r.u16()),
+		Height: int(r.u16()),
+		depth:  int(r.u16()),
+	}
+	f.layerOpacity = r.u32()&1 != 0
+	r.skip(2 + 4 + 4) // Speed (cũ), 2 DWORD luôn bằng 0
+	f.transparentIndex = r.u8()
+	r.skip(3)
+	r.u16() // Số màu
+	r.skip(128 - 34)
+	if r.err != nil {
+		return nil, r.err
+	}
+	if f.depth != 32 && f.depth != 16 && f.depth != 8 {
+		return nil, fmt.Errorf("độ sâu màu %d bit không được hỗ trợ", f.depth)
+	}
+
+	for i := 0; i < frames; i++ {
+		if err := f.readFrame(r); err != nil {
+			return nil, fmt.Errorf("frame %d: %w", i, err)
+		}
+	}
+	f.resolveVisibility()
+	return f, nil
+}
+
+// readFrame đọc 1 frame và các chunk bên trong
+func (f *AsepriteFile) readFrame(r *aseReader) error {
+	start := r.pos
+	size := int(r.u32())
+	if magic := r.u16(); magic != 0xF1FA {
+		return fmt.Errorf("sai magic của frame (%#x)", magic)
+	}
+	chunks := int(r.u16())
+	frame := AsepriteFrame{Duration: float64(r.u16()) / 1000}
+	r.skip(2)
+	if n := int(r.u32()); n != 0 {
+		chunks = n
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	for i := 0; i < chunks; i++ {
+		chunkStart := r.pos
+		chunkSize := int(r.u32())
+		kind := r.u16()
+		if r.err != nil || chunkSize < 6 || chunkStart+chunkSize > len(r.data) {
+			return fmt.Errorf("chunk %d bị cắt cụt", i)
+		}
+		body := &aseReader{data: r.data[r.pos : chunkStart+chunkSize]}
+		var err error
+		switch kind {
+		case aseChunkLayer:
+			f.readLayer(body)
+		case aseChunkCel:
+			err = f.readCel(body, &frame)
+		case aseChunkTags:
+			f.readTags(body)
+		case aseChunkPalette:
+			err = f.readPalette(body)
+		case aseChunkOldPalette:
+			if f.palette == nil {
+				err = f.readOldPalette(body)
+			}
+		}
+		if err == nil {
+			err = body.err
+		}
+		if err != nil {
+			return fmt.Errorf("chunk %#x: %w", kind, err)
+		}
+		r.pos = chunkStart + chunkSize
+	}
+	f.Frames = append(f.Frames, frame)
+	r.pos = start + size
+	return nil
+}
+
+func (f *AsepriteFile) readLayer(r *aseReader) {
+	flags := r.u16()
+	kind := r.u16()
+	level := int(r.u16())
+	r.skip(2 + 2 + 2) // Kích thước mặc định, blend mode (chỉ hỗ trợ normal)
+	opacity := r.u8()
+	r.skip(3)
+	name := r.str()
+	if !f.layerOpacity {
+		opacity = 255
+	}
+	f.Layers = append(f.Layers, AsepriteLayer{
+		Name: name,
+		// Layer tham chiếu (reference) chỉ để vẽ theo, không xuất ra ảnh
+		Visible: flags&aseLayerVisible != 0 && flags&aseLayerReference == 0,
+		Group:   kind == 1,
+		Level:   level,
+		Opacity: opacity,
+	})
+}
+
+func (f *AsepriteFile) readCel(r *aseReader, frame *AsepriteFrame) error {
+	cel := &AsepriteCel{Layer: int(r.u16())}
+	cel.X = int(int16(r.u16()))
+	cel.Y = int(int16(r.u16()))
+	cel.Opacity = r.u8()
+	kind := r.u16()
+	r.skip(2 + 5) // Z-index, reserved
+
+	switch kind {
+	case aseCelLinked:
+		// Cel dùng lại ảnh của cùng layer ở frame khác
+		linked := int(r.u16())
+		if linked >= len(f.Frames) {
+			return fmt.Errorf("cel liên kết tới frame %d chưa có", linked)
+		}
+		for _, c := range f.Frames[linked].Cels {
+			if c.Layer == cel.Layer {
+				copied := *c
+				copied.Opacity = cel.Opacity
+				frame.Cels = append(frame.Cels, &copied)
+				return nil
+			}
+		}
+		return nil
+	case aseCelRaw, aseCelCompressed:
+		w, h := int(r.u16()), int(r.u16())
+		if w*h > aseCelMaxPixels {
+			return fmt.Errorf("cel %dx%d quá lớn", w, h)
+		}
+		pixels := r.rest()
+		if kind == aseCelCompressed {
+			zr, err := zlib.NewReader(bytes.NewReader(pixels))
+			if err != nil {
+				return err
+			}
+			// Chỉ bung đúng số byte cel cần, phần thừa (nếu có) bị bỏ qua
+			if pixels, err = io.ReadAll(io.LimitReader(zr, int64(w*h*f.depth/8))); err != nil {
+				return err
+			}
+		}
+		img, err := f.celImage(pixels, w, h)
+		if err != nil {
+			return err
+		}
+		cel.Image = img
+		frame.Cels = append(frame.Cels, cel)
+		return nil
+	default:
+		// Cel tilemap chưa hỗ trợ
+		return nil
+	}
+}
+
+// celImage đổi pixel thô của cel (theo độ sâu màu của file) thành ảnh NRGBA
+func (f *AsepriteFile) celImage(pixels []byte, w, h int) (*image.NRGBA, error) {
+	bpp := f.depth / 8
+	if len(pixels) < w*h*bpp {
+		return nil, fmt.Errorf("cel %dx%d thiếu pixel (%d byte)", w, h, len(pixels))
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < w*h; i++ {
+		var c color.NRGBA
+		switch f.depth {
+		case 32:
+			c = color.NRGBA{pixels[i*4], pixels[i*4+1], pixels[i*4+2], pixels[i*4+3]}
+		case 16:
+			v := pixels[i*2]
+			c = color.NRGBA{v, v, v, pixels[i*2+1]}
+		case 8:
+			idx := pixels[i]
+			if idx != f.transparentIndex && int(idx) < len(f.palette) {
+				c = f.palette[idx]
+			}
+		}
+		copy(img.Pix[i*4:], []byte{c.R, c.G, c.B, c.A})
+	}
+	return img, nil
+}
+
+func (f *AsepriteFile) readTags(r *aseReader) {
+	n := int(r.u16())
+	r.skip(8)
+	for i := 0; i < n && r.err == nil; i++ {
+		tag := AsepriteTag{From: int(r.u16()), To: int(r.u16())}
+		tag.Direction = int(r.u8())
+		tag.Repeat = int(r.u16())
+		r.skip(6 + 3 + 1) // Reserved, màu tag (cũ)
+		tag.Name = r.str()
+		f.Tags = append(f.Tags, tag)
+	}
+}
+
+func (f *AsepriteFile) readPalette(r *aseReader) error {
+	size := int(r.u32())
+	first, last := int(r.u32()), int(r.u32())
+	r.skip(8)
+	if size > asePaletteMax {
+		return fmt.Errorf("palette %d màu, tối đa %d", size, asePaletteMax)
+	}
+	if first > last || last >= size {
+		return fmt.Errorf("palette có khoảng màu [%d, %d] ngoài %d màu", first, last, size)
+	}
+	if size > len(f.palette) {
+		f.palette = append(f.palette, make([]color.NRGBA, size-len(f.palette))...)
+	}
+	for i := first; i <= last && r.err == nil; i++ {
+		flags := r.u16()
+		f.palette[i] = color.NRGBA{r.u8(), r.u8(), r.u8(), r.u8()}
+		if flags&1 != 0 {
+			r.str() // Tên màu
+		}
+	}
+	return nil
+}
+
+// readOldPalette đọc palette kiểu cũ (chỉ dùng khi file không có chunk palette mới)
+func (f *AsepriteFile) readOldPalette(r *aseReader) error {
+	packets := int(r.u16())
+	idx := 0
+	for p := 0; p < packets && r.err == nil; p++ {
+		idx += int(r.u8())
+		count := int(r.u8())
+		if count == 0 {
+			count = 256
+		}
+		if idx+count > asePaletteMax {
+			return fmt.Errorf("palette cũ vượt quá %d màu", asePaletteMax)
+		}
+		for i := 0; i < count && r.err == nil; i, idx = i+1, idx+1 {
+			for idx >= len(f.palette) {
+				f.palette = append(f.palette, color.NRGBA{})
+			}
+			f.palette[idx] = color.NRGBA{r.u8(), r.u8(), r.u8(), 255}
+		}
+	}
+	return nil
+}
+
+// resolveVisibility ẩn các layer nằm trong group bị ẩn
+func (f *AsepriteFile) resolveVisibility() {
+	var parents []bool // parents[level] = group ở độ sâu level có hiện không
+	for i := range f.Layers {
+		l := &f.Layers[i]
+		if l.Level > 0 && l.Level <= len(parents) && !parents[l.Level-1] {
+			l.Visible = false
+		}
+		if l.Group {
+			parents = append(parents[:min(l.Level, len(parents))], l.Visible)
+		}
+	}
+}
+
+// FrameImage ghép các layer đang hiện của frame i thành 1 ảnh kích thước canvas
+func (f *AsepriteFile) FrameImage(i int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, f.Width, f.Height))
+	f.drawFrame(img, i, 0, 0)
+	return img
+}
+
+// drawFrame vẽ frame i lên dst tại (ox, oy), layer dưới vẽ trước
+func (f *AsepriteFile) drawFrame(dst draw.Image, i, ox, oy int) {
+	for layer := range f.Layers {
+		if !f.Layers[layer].Visible || f.Layers[layer].Group {
+			continue
+		}
+		for _, cel := range f.Frames[i].Cels {
+			if cel.Layer != layer || cel.Image == nil {
+				continue
+			}
+			opacity := int(cel.Opacity) * int(f.Layers[layer].Opacity) / 255
+			rect := cel.Image.Bounds().Add(image.Pt(ox+cel.X, oy+cel.Y))
+			// Cel có thể tràn ra ngoài canvas, phần tràn bị cắt bỏ
+			rect = rect.Intersect(image.Rect(ox, oy, ox+f.Width, oy+f.Height))
+			mask := image.NewUniform(color.Alpha{uint8(opacity)})
+			draw.DrawMask(dst, rect, cel.Image, rect.Min.Sub(image.Pt(ox+cel.X, oy+cel.Y)), mask, image.Point{}, draw.Over)
+		}
+	}
+}
+
+// Sheet ghép mọi frame thành 1 dải ảnh nằm ngang (frame i ở x = i*Width)
+func (f *AsepriteFile) Sheet() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, f.Width*len(f.Frames), f.Height))
+	for i := range f.Frames {
+		f.drawFrame(img, i, i*f.Width, 0)
+	}
+	return img
+}
+
+// Clips tạo bộ clip từ các tag, frame lấy trên dải ảnh của Sheet.
+// Tên clip là tên tag viết thường ("Walk" -> "walk"); tag có số ở cuối ("Attack01")
+// còn được đặt thêm tên không số ("attack") nếu chưa có tag nào tên đó.
+// Tag "Hurt"/"Death"/"Attack..." chạy 1 lượt, các tag khác lặp lại. Số lần lặp (Repeat)
+// của tag chỉ có tác dụng khi xem thử trong Aseprite nên không được dùng.
+// File không có tag thì cả dải frame là clip idle.
+func (f *AsepriteFile) Clips() ClipSet {
+	clips := ClipSet{}
+	if len(f.Tags) == 0 && len(f.Frames) > 0 {
+		clips[ClipIdle] = f.clip(ClipIdle, AsepriteTag{To: len(f.Frames) - 1})
+		return clips
+	}
+
+	aliases := map[string]string{}
+	for _, tag := range f.Tags {
+		name := strings.ToLower(tag.Name)
+		clips[name] = f.clip(name, tag)
+		if base := strings.TrimRight(name, "0123456789"); base != name && base != "" {
+			if _, ok := aliases[base]; !ok {
+				aliases[base] = name
+			}
+		}
+	}
+	for base, name := range aliases {
+		if _, ok := clips[base]; !ok {
+			clips[base] = clips[name]
+		}
+	}
+	return clips
+}
+
+// clip tạo clip từ 1 tag theo hướng chạy của tag
+func (f *AsepriteFile) clip(name string, tag AsepriteTag) *Animation {
+	var order []int
+	for i := tag.From; i <= tag.To && i < len(f.Frames); i++ {
+		order = append(order, i)
+	}
+	reverse := func(s []int) []int {
+		out := make([]int, len(s))
+		for i, v := range s {
+			out[len(s)-1-i] = v
+		}
+		return out
+	}
+	switch tag.Direction {
+	case aseTagReverse:
+		order = reverse(order)
+	case aseTagPingPong, aseTagPingPongReverse:
+		if tag.Direction == aseTagPingPongReverse {
+			order = reverse(order)
+		}
+		// Đi rồi về, không lặp frame ở 2 đầu
+		if len(order) > 2 {
+			order = append(order, reverse(order[1:len(order)-1])...)
+		}
+	}
+
+	anim := &Animation{Name: name, Loop: !oneShotClip(name)}
+	for _, i := range order {
+		anim.Frames = append(anim.Frames, AnimFrame{
+			X: i * f.Width, Y: 0, W: f.Width, H: f.Height,
+			Duration: f.Frames[i].Duration,
+		})
+	}
+	return anim
+}
+
+// oneShotClip kiểm tra clip có phải loại chỉ chạy 1 lượt (đánh, bị đánh, chết)
+func oneShotClip(name string) bool {
+	for _, prefix := range []string{ClipAttack, ClipHurt, ClipDeath} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// aseReader đọc số little-endian từ buffer. Đọc quá cuối buffer thì ghi nhận lỗi
+// và trả về 0, nên nơi gọi chỉ cần kiểm tra err sau cả loạt lần đọc.
+type aseReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *aseReader) take(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+	if n < 0 || r.pos+n > len(r.data) {
+		r.err = io.ErrUnexpectedEOF
+		return make([]byte, max(n, 0))
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *aseReader) skip(n int)   { r.take(n) }
+func (r *aseReader) u8() uint8    { return r.take(1)[0] }
+func (r *aseReader) u16() uint16  { return binary.LittleEndian.Uint16(r.take(2)) }
+func (r *aseReader) u32() uint32  { return binary.LittleEndian.Uint32(r.take(4)) }
+func (r *aseReader) str() string  { return string(r.take(int(r.u16()))) }
+func (r *aseReader) rest() []byte { return r.take(len(r.data) - r.pos) }
@@ -0,0 +1,302 @@
+package game
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// Bộ nhân vật gốc: file .aseprite và các dải PNG đã xuất từ chính file đó
+const asePackDir = "../Character-Asset-Pack-v1.03-Soldier&Orc/"
+
+// TestAsepriteMatchesExportedStrips đọc file .aseprite của bộ nhân vật và so với dải PNG xuất sẵn:
+// số frame, thời gian frame, tag thành clip, và ảnh ghép các layer phải khớp từng pixel
+func TestAsepriteMatchesExportedStrips(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames int
+		clips  []string // Tên clip theo thứ tự tag
+	}{
+		{"Orc", 34, []string{"idle", "walk", "attack01", "attack02", "hurt", "death"}},
+		{"Soldier", 43, []string{"idle", "walk", "attack01", "attack02", "attack03", "hurt", "death"}},
+	}
+	for _, tt := range tests {
+		f, err := LoadAseprite(asePackDir + "Aseprite file/" + tt.name + ".aseprite")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Width != 100 || f.Height != 100 || len(f.Frames) != tt.frames {
+			t.Fatalf("%s: canvas %dx%d, %d frame, muốn 100x100, %d frame", tt.name, f.Width, f.Height, len(f.Frames), tt.frames)
+		}
+		// Frame cuối (chết) giữ lâu hơn, các frame khác 0.1 giây
+		for i, frame := range f.Frames {
+			want := 0.1
+			if i == len(f.Frames)-1 {
+				want = 0.6
+			}
+			if !nearly(frame.Duration, want) {
+				t.Errorf("%s: frame %d dài %.3f giây, muốn %.3f", tt.name, i, frame.Duration, want)
+			}
+		}
+
+		clips := f.Clips()
+		if clips[ClipAttack] != clips["attack01"] {
+			t.Errorf("%s: clip attack phải là attack01", tt.name)
+		}
+		sheet := f.Sheet()
+		withShadow := f.Layers[0].Visible
+		for i, name := range tt.clips {
+			tag := f.Tags[i]
+			clip := clips[name]
+			if clip == nil {
+				t.Fatalf("%s: thiếu clip %q", tt.name, name)
+			}
+			if clip.Loop == oneShotClip(name) {
+				t.Errorf("%s: clip %q Loop = %v", tt.name, name, clip.Loop)
+			}
+			if len(clip.Frames) != tag.To-tag.From+1 {
+				t.Fatalf("%s: clip %q có %d frame, tag có %d", tt.name, name, len(clip.Frames), tag.To-tag.From+1)
+			}
+			for k, fr := range clip.Frames {
+				want := AnimFrame{X: (tag.From + k) * 100, W: 100, H: 100, Duration: f.Frames[tag.From+k].Duration}
+				if fr != want {
+					t.Errorf("%s: clip %q frame %d = %+v, muốn %+v", tt.name, name, k, fr, want)
+				}
+			}
+
+			// Dải xuất có bóng gồm mọi layer, dải không bóng bỏ layer shadow
+			file := tt.name + "-" + strings.ToUpper(name[:1]) + name[1:] + ".png"
+			strip := subImage(sheet, tag.From*100, (tag.To+1)*100)
+			comparePNG(t, strip, asePackDir+"Characters(100x100)/"+tt.name+"/"+tt.name+" with shadows/"+file)
+
+			f.Layers[0].Visible = false
+			strip = subImage(f.Sheet(), tag.From*100, (tag.To+1)*100)
+			comparePNG(t, strip, asePackDir+"Characters(100x100)/"+tt.name+"/"+tt.name+"/"+file)
+			f.Layers[0].Visible = withShadow
+		}
+	}
+}
+
+// subImage cắt cột [x0, x1) của sheet thành ảnh mới bắt đầu từ (0, 0)
+func subImage(sheet *image.NRGBA, x0, x1 int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, x1-x0, sheet.Bounds().Dy()))
+	draw.Draw(img, img.Bounds(), sheet, image.Pt(x0, 0), draw.Src)
+	return img
+}
+
+// comparePNG so ảnh với file PNG, pixel trong suốt hoàn toàn được coi là giống nhau bất kể màu
+func comparePNG(t *testing.T, got *image.NRGBA, path string) {
+	t.Helper()
+	fh, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+	want, err := png.Decode(fh)
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	if want.Bounds().Size() != got.Bounds().Size() {
+		t.Fatalf("%s: kích thước %v, muốn %v", path, got.Bounds().Size(), want.Bounds().Size())
+	}
+	b := want.Bounds()
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			w := color.NRGBAModel.Convert(want.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			g := got.NRGBAAt(x, y)
+			if w != g && (w.A != 0 || g.A != 0) {
+				t.Fatalf("%s: pixel (%d, %d) = %v, muốn %v", path, x, y, g, w)
+			}
+		}
+	}
+}
+
+// aseWriter dựng file .aseprite nhỏ trong bộ nhớ để thử các trường hợp bộ nhân vật không có
+type aseWriter struct {
+	bytes.Buffer
+}
+
+func (w *aseWriter) put(vs ...any) *aseWriter {
+	for _, v := range vs {
+		switch v := v.(type) {
+		case string:
+			binary.Write(w, binary.LittleEndian, uint16(len(v)))
+			w.WriteString(v)
+		case []byte:
+			w.Write(v)
+		default:
+			binary.Write(w, binary.LittleEndian, v)
+		}
+	}
+	return w
+}
+
+// aseChunk ghi 1 chunk gồm các trường vs
+func aseChunk(kind uint16, vs ...any) []byte {
+	var body aseWriter
+	body.put(vs...)
+	var c aseWriter
+	c.put(uint32(6+body.Len()), kind, body.Bytes())
+	return c.Bytes()
+}
+
+// aseBytes ghi file canvas w x h, độ sâu màu depth, mỗi frame dài 100 ms gồm các chunk cho trước
+func aseBytes(w, h, depth int, transparent uint8, frames ...[][]byte) []byte {
+	var body aseWriter
+	for _, chunks := range frames {
+		var data []byte
+		for _, c := range chunks {
+			data = append(data, c...)
+		}
+		body.put(uint32(16+len(data)), uint16(0xF1FA), uint16(len(chunks)), uint16(100), uint16(0), uint32(len(chunks)), data)
+	}
+	var f aseWriter
+	f.put(uint32(128+body.Len()), uint16(0xA5E0), uint16(len(frames)), uint16(w), uint16(h), uint16(depth),
+		uint32(1), uint16(100), uint32(0), uint32(0), transparent, [3]byte{}, uint16(0), make([]byte, 128-34))
+	f.Write(body.Bytes())
+	return f.Bytes()
+}
+
+// aseLayer ghi chunk layer thường (hiện) hoặc group
+func aseLayer(name string, group bool, level int, visible bool) []byte {
+	var flags, kind uint16
+	if visible {
+		flags = aseLayerVisible
+	}
+	if group {
+		kind = 1
+	}
+	return aseChunk(aseChunkLayer, flags, kind, uint16(level), uint16(0), uint16(0), uint16(0), uint8(255), [3]byte{}, name)
+}
+
+// aseCel ghi chunk cel ảnh w x h đặt tại (x, y), nén zlib nếu compressed
+func aseCel(layer, x, y, w, h int, pixels []byte, compressed bool) []byte {
+	kind := uint16(aseCelRaw)
+	if compressed {
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(pixels)
+		zw.Close()
+		pixels, kind = z.Bytes(), aseCelCompressed
+	}
+	return aseChunk(aseChunkCel, uint16(layer), int16(x), int16(y), uint8(255), kind, int16(0), [5]byte{},
+		uint16(w), uint16(h), pixels)
+}
+
+// asePalette ghi chunk palette size màu, đặt các màu [first, first+len(colors))
+func asePalette(size, first int, colors ...color.NRGBA) []byte {
+	vs := []any{uint32(size), uint32(first), uint32(first + len(colors) - 1), [8]byte{}}
+	for _, c := range colors {
+		vs = append(vs, uint16(0), c.R, c.G, c.B, c.A)
+	}
+	return aseChunk(aseChunkPalette, vs...)
+}
+
+func TestAsepriteIndexedAndGroups(t *testing.T) {
+	red, blue := color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 0, 255, 255}
+	data := aseBytes(2, 2, 8, 0,
+		[][]byte{
+			asePalette(3, 0, color.NRGBA{}, red, blue),
+			aseLayer("bg", false, 0, true),
+			aseLayer("nhom an", true, 0, false),
+			aseLayer("trong nhom", false, 1, true),
+			aseCel(0, 0, 0, 2, 2, []byte{1, 0, 2, 1}, true),
+			aseCel(2, 0, 0, 2, 2, []byte{2, 2, 2, 2}, false),
+		},
+		// Frame 2 dùng lại cel của layer bg ở frame 1
+		[][]byte{aseChunk(aseChunkCel, uint16(0), int16(1), int16(0), uint8(255), uint16(aseCelLinked), int16(0), [5]byte{}, uint16(0))},
+	)
+	f, err := ParseAseprite(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Layers[2].Visible {
+		t.Error("layer trong group ẩn phải bị ẩn")
+	}
+	// Layer trong group ẩn không được vẽ, chỉ số 0 là trong suốt
+	img := f.FrameImage(0)
+	want := []color.NRGBA{red, {}, blue, red}
+	for i, c := range want {
+		if got := img.NRGBAAt(i%2, i/2); got != c {
+			t.Errorf("pixel %d = %v, muốn %v", i, got, c)
+		}
+	}
+	if len(f.Frames[1].Cels) != 1 || f.Frames[1].Cels[0].Image != f.Frames[0].Cels[0].Image {
+		t.Error("cel liên kết phải dùng lại ảnh của frame 1")
+	}
+	if clips := f.Clips(); len(clips) != 1 || len(clips[ClipIdle].Frames) != 2 {
+		t.Errorf("file không có tag phải cho clip idle 2 frame: %+v", clips)
+	}
+}
+
+func TestAsepriteGrayscale(t *testing.T) {
+	data := aseBytes(2, 1, 16, 0, [][]byte{
+		aseLayer("xam", false, 0, true),
+		aseCel(0, 0, 0, 2, 1, []byte{200, 255, 50, 128}, false),
+	})
+	f, err := ParseAseprite(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := f.FrameImage(0)
+	if got := img.NRGBAAt(0, 0); got != (color.NRGBA{200, 200, 200, 255}) {
+		t.Errorf("pixel 0 = %v", got)
+	}
+	if got := img.NRGBAAt(1, 0); got.R != got.G || got.G != got.B || got.A != 128 {
+		t.Errorf("pixel 1 = %v, muốn xám với alpha 128", got)
+	}
+}
+
+func TestAsepriteRejectsMalformed(t *testing.T) {
+	layer := aseLayer("l", false, 0, true)
+	many := make([]color.NRGBA, asePaletteMax+1)
+	tests := []struct {
+		name   string
+		chunks [][]byte
+	}{
+		{"palette quá 256 màu", [][]byte{asePalette(asePaletteMax+1, 0, many...)}},
+		{"palette khai báo khổng lồ", [][]byte{asePalette(1<<31, 0, color.NRGBA{})}},
+		{"khoảng màu ngoài palette", [][]byte{asePalette(4, 3, color.NRGBA{}, color.NRGBA{})}},
+		{"palette cũ quá 256 màu", [][]byte{aseChunk(aseChunkOldPalette, uint16(2), uint8(200), uint8(100), bytes.Repeat([]byte{1}, 300))}},
+		{"cel khổng lồ", [][]byte{layer, aseCel(0, 0, 0, 65535, 65535, nil, true)}},
+		{"cel thiếu pixel", [][]byte{layer, aseCel(0, 0, 0, 4, 4, make([]byte, 10), false)}},
+		{"cel nén thiếu pixel", [][]byte{layer, aseCel(0, 0, 0, 4, 4, make([]byte, 10), true)}},
+		{"chunk cắt cụt", [][]byte{layer[:len(layer)-1]}},
+	}
+	for _, tt := range tests {
+		if _, err := ParseAseprite(aseBytes(4, 4, 32, 0, tt.chunks)); err == nil {
+			t.Errorf("%s: không báo lỗi", tt.name)
+		}
+	}
+}
+
+// TestAsepriteInflateLimit kiểm tra cel nén chứa nhiều dữ liệu hơn kích thước cel chỉ được bung
+// đúng số byte cần, không đọc hết cả khối
+func TestAsepriteInflateLimit(t *testing.T) {
+	pixels := bytes.Repeat([]byte{9, 8, 7, 255}, 64<<20/4) // 64 MB cho 1 cel 2x2
+	data := aseBytes(2, 2, 32, 0, [][]byte{
+		aseLayer("l", false, 0, true),
+		aseCel(0, 0, 0, 2, 2, pixels, true),
+	})
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	f, err := ParseAseprite(data)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.FrameImage(0).NRGBAAt(1, 1); got != (color.NRGBA{9, 8, 7, 255}) {
+		t.Errorf("pixel = %v", got)
+	}
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Errorf("giải mã cel 2x2 cấp phát %d byte", n)
+	}
+}
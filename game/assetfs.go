@@ -0,0 +1,51 @@
+package game
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// assetFS là hệ thống file mà các hàm Load* đọc dữ liệu (nil = đọc thẳng từ đĩa)
+var assetFS fs.FS
+
+// SetAssetFS đặt hệ thống file cho các hàm Load* (embed.FS, bundle zip, os.DirFS...).
+// Đường dẫn vẫn viết như trên đĩa ("assets/data/enemies.json") và được tra tương đối
+// so với gốc của fsys. nil = đọc lại từ đĩa.
+func SetAssetFS(fsys fs.FS) {
+	assetFS = fsys
+}
+
+// AssetPath đổi đường dẫn trên đĩa thành đường dẫn hợp lệ trong fs.FS (dấu /, không có "./")
+func AssetPath(p string) string {
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(p)), "./")
+}
+
+// ReadAsset đọc nội dung 1 file asset
+func ReadAsset(p string) ([]byte, error) {
+	if assetFS == nil {
+		return os.ReadFile(p)
+	}
+	return fs.ReadFile(assetFS, AssetPath(p))
+}
+
+// OpenAsset mở 1 file asset để đọc
+func OpenAsset(p string) (fs.File, error) {
+	if assetFS == nil {
+		return os.Open(p)
+	}
+	return assetFS.Open(AssetPath(p))
+}
+
+// AssetExists kiểm tra file asset có tồn tại không
+func AssetExists(p string) bool {
+	var err error
+	if assetFS == nil {
+		_, err = os.Stat(p)
+	} else {
+		_, err = fs.Stat(assetFS, AssetPath(p))
+	}
+	return err == nil
+}
@@ -0,0 +1,186 @@
+package game
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// Các kiểu AI của quái (giá trị "behavior" trong file dữ liệu)
+const (
+	BehaviorChaser   = "chaser"
+	BehaviorDasher   = "dasher"
+	BehaviorKiter    = "kiter"
+	BehaviorWanderer = "wanderer"
+	BehaviorTurret   = "turret"
+)
+
+// BehaviorContext là những gì AI của quái biết về thế giới trong 1 bước mô phỏng.
+// Test có thể tự dựng context với vị trí player giả để kiểm tra từng Behavior.
+type BehaviorContext struct {
+	DT            float64 // Delta time của bước (giây)
+	PlayerX       float64
+	PlayerY       float64
+	PlayerCenterX float64 // Tâm player, dùng để nhắm bắn
+	PlayerCenterY float64
+	MapWidth      float64
+	MapHeight     float64
+	Collision     *CollisionGrid // Tường của map (nil = không có tường)
+	Rand          *rand.Rand
+
+	Shots   []*Projectile   // Đạn quái bắn ra trong bước này
+	Summons []SummonRequest // Quái con được gọi ra trong bước này
+}
+
+// Behavior là AI điều khiển 1 con quái.
+// Mỗi con quái có instance riêng nên Behavior được phép giữ trạng thái.
+type Behavior interface {
+	Update(e *Enemy, ctx *BehaviorContext)
+}
+
+// behaviorFactories tạo Behavior theo tên trong file dữ liệu
+var behaviorFactories = map[string]func(a *EnemyArchetype) Behavior{
+	BehaviorChaser:   func(a *EnemyArchetype) Behavior { return &Chaser{} },
+	BehaviorDasher:   func(a *EnemyArchetype) Behavior { return NewDasher(a.Timings) },
+	BehaviorKiter:    func(a *EnemyArchetype) Behavior { return &Kiter{PreferredDist: a.PreferredDist} },
+	BehaviorWanderer: func(a *EnemyArchetype) Behavior { return &Wanderer{Interval: a.Timings.Wander} },
+	BehaviorTurret:   func(a *EnemyArchetype) Behavior { return &Turret{} },
+}
+
+// NewBehavior tạo Behavior cho loại quái, nil nếu tên behavior không tồn tại
+func NewBehavior(a *EnemyArchetype) Behavior {
+	factory, ok := behaviorFactories[a.Behavior]
+	if !ok {
+		return nil
+	}
+	return factory(a)
+}
+
+// directionTo trả về hướng đã chuẩn hóa và khoảng cách từ enemy tới player
+func directionTo(e *Enemy, ctx *BehaviorContext) (dx, dy, distance float64) {
+	dx = ctx.PlayerX - e.X
+	dy = ctx.PlayerY - e.Y
+	distance = math.Sqrt(dx*dx + dy*dy)
+	if distance > 0 {
+		dx /= distance
+		dy /= distance
+	}
+	return dx, dy, distance
+}
+
+// Chaser luôn đuổi thẳng theo player khi player trong tầm nhìn
+type Chaser struct{}
+
+func (c *Chaser) Update(e *Enemy, ctx *BehaviorContext) {
+	dx, dy, distance := directionTo(e, ctx)
+	if distance < e.FollowDist && distance > 0 {
+		e.Move(dx, dy, e.Speed*ctx.DT, ctx)
+	}
+}
+
+// Trạng thái của Dasher
+const (
+	StateRest = 0
+	StateDash = 1
+)
+
+// Dasher nghỉ rồi lao tới player theo chu kỳ
+type Dasher struct {
+	State   int     // StateRest hoặc StateDash
+	Timer   float64 // Bộ đếm thời gian cho trạng thái hiện tại
+	Timings EnemyTimings
+}
+
+// NewDasher tạo Dasher bắt đầu ở trạng thái nghỉ, sẵn sàng lao tới ngay
+func NewDasher(timings EnemyTimings) *Dasher {
+	return &Dasher{State: StateRest, Timings: timings}
+}
+
+func (d *Dasher) Update(e *Enemy, ctx *BehaviorContext) {
+	// 1. Cập nhật bộ đếm thời gian
+	d.Timer -= ctx.DT
+
+	// 2. Kiểm tra đổi trạng thái
+	if d.Timer <= 0 {
+		if d.State == StateRest { // Nếu đang nghỉ -> Chuyển sang Lao tới
+			d.State = StateDash
+			d.Timer = d.Timings.Dash // Lao tới trong 1.2 giây (mặc định)
+		} else { // Nếu đang lao tới -> Chuyển sang Nghỉ
+			d.State = StateRest
+			d.Timer = d.Timings.Rest // Nghỉ trong 0.8 giây (mặc định)
+		}
+	}
+
+	// 3. Chỉ di chuyển khi ở trạng thái Lao tới, lúc nghỉ quái đứng yên tại chỗ
+	if d.State != StateDash {
+		return
+	}
+	dx, dy, distance := directionTo(e, ctx)
+
+	// Chỉ lao tới nếu trong tầm nhìn (FollowDist)
+	if distance < e.FollowDist && distance > 0 {
+		// Khi lao tới, tăng tốc độ lên một chút (mặc định e.Speed * 1.8)
+		e.Move(dx, dy, e.Speed*d.Timings.DashMultiplier*ctx.DT, ctx)
+	}
+}
+
+// Kiter giữ khoảng cách PreferredDist với player: lại gần khi quá xa,
+// lùi lại khi quá gần và đi vòng quanh player khi đúng tầm
+type Kiter struct {
+	PreferredDist float64
+}
+
+// kiterTolerance là khoảng sai số (px) quanh PreferredDist mà Kiter coi là đúng tầm
+const kiterTolerance = 24.0
+
+func (k *Kiter) Update(e *Enemy, ctx *BehaviorContext) {
+	dx, dy, distance := directionTo(e, ctx)
+	if distance >= e.FollowDist || distance == 0 {
+		return
+	}
+
+	step := e.Speed * ctx.DT
+	switch {
+	case distance > k.PreferredDist+kiterTolerance:
+		e.Move(dx, dy, step, ctx)
+	case distance < k.PreferredDist-kiterTolerance:
+		e.Move(-dx, -dy, step, ctx)
+	default:
+		// Đi vòng (vuông góc với hướng tới player) cho khó bắn
+		e.Move(-dy, dx, step*0.5, ctx)
+	}
+}
+
+// Wanderer đi lang thang theo hướng ngẫu nhiên, đổi hướng sau mỗi Interval giây
+type Wanderer struct {
+	Interval float64
+	Timer    float64
+	DirX     float64
+	DirY     float64
+}
+
+func (w *Wanderer) Update(e *Enemy, ctx *BehaviorContext) {
+	w.Timer -= ctx.DT
+	if w.Timer <= 0 {
+		w.Timer = w.Interval
+		if ctx.Rand != nil {
+			angle := ctx.Rand.Float64() * 2 * math.Pi
+			w.DirX, w.DirY = math.Cos(angle), math.Sin(angle)
+		}
+	}
+
+	prevX, prevY := e.X, e.Y
+	e.Move(w.DirX, w.DirY, e.Speed*ctx.DT, ctx)
+
+	// Chạm mép bản đồ thì quay đầu
+	if e.X == prevX {
+		w.DirX = -w.DirX
+	}
+	if e.Y == prevY {
+		w.DirY = -w.DirY
+	}
+}
+
+// Turret đứng yên tại chỗ (thường đi kèm vũ khí bắn xa)
+type Turret struct{}
+
+func (t *Turret) Update(e *Enemy, ctx *BehaviorContext) {}
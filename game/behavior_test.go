@@ -0,0 +1,155 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+const testDT = 1.0 / DefaultTickRate
+
+// testEnemy tạo quái đứng ở (x, y) dùng AI của loại quái mặc định với behavior cho trước, bỏ qua thời gian chờ sinh
+func testEnemy(behavior string, x, y float64) *Enemy {
+	a := DefaultArchetype()
+	a.Behavior = behavior
+	e := a.NewEnemy(x, y)
+	e.SpawnTimer = 0
+	return e
+}
+
+// stepBehavior chạy 1 bước AI với player giả ở (px, py) và trả về vận tốc (px/giây) của quái
+func stepBehavior(e *Enemy, px, py float64, ctx *BehaviorContext) (vx, vy float64) {
+	ctx.DT = testDT
+	ctx.PlayerX, ctx.PlayerY = px, py
+	ctx.PlayerCenterX, ctx.PlayerCenterY = px+8, py+8
+	x, y := e.X, e.Y
+	e.Behavior.Update(e, ctx)
+	return (e.X - x) / testDT, (e.Y - y) / testDT
+}
+
+func newTestContext() *BehaviorContext {
+	return &BehaviorContext{MapWidth: 4000, MapHeight: 4000, Rand: NewRand(1)}
+}
+
+func nearly(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestBehaviorVelocity(t *testing.T) {
+	a := DefaultArchetype()
+	speed, dash := a.Speed, a.Speed*a.Timings.DashMultiplier
+	// Quái luôn đứng ở (500, 500), PreferredDist của Kiter là 160
+	tests := []struct {
+		name     string
+		behavior string
+		px, py   float64
+		vx, vy   float64
+	}{
+		{"chaser đuổi sang phải", BehaviorChaser, 700, 500, speed, 0},
+		{"chaser đuổi chéo", BehaviorChaser, 400, 400, -speed / math.Sqrt2, -speed / math.Sqrt2},
+		{"chaser ngoài tầm nhìn", BehaviorChaser, 1000, 500, 0, 0},
+		{"chaser đứng trùng player", BehaviorChaser, 500, 500, 0, 0},
+		{"dasher lao ngay bước đầu", BehaviorDasher, 500, 300, 0, -dash},
+		{"kiter lại gần khi xa", BehaviorKiter, 800, 500, speed, 0},
+		{"kiter lùi khi gần", BehaviorKiter, 500, 560, 0, -speed},
+		{"kiter đi vòng khi đúng tầm", BehaviorKiter, 660, 500, 0, speed / 2},
+		{"kiter ngoài tầm nhìn", BehaviorKiter, 1000, 500, 0, 0},
+		{"turret đứng yên", BehaviorTurret, 520, 500, 0, 0},
+	}
+	for _, tt := range tests {
+		e := testEnemy(tt.behavior, 500, 500)
+		vx, vy := stepBehavior(e, tt.px, tt.py, newTestContext())
+		if !nearly(vx, tt.vx) || !nearly(vy, tt.vy) {
+			t.Errorf("%s: vận tốc (%.3f, %.3f), muốn (%.3f, %.3f)", tt.name, vx, vy, tt.vx, tt.vy)
+		}
+	}
+}
+
+// TestChaserFollowsMovingPlayer cho player chạy vòng quanh quái, mỗi bước quái phải đi thẳng về phía player
+func TestChaserFollowsMovingPlayer(t *testing.T) {
+	e := testEnemy(BehaviorChaser, 500, 500)
+	ctx := newTestContext()
+	for i := 0; i < 240; i++ {
+		angle := float64(i) * 0.05
+		px, py := 500+200*math.Cos(angle), 500+200*math.Sin(angle)
+		dx, dy := px-e.X, py-e.Y
+		d := math.Hypot(dx, dy)
+		vx, vy := stepBehavior(e, px, py, ctx)
+		if !nearly(vx, e.Speed*dx/d) || !nearly(vy, e.Speed*dy/d) {
+			t.Fatalf("bước %d: vận tốc (%.3f, %.3f) không hướng về player (%.1f, %.1f)", i, vx, vy, px, py)
+		}
+	}
+}
+
+// TestWandererIgnoresPlayer kiểm tra Wanderer đi đều tốc độ, giữ hướng trong Interval rồi mới đổi,
+// và không phụ thuộc vị trí player
+func TestWandererIgnoresPlayer(t *testing.T) {
+	a, b := testEnemy(BehaviorWanderer, 500, 500), testEnemy(BehaviorWanderer, 500, 500)
+	ctxA, ctxB := newTestContext(), newTestContext()
+	interval := a.Archetype.Timings.Wander
+	var lastX, lastY float64
+	changes := 0
+	steps := int(math.Round(3 * interval / testDT))
+	for i := 0; i < steps; i++ {
+		vx, vy := stepBehavior(a, 600, 500, ctxA)
+		wx, wy := stepBehavior(b, 100, 900, ctxB)
+		if !nearly(vx, wx) || !nearly(vy, wy) {
+			t.Fatalf("bước %d: vận tốc phụ thuộc player: (%.3f, %.3f) và (%.3f, %.3f)", i, vx, vy, wx, wy)
+		}
+		if !nearly(math.Hypot(vx, vy), a.Speed) {
+			t.Fatalf("bước %d: tốc độ %.3f, muốn %.3f", i, math.Hypot(vx, vy), a.Speed)
+		}
+		if i > 0 && (!nearly(vx, lastX) || !nearly(vy, lastY)) {
+			changes++
+		}
+		lastX, lastY = vx, vy
+	}
+	// Đổi hướng mỗi Interval giây: bước đầu chọn hướng, rồi đổi thêm 2 lần
+	if changes != 2 {
+		t.Errorf("đổi hướng %d lần trong %.1f giây, muốn 2", changes, 3*interval)
+	}
+}
+
+// TestDasherCycle kiểm tra chu kỳ lao tới Dash giây rồi nghỉ Rest giây của Dasher
+func TestDasherCycle(t *testing.T) {
+	e := testEnemy(BehaviorDasher, 2000, 2000)
+	d := e.Behavior.(*Dasher)
+	ctx := newTestContext()
+	timings := d.Timings
+
+	// Đo độ dài (giây) của từng đoạn lao/nghỉ liên tiếp, player luôn ở phía trên trong tầm nhìn
+	type phase struct {
+		dashing  bool
+		duration float64
+	}
+	var phases []phase
+	for i := 0; i < int(math.Round(3*(timings.Dash+timings.Rest)/testDT)); i++ {
+		vx, vy := stepBehavior(e, e.X, e.Y-300, ctx)
+		speed := math.Hypot(vx, vy)
+		dashing := speed > 0
+		if dashing && !nearly(speed, e.Speed*timings.DashMultiplier) {
+			t.Fatalf("bước %d: tốc độ lao %.3f, muốn %.3f", i, speed, e.Speed*timings.DashMultiplier)
+		}
+		if dashing != (d.State == StateDash) {
+			t.Fatalf("bước %d: di chuyển = %v nhưng State = %d", i, dashing, d.State)
+		}
+		if n := len(phases); n > 0 && phases[n-1].dashing == dashing {
+			phases[n-1].duration += testDT
+		} else {
+			phases = append(phases, phase{dashing, testDT})
+		}
+	}
+
+	if len(phases) < 5 || !phases[0].dashing {
+		t.Fatalf("chu kỳ sai: %+v", phases)
+	}
+	// Bỏ đoạn cuối vì có thể bị cắt ngang
+	for i, p := range phases[:len(phases)-1] {
+		want := timings.Rest
+		if p.dashing {
+			want = timings.Dash
+		}
+		if math.Abs(p.duration-want) > testDT+1e-9 {
+			t.Errorf("đoạn %d (lao = %v) dài %.3f giây, muốn %.3f", i, p.dashing, p.duration, want)
+		}
+	}
+}
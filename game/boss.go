@@ -0,0 +1,386 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+)
+
+// Các đòn đánh của boss (giá trị "kind" trong file dữ liệu)
+const (
+	AttackCharge = "charge" // Khóa hướng rồi lao thẳng về phía player
+	AttackRing   = "ring"   // Bắn 1 vòng đạn 360 độ
+	AttackSummon = "summon" // Gọi thêm quái con quanh boss
+)
+
+// Trạng thái của boss trong chu kỳ đòn đánh
+const (
+	BossIdle      = 0 // Đuổi theo player, chờ hết cooldown
+	BossTelegraph = 1 // Đứng yên báo hiệu đòn sắp tung ra
+	BossCharging  = 2 // Đang lao tới
+)
+
+// DefaultBossEvery là số wave giữa 2 wave boss (wave 5, 10, 15...)
+const DefaultBossEvery = 5
+
+// maxSummonCount là số quái con tối đa của 1 đòn summon
+const maxSummonCount = 32
+
+// BossAttack là 1 đòn đánh của boss
+type BossAttack struct {
+	Kind      string  `json:"kind"`
+	Telegraph float64 `json:"telegraph"` // Thời gian báo hiệu trước khi ra đòn (giây)
+	Duration  float64 `json:"duration"`  // Thời gian lao tới (charge)
+	Cooldown  float64 `json:"cooldown"`  // Thời gian nghỉ sau đòn
+	Count     int     `json:"count"`     // Số viên đạn (ring) hoặc số quái con (summon)
+	Speed     float64 `json:"speed"`     // Tốc độ lao tới hoặc tốc độ đạn (px mỗi giây)
+	Damage    float64 `json:"damage"`    // Sát thương mỗi viên đạn (ring)
+	Minion    string  `json:"minion"`    // ID loại quái con (summon)
+}
+
+// BossPhase là 1 giai đoạn của boss, bắt đầu khi máu tụt xuống dưới Threshold
+type BossPhase struct {
+	Threshold       float64      `json:"threshold"`       // Tỉ lệ máu (0..1) để vào phase này
+	SpeedMultiplier float64      `json:"speedMultiplier"` // Hệ số tốc độ đuổi trong phase
+	Attacks         []BossAttack `json:"attacks"`         // Các đòn lần lượt xoay vòng
+}
+
+// BossReward là phần thưởng chắc chắn nhận được khi hạ boss
+type BossReward struct {
+	Potions int  `json:"potions"` // Số bình máu rơi ra
+	Skill   bool `json:"skill"`   // Được chọn thêm 1 kỹ năng
+}
+
+// BossDef là định nghĩa 1 boss, đọc từ file dữ liệu
+type BossDef struct {
+	ID            string      `json:"id"`
+	Name          string      `json:"name"`
+	Sprite        string      `json:"sprite"`
+	Width         float64     `json:"width"`
+	Height        float64     `json:"height"`
+	MaxHealth     float64     `json:"maxHealth"`
+	Speed         float64     `json:"speed"`         // px mỗi giây
+	ContactDamage float64     `json:"contactDamage"` // Sát thương mỗi giây khi chạm vào player
+	SpawnDelay    float64     `json:"spawnDelay"`
+	Phases        []BossPhase `json:"phases"` // Theo thứ tự Threshold giảm dần
+	Reward        BossReward  `json:"reward"`
+}
+
+// bossFile là cấu trúc file định nghĩa boss
+type bossFile struct {
+	Bosses []*BossDef `json:"bosses"`
+}
+
+// DefaultBossID là boss dùng khi không có dữ liệu nào khác
+const DefaultBossID = "skeleton_king"
+
+// DefaultBoss trả về boss mặc định, dùng khi không có file dữ liệu
+func DefaultBoss() *BossDef {
+	return &BossDef{
+		ID:            DefaultBossID,
+		Name:          "Skeleton King",
+		Sprite:        SpriteEnemy,
+		Width:         48,
+		Height:        48,
+		MaxHealth:     400,
+		Speed:         48,
+		ContactDamage: 400,
+		SpawnDelay:    1.5,
+		Phases: []BossPhase{
+			{
+				Threshold:       1,
+				SpeedMultiplier: 1,
+				Attacks: []BossAttack{
+					{Kind: AttackCharge, Telegraph: 0.8, Duration: 0.6, Cooldown: 1.5, Speed: 360},
+					{Kind: AttackRing, Telegraph: 0.6, Cooldown: 1.5, Count: 12, Speed: 120, Damage: 8},
+				},
+			},
+			{
+				Threshold:       0.6,
+				SpeedMultiplier: 1.3,
+				Attacks: []BossAttack{
+					{Kind: AttackRing, Telegraph: 0.5, Cooldown: 1.2, Count: 16, Speed: 130, Damage: 8},
+					{Kind: AttackSummon, Telegraph: 0.8, Cooldown: 1.5, Count: 3, Minion: DefaultArchetypeID},
+					{Kind: AttackCharge, Telegraph: 0.6, Duration: 0.6, Cooldown: 1.2, Speed: 400},
+				},
+			},
+			{
+				Threshold:       0.3,
+				SpeedMultiplier: 1.6,
+				Attacks: []BossAttack{
+					{Kind: AttackCharge, Telegraph: 0.5, Duration: 0.7, Cooldown: 0.8, Speed: 440},
+					{Kind: AttackRing, Telegraph: 0.4, Cooldown: 1.0, Count: 24, Speed: 140, Damage: 8},
+					{Kind: AttackSummon, Telegraph: 0.6, Cooldown: 1.2, Count: 4, Minion: DefaultArchetypeID},
+				},
+			},
+		},
+		Reward: BossReward{Potions: 3, Skill: true},
+	}
+}
+
+// LoadBosses đọc file định nghĩa boss (JSON) và trả về map theo ID
+func LoadBosses(path string) (map[string]*BossDef, error) {
+	contents, err := ReadAsset(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBosses(path, contents)
+}
+
+// ParseBosses đọc định nghĩa boss từ nội dung JSON đã có sẵn (ví dụ lưu trong replay),
+// path chỉ dùng để báo lỗi
+func ParseBosses(path string, contents []byte) (map[string]*BossDef, error) {
+	var file bossFile
+	if err := json.Unmarshal(contents, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	bosses := make(map[string]*BossDef, len(file.Bosses))
+	for i, b := range file.Bosses {
+		if b.ID == "" {
+			return nil, fmt.Errorf("%s: boss thứ %d thiếu id", path, i)
+		}
+		if _, ok := bosses[b.ID]; ok {
+			return nil, fmt.Errorf("%s: trùng id boss %q", path, b.ID)
+		}
+		b.applyDefaults()
+		if err := b.validate(); err != nil {
+			return nil, fmt.Errorf("%s: boss %q: %w", path, b.ID, err)
+		}
+		bosses[b.ID] = b
+	}
+	return bosses, nil
+}
+
+// applyDefaults điền giá trị mặc định cho các trường bị bỏ trống
+func (b *BossDef) applyDefaults() {
+	def := DefaultBoss()
+	if b.Name == "" {
+		b.Name = b.ID
+	}
+	if b.Sprite == "" {
+		b.Sprite = def.Sprite
+	}
+	if b.Width == 0 {
+		b.Width = def.Width
+	}
+	if b.Height == 0 {
+		b.Height = def.Height
+	}
+	if len(b.Phases) == 0 {
+		b.Phases = append([]BossPhase(nil), def.Phases...)
+	}
+	for i := range b.Phases {
+		if b.Phases[i].SpeedMultiplier == 0 {
+			b.Phases[i].SpeedMultiplier = 1
+		}
+	}
+}
+
+func (b *BossDef) validate() error {
+	if b.MaxHealth <= 0 {
+		return fmt.Errorf("maxHealth phải > 0")
+	}
+	for i, phase := range b.Phases {
+		if phase.Threshold <= 0 || phase.Threshold > 1 {
+			return fmt.Errorf("phase %d: threshold phải trong khoảng (0, 1]", i)
+		}
+		if i > 0 && phase.Threshold >= b.Phases[i-1].Threshold {
+			return fmt.Errorf("phase %d: threshold phải nhỏ hơn phase trước", i)
+		}
+		if len(phase.Attacks) == 0 {
+			return fmt.Errorf("phase %d: chưa có đòn đánh nào", i)
+		}
+		for _, a := range phase.Attacks {
+			switch a.Kind {
+			case AttackCharge, AttackRing, AttackSummon:
+			default:
+				return fmt.Errorf("phase %d: đòn đánh không hợp lệ %q", i, a.Kind)
+			}
+			if a.Kind != AttackCharge && a.Count < 1 {
+				return fmt.Errorf("phase %d: %s cần count >= 1", i, a.Kind)
+			}
+			if a.Kind == AttackRing && a.Damage <= 0 {
+				return fmt.Errorf("phase %d: ring cần damage > 0", i)
+			}
+			if a.Kind == AttackSummon && a.Count > maxSummonCount {
+				return fmt.Errorf("phase %d: summon gọi tối đa %d quái con", i, maxSummonCount)
+			}
+		}
+	}
+	if b.Reward.Potions < 0 {
+		return fmt.Errorf("reward.potions không được âm")
+	}
+	return nil
+}
+
+// ValidateMinions kiểm tra quái con của mọi đòn summon đều có trong archetypes
+// (map rỗng được coi như chỉ có loại mặc định, giống SetArchetypes)
+func ValidateMinions(bosses map[string]*BossDef, archetypes map[string]*EnemyArchetype) error {
+	for _, id := range SortedBossIDs(bosses) {
+		for i, phase := range bosses[id].Phases {
+			for _, a := range phase.Attacks {
+				if a.Kind == AttackSummon && a.Minion != "" && !hasArchetype(archetypes, a.Minion) {
+					return fmt.Errorf("boss %q: phase %d: không có loại quái %q", id, i, a.Minion)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hasBoss cho biết bosses có boss id (map rỗng chỉ có boss mặc định)
+func hasBoss(bosses map[string]*BossDef, id string) bool {
+	if len(bosses) == 0 {
+		return id == DefaultBossID
+	}
+	_, ok := bosses[id]
+	return ok
+}
+
+// NewEnemy tạo quái boss tại vị trí (x, y)
+func (b *BossDef) NewEnemy(x, y float64) *Enemy {
+	e := NewEnemy(b.Sprite, x, y, b.MaxHealth, b.Speed, b.ContactDamage, math.MaxFloat64)
+	e.Width = b.Width
+	e.Height = b.Height
+	e.SpawnTimer = b.SpawnDelay
+	e.Behavior = NewBoss(b)
+	return e
+}
+
+// SortedBossIDs trả về ID các boss theo thứ tự alphabet
+func SortedBossIDs(bosses map[string]*BossDef) []string {
+	ids := make([]string, 0, len(bosses))
+	for id := range bosses {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SummonRequest là yêu cầu gọi thêm quái con, World tạo quái theo loại Archetype
+type SummonRequest struct {
+	Archetype string
+	X, Y      float64
+}
+
+// Boss là AI của boss: đuổi theo player, lần lượt tung các đòn của phase hiện tại
+// và đổi phase khi máu tụt qua các ngưỡng
+type Boss struct {
+	Def         *BossDef
+	Phase       int     // Chỉ số phase hiện tại trong Def.Phases
+	State       int     // BossIdle, BossTelegraph hoặc BossCharging
+	Timer       float64 // Thời gian còn lại của trạng thái hiện tại
+	AttackIndex int     // Đòn kế tiếp trong phase
+	Attack      *BossAttack
+	AimX, AimY  float64 // Hướng lao tới đã khóa lúc báo hiệu
+}
+
+// NewBoss tạo AI boss ở phase đầu tiên
+func NewBoss(def *BossDef) *Boss {
+	b := &Boss{Def: def}
+	if len(def.Phases) > 0 {
+		b.Timer = def.Phases[0].Attacks[0].Cooldown
+	}
+	return b
+}
+
+// AsBoss trả về AI boss của quái (nil nếu không phải boss)
+func AsBoss(e *Enemy) *Boss {
+	if e == nil {
+		return nil
+	}
+	b, _ := e.Behavior.(*Boss)
+	return b
+}
+
+// Telegraphing cho biết boss có đang báo hiệu đòn sắp tung ra không
+func (b *Boss) Telegraphing() bool {
+	return b.State == BossTelegraph
+}
+
+func (b *Boss) Update(e *Enemy, ctx *BehaviorContext) {
+	if len(b.Def.Phases) == 0 {
+		return
+	}
+	b.updatePhase(e)
+	phase := &b.Def.Phases[b.Phase]
+
+	b.Timer -= ctx.DT
+	switch b.State {
+	case BossIdle:
+		dx, dy, distance := directionTo(e, ctx)
+		if distance > 0 {
+			e.Move(dx, dy, e.Speed*phase.SpeedMultiplier*ctx.DT, ctx)
+		}
+		if b.Timer <= 0 {
+			b.Attack = &phase.Attacks[b.AttackIndex%len(phase.Attacks)]
+			b.AttackIndex++
+			b.State = BossTelegraph
+			b.Timer = b.Attack.Telegraph
+
+			// Khóa hướng lao tới ngay lúc báo hiệu để người chơi kịp né
+			cx, cy := e.GetCenter()
+			b.AimX, b.AimY = ctx.PlayerCenterX-cx, ctx.PlayerCenterY-cy
+			if l := math.Hypot(b.AimX, b.AimY); l > 0 {
+				b.AimX /= l
+				b.AimY /= l
+			}
+		}
+	case BossTelegraph:
+		if b.Timer <= 0 {
+			b.release(e, ctx)
+		}
+	case BossCharging:
+		e.Move(b.AimX, b.AimY, b.Attack.Speed*ctx.DT, ctx)
+		if b.Timer <= 0 {
+			b.rest()
+		}
+	}
+}
+
+// updatePhase chuyển sang phase sau khi máu tụt qua ngưỡng của phase đó
+func (b *Boss) updatePhase(e *Enemy) {
+	ratio := e.Health / e.MaxHealth
+	for b.Phase+1 < len(b.Def.Phases) && ratio <= b.Def.Phases[b.Phase+1].Threshold {
+		b.Phase++
+		b.AttackIndex = 0
+		b.State = BossIdle
+		b.Timer = 0.5 // Khựng lại một chút khi đổi phase
+		log.Printf("%s chuyển sang phase %d!", b.Def.Name, b.Phase+1)
+	}
+}
+
+// release tung đòn đã báo hiệu
+func (b *Boss) release(e *Enemy, ctx *BehaviorContext) {
+	a := b.Attack
+	cx, cy := e.GetCenter()
+	switch a.Kind {
+	case AttackCharge:
+		b.State = BossCharging
+		b.Timer = a.Duration
+		return
+	case AttackRing:
+		ring := WeaponDef{Pattern: PatternRing, Count: a.Count, Speed: a.Speed, Damage: a.Damage}
+		ring.applyDefaults()
+		ctx.Shots = append(ctx.Shots, ring.Fire(cx, cy, ctx.PlayerCenterX, ctx.PlayerCenterY)...)
+	case AttackSummon:
+		for i := 0; i < a.Count; i++ {
+			angle := 2 * math.Pi * float64(i) / float64(a.Count)
+			ctx.Summons = append(ctx.Summons, SummonRequest{
+				Archetype: a.Minion,
+				X:         cx + math.Cos(angle)*e.Width,
+				Y:         cy + math.Sin(angle)*e.Height,
+			})
+		}
+	}
+	b.rest()
+}
+
+// rest quay về trạng thái đuổi theo, chờ cooldown của đòn vừa tung
+func (b *Boss) rest() {
+	b.State = BossIdle
+	b.Timer = b.Attack.Cooldown
+}
@@ -0,0 +1,69 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+// bossAttackFile là file boss có 1 boss với đòn đánh attack
+func bossAttackFile(tb testing.TB, attack string) string {
+	tb.Helper()
+	return writeAsset(tb, "bosses.json", `{"bosses": [{"id": "b", "maxHealth": 100, "phases": [
+		{"threshold": 1, "attacks": [`+attack+`]}]}]}`)
+}
+
+func TestLoadBossesAttacks(t *testing.T) {
+	tests := []struct {
+		attack string
+		ok     bool
+	}{
+		{`{"kind": "ring", "count": 8, "speed": 100, "damage": 3}`, true},
+		{`{"kind": "ring", "count": 8, "speed": 100}`, false},
+		{`{"kind": "ring", "count": 8, "speed": 100, "damage": 0}`, false},
+		{`{"kind": "ring", "count": 8, "speed": 100, "damage": -5}`, false},
+		{`{"kind": "summon", "count": 32, "minion": "orc"}`, true},
+		{`{"kind": "summon", "count": 33, "minion": "orc"}`, false},
+		{`{"kind": "summon", "count": 1000000000, "minion": "orc"}`, false},
+	}
+	for _, tt := range tests {
+		if _, err := LoadBosses(bossAttackFile(t, tt.attack)); (err == nil) != tt.ok {
+			t.Errorf("%s: lỗi = %v, muốn ok = %v", tt.attack, err, tt.ok)
+		}
+	}
+
+	// Boss bỏ trống phases dùng bản sao các phase mặc định, sửa 1 boss không ảnh hưởng boss khác
+	bosses, err := LoadBosses(writeAsset(t, "bosses.json", `{"bosses": [{"id": "a", "maxHealth": 1}, {"id": "b", "maxHealth": 1}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bosses["a"].Phases[0].SpeedMultiplier = 9
+	if bosses["b"].Phases[0].SpeedMultiplier == 9 || DefaultBoss().Phases[0].SpeedMultiplier == 9 {
+		t.Error("các boss dùng chung phase mặc định")
+	}
+}
+
+func TestValidateMinions(t *testing.T) {
+	archetypes, err := LoadArchetypes("../assets/data/enemies.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bosses, err := LoadBosses("../assets/data/bosses.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateMinions(bosses, archetypes); err != nil {
+		t.Error(err)
+	}
+
+	typo, err := LoadBosses(bossAttackFile(t, `{"kind": "summon", "count": 2, "minion": "orcc"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateMinions(typo, archetypes); err == nil || !strings.Contains(err.Error(), `"orcc"`) {
+		t.Errorf("quái con sai tên: lỗi = %v", err)
+	}
+	// Không có dữ liệu quái: chỉ có loại mặc định
+	if err := ValidateMinions(map[string]*BossDef{DefaultBossID: DefaultBoss()}, nil); err != nil {
+		t.Errorf("boss mặc định với quái mặc định: %v", err)
+	}
+}
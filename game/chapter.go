@@ -0,0 +1,216 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+)
+
+// RoomDef là 1 phòng của chapter: 1 map và các wave phải dọn sạch để mở cổng
+type RoomDef struct {
+	Map      string     `json:"map"`      // Đường dẫn map, tương đối so với file chapter
+	Waves    []WaveDef  `json:"waves"`    // Bỏ trống = phòng không có quái, cổng mở ngay
+	Generate *RoomRules `json:"generate"` // Có thì phòng được sinh ngẫu nhiên mỗi lượt, Map chỉ là map mẫu (lấy tileset)
+
+	Tilemap *TilemapJSON `json:"-"` // Map đã load
+}
+
+// Script trả về kịch bản wave của phòng
+func (r *RoomDef) Script() *WaveScript {
+	return &WaveScript{Waves: r.Waves}
+}
+
+// ChapterDef là 1 chapter: chuỗi phòng chơi lần lượt, dọn xong phòng cuối là qua chapter
+type ChapterDef struct {
+	ID    string     `json:"id"`
+	Name  string     `json:"name"`
+	Rooms []*RoomDef `json:"rooms"`
+
+	Path   string `json:"-"` // File chapter đã đọc, map của phòng tính tương đối so với file này
+	Source []byte `json:"-"` // Nội dung JSON gốc, replay lưu lại để sinh lại đúng các phòng
+}
+
+// LoadChapter đọc file chapter (JSON) và load map của mọi phòng.
+// Map được load hết từ đầu để lỗi thiếu file hiện ra ngay, và chuyển phòng không phải đọc đĩa.
+func LoadChapter(path string) (*ChapterDef, error) {
+	contents, err := ReadAsset(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseChapter(path, contents)
+}
+
+// ParseChapter đọc chapter từ nội dung JSON đã có sẵn (ví dụ lưu trong replay),
+// path dùng để báo lỗi và tìm map của các phòng
+func ParseChapter(path string, contents []byte) (*ChapterDef, error) {
+	chapter := ChapterDef{Path: path, Source: contents}
+	if err := json.Unmarshal(contents, &chapter); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(chapter.Rooms) == 0 {
+		return nil, fmt.Errorf("%s: chapter không có phòng nào", path)
+	}
+
+	// Nhiều phòng dùng chung 1 map thì chỉ load 1 lần
+	maps := map[string]*TilemapJSON{}
+	for i, room := range chapter.Rooms {
+		for j := range room.Waves {
+			for k := range room.Waves[j].Groups {
+				group := &room.Waves[j].Groups[k]
+				group.applyDefaults()
+				if err := group.validate(); err != nil {
+					return nil, fmt.Errorf("%s: phòng %d, wave %d, nhóm %d: %w", path, i+1, j+1, k+1, err)
+				}
+			}
+		}
+		if room.Generate != nil {
+			room.Generate.applyDefaults()
+			if err := room.Generate.validate(); err != nil {
+				return nil, fmt.Errorf("%s: phòng %d: %w", path, i+1, err)
+			}
+		}
+
+		if room.Map == "" {
+			return nil, fmt.Errorf("%s: phòng %d: thiếu map", path, i+1)
+		}
+		mapPath := filepath.Join(filepath.Dir(path), room.Map)
+		tilemap, ok := maps[mapPath]
+		if !ok {
+			var err error
+			tilemap, err = LoadTilemap(mapPath)
+			if err != nil {
+				return nil, fmt.Errorf("%s: phòng %d: %w", path, i+1, err)
+			}
+			maps[mapPath] = tilemap
+		}
+		room.Tilemap = tilemap
+	}
+	return &chapter, nil
+}
+
+// Validate kiểm tra mọi ID loại quái và boss trong wave của các phòng đều có trong archetypes và bosses
+// (xem WaveScript.Validate)
+func (c *ChapterDef) Validate(archetypes map[string]*EnemyArchetype, bosses map[string]*BossDef) error {
+	for i, room := range c.Rooms {
+		if err := room.Script().Validate(archetypes, bosses); err != nil {
+			return fmt.Errorf("%s: phòng %d, %w", c.Path, i+1, err)
+		}
+	}
+	return nil
+}
+
+// RunStats là thống kê của lượt chơi, hiện ở màn hình kết quả chapter
+type RunStats struct {
+	Kills          int
+	BossesDefeated int
+	RoomsCleared   int
+	Time           float64 // Giây mô phỏng
+}
+
+// SetChapter đặt chapter để chơi. Nếu world đã có player thì bắt đầu luôn từ phòng đầu.
+// nil = chơi 1 map với wave endless như cũ.
+func (w *World) SetChapter(chapter *ChapterDef) {
+	w.Chapter = chapter
+	if chapter != nil && w.Player != nil {
+		w.enterRoom(0)
+	}
+}
+
+// CurrentRoom trả về phòng đang chơi (nil nếu không chơi chapter)
+func (w *World) CurrentRoom() *RoomDef {
+	if w.Chapter == nil || w.Room < 0 || w.Room >= len(w.Chapter.Rooms) {
+		return nil
+	}
+	return w.Chapter.Rooms[w.Room]
+}
+
+// GateOpen kiểm tra cổng đã mở chưa: khi chơi chapter, cổng chỉ mở sau khi dọn sạch phòng
+func (w *World) GateOpen() bool {
+	return w.Chapter == nil || w.RoomCleared
+}
+
+// enterRoom chuyển sang phòng thứ i của chapter, giữ nguyên player (máu, kỹ năng)
+func (w *World) enterRoom(i int) {
+	w.Room = i
+	room := w.Chapter.Rooms[i]
+	w.changeMap(w.roomTilemap(i), room.Script())
+	if len(room.Waves) == 0 {
+		w.Wave.Clear()
+	}
+	log.Printf("Vào phòng %d/%d", i+1, len(w.Chapter.Rooms))
+}
+
+// roomTilemap trả về map của phòng thứ i: map khai báo, hoặc phòng sinh từ map mẫu
+func (w *World) roomTilemap(i int) *TilemapJSON {
+	room := w.Chapter.Rooms[i]
+	if room.Generate == nil {
+		return room.Tilemap
+	}
+	// Mỗi phòng có seed riêng suy ra từ seed lượt chơi, nên replay sinh lại đúng phòng cũ
+	generated, err := GenerateRoom(room.Tilemap, *room.Generate, RoomSeed(w.Seed, i))
+	if err != nil {
+		log.Printf("Không sinh được phòng %d, dùng map mẫu: %v", i+1, err)
+		return room.Tilemap
+	}
+	return generated
+}
+
+// changeMap đổi sang map mới: xóa quái, đạn, bình máu, bắt đầu lại wave
+// và đưa player tới điểm xuất phát của map (nếu có)
+func (w *World) changeMap(tilemap *TilemapJSON, script *WaveScript) {
+	w.setTilemap(tilemap)
+	w.clearEntities()
+	w.Wave = NewWaveManager(w.MapWidth, w.MapHeight)
+	w.Wave.SetScript(script)
+	w.RoomCleared = false
+	if w.HasPlayerStart {
+		w.Player.X = w.PlayerStartX - w.Player.Width/2
+		w.Player.Y = w.PlayerStartY - w.Player.Height/2
+	}
+}
+
+// onRoomCleared xử lý khi dọn xong wave cuối của phòng: mở cổng, hoặc kết thúc chapter nếu là phòng cuối
+func (w *World) onRoomCleared() {
+	w.RoomCleared = true
+	w.Stats.RoomsCleared++
+	if w.Room == len(w.Chapter.Rooms)-1 {
+		w.State = StateChapterComplete
+		log.Printf("Hoàn thành chapter %s!", w.Chapter.Name)
+		return
+	}
+	log.Println("Phòng đã dọn sạch, cổng đã mở!")
+}
+
+// handleTeleportGate xử lý nhấn E trên cổng: sang phòng kế tiếp của chapter,
+// hoặc (khi không chơi chapter) load map đích của cổng
+func (w *World) handleTeleportGate(in Input) {
+	gate := w.CurrentGate()
+	if !in.Interact || gate == nil {
+		return
+	}
+
+	if w.Chapter != nil {
+		if !w.RoomCleared {
+			log.Println("Cổng chưa mở, hãy hạ hết quái!")
+			return
+		}
+		if w.Room+1 < len(w.Chapter.Rooms) {
+			w.enterRoom(w.Room + 1)
+		}
+		return
+	}
+
+	if gate.Destination == "" || w.Tilemap == nil {
+		return
+	}
+	// Map đích tính tương đối so với thư mục của map hiện tại
+	path := filepath.Join(filepath.Dir(w.Tilemap.Path), gate.Destination)
+	tilemap, err := LoadTilemap(path)
+	if err != nil {
+		log.Printf("Không load được map %s: %v", path, err)
+		return
+	}
+	log.Printf("Chuyển sang map %s!", gate.Destination)
+	w.changeMap(tilemap, w.WaveScript)
+}
@@ -0,0 +1,178 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// DefaultStreamMargin là khoảng (pixel) quanh camera mà chunk được nạp trước khi lọt vào màn hình
+const DefaultStreamMargin = 128
+
+// TileChunk là 1 mảnh tile layer của map vô hạn (Tiled infinite map).
+// Data chỉ được giải mã khi cần (Load) và có thể giải phóng lại (Unload) khi ở xa camera.
+type TileChunk struct {
+	X      int `json:"x"` // Tọa độ tile góc trên trái
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// Data là GID thô của từng ô (còn bit lật), nil khi chunk chưa nạp
+	Data    []int           `json:"-"`
+	RawData json.RawMessage `json:"data"` // Data như trong file JSON: mảng số, CSV hoặc base64
+	source  func() ([]int, error)
+}
+
+// Loaded kiểm tra chunk đã được giải mã chưa
+func (c *TileChunk) Loaded() bool {
+	return c.Data != nil
+}
+
+// Load giải mã data của chunk (không làm gì nếu đã nạp)
+func (c *TileChunk) Load() error {
+	if c.Data != nil || c.source == nil {
+		return nil
+	}
+	data, err := c.decode()
+	if err != nil {
+		return err
+	}
+	c.Data = data
+	return nil
+}
+
+// Unload giải phóng data đã giải mã, lần Load sau sẽ giải mã lại
+func (c *TileChunk) Unload() {
+	if c.source != nil {
+		c.Data = nil
+	}
+}
+
+// Tiles trả về data của chunk: data đã nạp, hoặc giải mã tạm mà không giữ lại
+func (c *TileChunk) Tiles() ([]int, error) {
+	if c.Data != nil || c.source == nil {
+		return c.Data, nil
+	}
+	return c.decode()
+}
+
+// decode giải mã data từ nguồn và kiểm tra kích thước
+func (c *TileChunk) decode() ([]int, error) {
+	data, err := c.source()
+	if err != nil {
+		return nil, fmt.Errorf("chunk (%d, %d): %w", c.X, c.Y, err)
+	}
+	if len(data) != c.Width*c.Height {
+		return nil, fmt.Errorf("chunk (%d, %d): có %d ô, cần %d", c.X, c.Y, len(data), c.Width*c.Height)
+	}
+	return data, nil
+}
+
+// Bounds trả về vùng (x, y, w, h) pixel của chunk trên map
+func (c *TileChunk) Bounds(tileW, tileH int) (float64, float64, float64, float64) {
+	return float64(c.X * tileW), float64(c.Y * tileH), float64(c.Width * tileW), float64(c.Height * tileH)
+}
+
+// Chunked kiểm tra layer có được chia chunk không (map vô hạn)
+func (l *TilemapLayerJSON) Chunked() bool {
+	return len(l.Chunks) > 0
+}
+
+// EachTile gọi fn cho mọi ô khác 0 của layer với tọa độ tile và GID thô.
+// Chunk chưa nạp được giải mã tạm rồi bỏ, không làm thay đổi trạng thái nạp.
+func (l *TilemapLayerJSON) EachTile(fn func(tx, ty, gid int)) error {
+	if !l.Chunked() {
+		for idx, gid := range l.Data {
+			if gid != 0 {
+				fn(idx%l.Width, idx/l.Width, gid)
+			}
+		}
+		return nil
+	}
+	for _, c := range l.Chunks {
+		data, err := c.Tiles()
+		if err != nil {
+			return err
+		}
+		for idx, gid := range data {
+			if gid != 0 {
+				fn(c.X+idx%c.Width, c.Y+idx/c.Width, gid)
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeInfinite dời map vô hạn về gốc (0, 0): chunk có thể nằm ở tọa độ âm,
+// còn world, va chạm và camera đều tính từ 0. Width/Height của map thành khung bao các chunk.
+func (t *TilemapJSON) normalizeInfinite() {
+	minX, minY := math.MaxInt, math.MaxInt
+	maxX, maxY := math.MinInt, math.MinInt
+	for _, layer := range t.Layers {
+		for _, c := range layer.Chunks {
+			minX = min(minX, c.X)
+			minY = min(minY, c.Y)
+			maxX = max(maxX, c.X+c.Width)
+			maxY = max(maxY, c.Y+c.Height)
+		}
+	}
+	if minX > maxX {
+		return
+	}
+
+	t.OriginX, t.OriginY = minX, minY
+	t.Width, t.Height = maxX-minX, maxY-minY
+	offX := float64(minX * t.TileW)
+	offY := float64(minY * t.TileH)
+	for i := range t.Layers {
+		layer := &t.Layers[i]
+		for _, c := range layer.Chunks {
+			c.X -= minX
+			c.Y -= minY
+		}
+		if layer.Chunked() {
+			layer.StartX -= minX
+			layer.StartY -= minY
+		}
+		for j := range layer.Objects {
+			layer.Objects[j].X -= offX
+			layer.Objects[j].Y -= offY
+		}
+	}
+}
+
+// StreamChunks nạp các chunk giao với vùng (x, y, w, h) pixel mở rộng thêm margin,
+// và giải phóng chunk nằm ngoài vùng mở rộng 2*margin (chừa khoảng đệm để không nạp/giải phóng liên tục ở rìa).
+func (t *TilemapJSON) StreamChunks(x, y, w, h, margin float64) error {
+	for i := range t.Layers {
+		for _, c := range t.Layers[i].Chunks {
+			cx, cy, cw, ch := c.Bounds(t.TileW, t.TileH)
+			switch {
+			case rectsOverlap(cx, cy, cw, ch, x-margin, y-margin, w+2*margin, h+2*margin):
+				if err := c.Load(); err != nil {
+					return fmt.Errorf("layer %q: %w", t.Layers[i].Name, err)
+				}
+			case !rectsOverlap(cx, cy, cw, ch, x-2*margin, y-2*margin, w+4*margin, h+4*margin):
+				c.Unload()
+			}
+		}
+	}
+	return nil
+}
+
+// LoadedChunks đếm số chunk đang được nạp trên tổng số chunk của map
+func (t *TilemapJSON) LoadedChunks() (loaded, total int) {
+	for _, layer := range t.Layers {
+		for _, c := range layer.Chunks {
+			total++
+			if c.Loaded() {
+				loaded++
+			}
+		}
+	}
+	return loaded, total
+}
+
+// rectsOverlap kiểm tra 2 hình chữ nhật có giao nhau không
+func rectsOverlap(ax, ay, aw, ah, bx, by, bw, bh float64) bool {
+	return ax < bx+bw && ax+aw > bx && ay < by+bh && ay+ah > by
+}
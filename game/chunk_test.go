@@ -0,0 +1,123 @@
+package game
+
+import (
+	"testing"
+)
+
+// denseCopy chép map vô hạn thành map thường (mỗi layer 1 mảng Data), dùng làm kết quả mong đợi
+func denseCopy(tb testing.TB, tm *TilemapJSON) *TilemapJSON {
+	tb.Helper()
+	dense := *tm
+	dense.Layers = nil
+	for _, layer := range tm.Layers {
+		data := make([]int, tm.Width*tm.Height)
+		if err := layer.EachTile(func(tx, ty, gid int) {
+			data[ty*tm.Width+tx] = gid
+		}); err != nil {
+			tb.Fatal(err)
+		}
+		layer.Chunks, layer.Data = nil, data
+		layer.Width, layer.Height = tm.Width, tm.Height
+		dense.Layers = append(dense.Layers, layer)
+	}
+	return &dense
+}
+
+// sameCollision so 2 lưới ở mọi ô của map và 1 viền ngoài map
+func sameCollision(t *testing.T, got, want *CollisionGrid) {
+	t.Helper()
+	for ty := -1; ty <= want.Height; ty++ {
+		for tx := -1; tx <= want.Width; tx++ {
+			x, y := (float64(tx)+0.5)*want.TileW, (float64(ty)+0.5)*want.TileH
+			if got.IsSolid(tx, ty) != want.IsSolid(tx, ty) || got.BlocksShot(x, y) != want.BlocksShot(x, y) {
+				t.Fatalf("ô (%d, %d): IsSolid %v BlocksShot %v, muốn %v %v", tx, ty,
+					got.IsSolid(tx, ty), got.BlocksShot(x, y), want.IsSolid(tx, ty), want.BlocksShot(x, y))
+			}
+		}
+	}
+}
+
+func TestCollisionGridChunksOnDemand(t *testing.T) {
+	tm, err := LoadTilemap("testdata/infinite.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Chunk từ (-32, -16) tới (48, 32) được dời về gốc: 5x3 ô chunk 16x16
+	if tm.Width != 80 || tm.Height != 48 || tm.OriginX != -32 || tm.OriginY != -16 {
+		t.Fatalf("map %dx%d gốc (%d, %d), muốn 80x48 gốc (-32, -16)", tm.Width, tm.Height, tm.OriginX, tm.OriginY)
+	}
+	want := NewCollisionGrid(denseCopy(t, tm), tm.TileCollides)
+
+	grid := NewCollisionGrid(tm, tm.TileCollides)
+	if loaded, total := grid.LoadedCells(); loaded != 0 || total != 15 {
+		t.Fatalf("lúc tạo đã dựng %d/%d ô, muốn 0/15", loaded, total)
+	}
+	grid.IsSolid(20, 5)
+	if loaded, _ := grid.LoadedCells(); loaded != 1 {
+		t.Errorf("truy vấn 1 ô dựng %d ô", loaded)
+	}
+	sameCollision(t, grid, want)
+	// Va chạm giải mã chunk tạm, không giữ data của chunk
+	if loaded, _ := tm.LoadedChunks(); loaded != 0 {
+		t.Errorf("dựng va chạm đã nạp %d chunk", loaded)
+	}
+	// Có tile nền mang thuộc tính collides, có tường, có hố
+	solid, pits := 0, 0
+	for ty := 0; ty < grid.Height; ty++ {
+		for tx := 0; tx < grid.Width; tx++ {
+			if s, p := grid.tile(tx, ty); s {
+				solid++
+			} else if p {
+				pits++
+			}
+		}
+	}
+	if solid == 0 || pits == 0 {
+		t.Fatalf("fixture quá đơn giản: %d tường, %d hố", solid, pits)
+	}
+}
+
+func TestCollisionGridStream(t *testing.T) {
+	tm, err := LoadTilemap("testdata/infinite.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NewCollisionGrid(denseCopy(t, tm), tm.TileCollides)
+	w := NewWorld(tm, 1)
+	grid := w.Collision
+	const margin = 128 // Mỗi ô chunk rộng 256 px
+
+	// Camera ở góc trên trái: vùng nạp [-128, 448) x [-128, 308) gồm ô (0..1, 0..1)
+	if err := w.StreamChunks(0, 0, 320, 180, margin); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, _ := grid.LoadedCells(); loaded != 4 {
+		t.Errorf("camera góc trên trái: dựng %d ô, muốn 4", loaded)
+	}
+	if loaded, _ := tm.LoadedChunks(); loaded == 0 {
+		t.Error("data chunk quanh camera chưa được nạp")
+	}
+
+	// Quái vẫn va chạm ở ô (0, 0) nên ô này được giữ dù camera đã đi xa,
+	// ô (1, 0), (0, 1), (1, 1) bị giải phóng. Vùng nạp mới gồm ô (3..4, 1..2).
+	grid.IsSolid(1, 1)
+	if err := w.StreamChunks(1000, 500, 320, 180, margin); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, _ := grid.LoadedCells(); loaded != 5 {
+		t.Errorf("camera đi xa: còn %d ô, muốn 5", loaded)
+	}
+	if grid.cells[0] == nil || grid.cells[1] != nil {
+		t.Error("ô đang dùng phải được giữ, ô không dùng phải bị giải phóng")
+	}
+	// Không còn truy vấn nào ở ô (0, 0) nên lần sau ô bị giải phóng
+	if err := w.StreamChunks(1000, 500, 320, 180, margin); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, _ := grid.LoadedCells(); loaded != 4 || grid.cells[0] != nil {
+		t.Errorf("ô không dùng nữa chưa bị giải phóng: còn %d ô", loaded)
+	}
+
+	// Ô đã giải phóng được dựng lại giống hệt
+	sameCollision(t, grid, want)
+}
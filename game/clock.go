@@ -0,0 +1,61 @@
+package game
+
+// DefaultTickRate là số bước mô phỏng mỗi giây mặc định
+const DefaultTickRate = 60
+
+// Clock là đồng hồ mô phỏng bước cố định (fixed timestep).
+// Thời gian thực được cộng dồn vào Accumulator, mỗi khi đủ Step giây thì
+// mô phỏng chạy thêm 1 bước. Nhờ vậy tốc độ gameplay không phụ thuộc TPS
+// thực tế, và test có thể gọi Tick để tua thời gian một cách xác định.
+type Clock struct {
+	Step        float64 // Độ dài 1 bước mô phỏng (giây)
+	Accumulator float64 // Thời gian thực chưa được mô phỏng
+	Frame       uint64  // Số bước đã mô phỏng
+	Time        float64 // Tổng thời gian mô phỏng (giây)
+	MaxSteps    int     // Số bước tối đa mỗi lần Advance (tránh "spiral of death")
+}
+
+// NewClock tạo clock với số bước mô phỏng mỗi giây cho trước
+func NewClock(tickRate int) *Clock {
+	if tickRate <= 0 {
+		tickRate = DefaultTickRate
+	}
+	return &Clock{
+		Step:     1.0 / float64(tickRate),
+		MaxSteps: 5,
+	}
+}
+
+// DT trả về delta time (giây) của 1 bước mô phỏng
+func (c *Clock) DT() float64 {
+	return c.Step
+}
+
+// Advance cộng dồn thời gian thực đã trôi qua và trả về số bước cần mô phỏng.
+// Nếu bị trễ quá MaxSteps bước thì phần dư bị bỏ đi để game không bị đứng.
+func (c *Clock) Advance(elapsed float64) int {
+	if elapsed > 0 {
+		c.Accumulator += elapsed
+	}
+
+	steps := 0
+	for c.Accumulator >= c.Step && steps < c.MaxSteps {
+		c.Accumulator -= c.Step
+		steps++
+	}
+	if c.Accumulator >= c.Step {
+		c.Accumulator = 0
+	}
+	return steps
+}
+
+// Tick ghi nhận 1 bước mô phỏng đã chạy xong
+func (c *Clock) Tick() {
+	c.Frame++
+	c.Time += c.Step
+}
+
+// Alpha trả về tỉ lệ (0..1) của bước kế tiếp đã tích lũy, dùng để nội suy khi vẽ
+func (c *Clock) Alpha() float64 {
+	return c.Accumulator / c.Step
+}
@@ -0,0 +1,64 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClockAdvance(t *testing.T) {
+	step := 1.0 / DefaultTickRate
+	tests := []struct {
+		name    string
+		elapsed []float64 // Thời gian thực của từng lần Advance liên tiếp
+		steps   []int     // Số bước mong đợi của từng lần
+		acc     float64   // Accumulator sau lần cuối
+	}{
+		{"đúng 1 bước", []float64{step}, []int{1}, 0},
+		{"chưa đủ 1 bước", []float64{step / 2}, []int{0}, step / 2},
+		{"cộng dồn phần lẻ", []float64{step * 0.6, step * 0.6, step * 0.6}, []int{0, 1, 0}, step * 0.8},
+		{"nhiều bước 1 lần", []float64{step * 3.5}, []int{3}, step / 2},
+		{"đúng MaxSteps", []float64{step * 5}, []int{5}, 0},
+		{"quá MaxSteps bỏ phần dư", []float64{step * 5.5}, []int{5}, step / 2},
+		{"trễ rất lâu bỏ hết phần dư", []float64{1}, []int{5}, 0},
+		{"thời gian âm bị bỏ qua", []float64{step / 2, -1, step / 2}, []int{0, 0, 1}, 0},
+	}
+	for _, tt := range tests {
+		c := NewClock(DefaultTickRate)
+		if c.MaxSteps != 5 {
+			t.Fatalf("MaxSteps mặc định = %d, muốn 5", c.MaxSteps)
+		}
+		for i, elapsed := range tt.elapsed {
+			if got := c.Advance(elapsed); got != tt.steps[i] {
+				t.Errorf("%s: lần %d Advance(%.4f) = %d, muốn %d", tt.name, i, elapsed, got, tt.steps[i])
+			}
+		}
+		if math.Abs(c.Accumulator-tt.acc) > 1e-9 {
+			t.Errorf("%s: Accumulator = %.6f, muốn %.6f", tt.name, c.Accumulator, tt.acc)
+		}
+		if c.Alpha() < 0 || c.Alpha() >= 1 {
+			t.Errorf("%s: Alpha = %.3f ngoài [0, 1)", tt.name, c.Alpha())
+		}
+	}
+}
+
+func TestClockTick(t *testing.T) {
+	c := NewClock(30)
+	if c.DT() != 1.0/30 {
+		t.Fatalf("DT = %v, muốn 1/30", c.DT())
+	}
+	// Advance chỉ báo số bước, Frame và Time chỉ tăng khi Tick
+	steps := c.Advance(0.1)
+	if c.Frame != 0 || c.Time != 0 {
+		t.Fatalf("Advance đã đổi Frame/Time: %d, %v", c.Frame, c.Time)
+	}
+	for i := 0; i < steps; i++ {
+		c.Tick()
+	}
+	if c.Frame != 3 || math.Abs(c.Time-0.1) > 1e-9 {
+		t.Errorf("sau %d Tick: Frame %d, Time %v, muốn 3, 0.1", steps, c.Frame, c.Time)
+	}
+
+	if NewClock(0).Step != 1.0/DefaultTickRate {
+		t.Error("tick rate 0 phải dùng DefaultTickRate")
+	}
+}
@@ -0,0 +1,311 @@
+package game
+
+import (
+	"math"
+	"strings"
+)
+
+// CollisionLayerName là tên tile layer chỉ dùng để đánh dấu tường (mọi tile khác 0 đều chặn)
+const CollisionLayerName = "Collision"
+
+// PitLayerName là tên tile layer đánh dấu hố (nước, vực): chặn di chuyển nhưng đạn bay qua được
+const PitLayerName = "Pits"
+
+// CollisionGrid là lưới tile chặn di chuyển của map.
+// Ngoài rìa bản đồ cũng được coi là tường.
+//
+// Map thường được dựng sẵn cả lưới. Map vô hạn (chia chunk) thì lưới được chia theo ô bằng kích thước
+// chunk: ô chỉ được dựng từ data của các chunk khi có truy vấn đầu tiên rơi vào, và được giải phóng
+// khi ở xa camera (xem Stream), nên không phải giải mã và giữ va chạm của cả map.
+type CollisionGrid struct {
+	Width, Height int // Số tile theo mỗi chiều
+	TileW, TileH  float64
+	solid         []bool
+	pit           []bool
+
+	// Chỉ dùng cho map vô hạn
+	cellW, cellH int // Kích thước 1 ô (tile)
+	cols         int
+	cells        []*collisionCell    // nil = ô chưa dựng
+	sources      [][]collisionSource // Các chunk giao với từng ô
+	collides     func(gid int) bool
+}
+
+// collisionCell là lưới va chạm của 1 ô chunk. solid và pit nil nếu ô không có chunk nào.
+type collisionCell struct {
+	solid, pit []bool
+	used       bool // Có truy vấn kể từ lần Stream trước
+}
+
+// collisionSource là 1 chunk góp vào ô va chạm, kèm vai trò của layer chứa nó
+type collisionSource struct {
+	chunk     *TileChunk
+	collision bool // Thuộc layer Collision
+	pit       bool // Thuộc layer Pits
+}
+
+// NewCollisionGrid dựng lưới va chạm từ map: tile thuộc layer Collision,
+// hoặc tile mà collides(gid) trả về true (thuộc tính "collides" trong tileset).
+// collides có thể nil nếu không có tileset.
+func NewCollisionGrid(tilemap *TilemapJSON, collides func(gid int) bool) *CollisionGrid {
+	c := &CollisionGrid{
+		Width:  tilemap.Width,
+		Height: tilemap.Height,
+		TileW:  float64(tilemap.TileW),
+		TileH:  float64(tilemap.TileH),
+	}
+	if c.initCells(tilemap, collides) {
+		return c
+	}
+
+	c.solid = make([]bool, tilemap.Width*tilemap.Height)
+	c.pit = make([]bool, tilemap.Width*tilemap.Height)
+	for _, layer := range tilemap.Layers {
+		if layer.Type == LayerObjects {
+			continue
+		}
+		isCollisionLayer := strings.EqualFold(layer.Name, CollisionLayerName)
+		isPitLayer := strings.EqualFold(layer.Name, PitLayerName)
+		// Lỗi data đã được báo khi load map nên bỏ qua ở đây
+		_ = layer.EachTile(func(tx, ty, gid int) {
+			if tx < 0 || ty < 0 || tx >= c.Width || ty >= c.Height {
+				return
+			}
+			if isPitLayer {
+				c.pit[ty*c.Width+tx] = true
+				return
+			}
+			if isCollisionLayer || (collides != nil && collides(gid)) {
+				c.solid[ty*c.Width+tx] = true
+			}
+		})
+	}
+	return c
+}
+
+// initCells chia lưới theo ô chunk nếu map có layer chia chunk: chỉ ghi lại chunk nào giao với ô nào,
+// chưa giải mã chunk. Trả về false nếu là map thường.
+func (c *CollisionGrid) initCells(tilemap *TilemapJSON, collides func(gid int) bool) bool {
+	for _, layer := range tilemap.Layers {
+		if layer.Chunked() && c.cellW == 0 {
+			c.cellW, c.cellH = layer.Chunks[0].Width, layer.Chunks[0].Height
+		}
+	}
+	if c.cellW <= 0 || c.cellH <= 0 {
+		return false
+	}
+
+	c.cols = (c.Width + c.cellW - 1) / c.cellW
+	rows := (c.Height + c.cellH - 1) / c.cellH
+	c.cells = make([]*collisionCell, c.cols*rows)
+	c.sources = make([][]collisionSource, c.cols*rows)
+	c.collides = collides
+	for _, layer := range tilemap.Layers {
+		if layer.Type == LayerObjects {
+			continue
+		}
+		chunks := layer.Chunks
+		if !layer.Chunked() && len(layer.Data) > 0 {
+			// Layer không chia chunk trong map vô hạn: coi cả layer là 1 chunk
+			chunks = []*TileChunk{{Width: layer.Width, Height: layer.Height, Data: layer.Data}}
+		}
+		src := collisionSource{
+			collision: strings.EqualFold(layer.Name, CollisionLayerName),
+			pit:       strings.EqualFold(layer.Name, PitLayerName),
+		}
+		for _, chunk := range chunks {
+			src.chunk = chunk
+			x0, y0 := max(chunk.X/c.cellW, 0), max(chunk.Y/c.cellH, 0)
+			x1 := min((chunk.X+chunk.Width-1)/c.cellW, c.cols-1)
+			y1 := min((chunk.Y+chunk.Height-1)/c.cellH, rows-1)
+			for cy := y0; cy <= y1; cy++ {
+				for cx := x0; cx <= x1; cx++ {
+					c.sources[cy*c.cols+cx] = append(c.sources[cy*c.cols+cx], src)
+				}
+			}
+		}
+	}
+	return true
+}
+
+// cell trả về ô thứ i, dựng từ các chunk nếu chưa có
+func (c *CollisionGrid) cell(i int) *collisionCell {
+	cell := c.cells[i]
+	if cell == nil {
+		cell = c.buildCell(i)
+		c.cells[i] = cell
+	}
+	cell.used = true
+	return cell
+}
+
+// buildCell dựng lưới va chạm của ô thứ i từ data của các chunk giao với ô.
+// Chunk chưa nạp được giải mã tạm, không làm thay đổi trạng thái nạp.
+func (c *CollisionGrid) buildCell(i int) *collisionCell {
+	cell := &collisionCell{}
+	if len(c.sources[i]) == 0 {
+		return cell
+	}
+	cell.solid = make([]bool, c.cellW*c.cellH)
+	cell.pit = make([]bool, c.cellW*c.cellH)
+	x0, y0 := i%c.cols*c.cellW, i/c.cols*c.cellH
+	for _, src := range c.sources[i] {
+		// Lỗi data đã được báo khi load map nên bỏ qua ở đây
+		data, err := src.chunk.Tiles()
+		if err != nil {
+			continue
+		}
+		for idx, gid := range data {
+			tx := src.chunk.X + idx%src.chunk.Width - x0
+			ty := src.chunk.Y + idx/src.chunk.Width - y0
+			if gid == 0 || tx < 0 || ty < 0 || tx >= c.cellW || ty >= c.cellH {
+				continue
+			}
+			if src.pit {
+				cell.pit[ty*c.cellW+tx] = true
+			} else if src.collision || (c.collides != nil && c.collides(gid)) {
+				cell.solid[ty*c.cellW+tx] = true
+			}
+		}
+	}
+	return cell
+}
+
+// tile trả về tile (tx, ty) (đã nằm trong map) có phải tường, hố không
+func (c *CollisionGrid) tile(tx, ty int) (solid, pit bool) {
+	if c.cells == nil {
+		i := ty*c.Width + tx
+		return c.solid[i], c.pit[i]
+	}
+	cx, cy := tx/c.cellW, ty/c.cellH
+	cell := c.cell(cy*c.cols + cx)
+	if cell.solid == nil {
+		return false, false
+	}
+	i := (ty-cy*c.cellH)*c.cellW + tx - cx*c.cellW
+	return cell.solid[i], cell.pit[i]
+}
+
+// Stream dựng sẵn các ô giao với vùng (x, y, w, h) pixel mở rộng thêm margin, và giải phóng ô
+// nằm ngoài vùng mở rộng 2*margin nếu không có truy vấn nào kể từ lần Stream trước
+// (ô có quái đang đi ở xa camera vẫn được giữ). Map thường không làm gì.
+func (c *CollisionGrid) Stream(x, y, w, h, margin float64) {
+	if c == nil || c.cells == nil {
+		return
+	}
+	cw, ch := float64(c.cellW)*c.TileW, float64(c.cellH)*c.TileH
+	for i := range c.cells {
+		cx, cy := float64(i%c.cols)*cw, float64(i/c.cols)*ch
+		switch {
+		case rectsOverlap(cx, cy, cw, ch, x-margin, y-margin, w+2*margin, h+2*margin):
+			c.cell(i)
+		case !rectsOverlap(cx, cy, cw, ch, x-2*margin, y-2*margin, w+4*margin, h+4*margin):
+			if c.cells[i] != nil && !c.cells[i].used {
+				c.cells[i] = nil
+			}
+		}
+		if c.cells[i] != nil {
+			c.cells[i].used = false
+		}
+	}
+}
+
+// LoadedCells đếm số ô đã dựng trên tổng số ô (map thường: 0, 0)
+func (c *CollisionGrid) LoadedCells() (loaded, total int) {
+	for _, cell := range c.cells {
+		if cell != nil {
+			loaded++
+		}
+	}
+	return loaded, len(c.cells)
+}
+
+// IsSolid kiểm tra tile (tx, ty) có chặn di chuyển không (tường hoặc hố)
+func (c *CollisionGrid) IsSolid(tx, ty int) bool {
+	if tx < 0 || ty < 0 || tx >= c.Width || ty >= c.Height {
+		return true
+	}
+	solid, pit := c.tile(tx, ty)
+	return solid || pit
+}
+
+// BlocksShot kiểm tra điểm (x, y) có chặn đạn không: chỉ tường chặn, đạn bay qua hố
+func (c *CollisionGrid) BlocksShot(x, y float64) bool {
+	if c == nil {
+		return false
+	}
+	tx, ty := int(math.Floor(x/c.TileW)), int(math.Floor(y/c.TileH))
+	if tx < 0 || ty < 0 || tx >= c.Width || ty >= c.Height {
+		return true
+	}
+	solid, _ := c.tile(tx, ty)
+	return solid
+}
+
+// SolidAt kiểm tra điểm (x, y) có nằm trong tường không
+func (c *CollisionGrid) SolidAt(x, y float64) bool {
+	if c == nil {
+		return false
+	}
+	return c.IsSolid(int(math.Floor(x/c.TileW)), int(math.Floor(y/c.TileH)))
+}
+
+// Overlaps kiểm tra hình chữ nhật (x, y, w, h) có chạm tile tường nào không
+func (c *CollisionGrid) Overlaps(x, y, w, h float64) bool {
+	if c == nil {
+		return false
+	}
+	// Trừ 1 lượng rất nhỏ để hình chữ nhật đứng sát mép tile không bị tính là chạm
+	const eps = 1e-6
+	x0 := int(math.Floor(x / c.TileW))
+	y0 := int(math.Floor(y / c.TileH))
+	x1 := int(math.Floor((x + w - eps) / c.TileW))
+	y1 := int(math.Floor((y + h - eps) / c.TileH))
+	for ty := y0; ty <= y1; ty++ {
+		for tx := x0; tx <= x1; tx++ {
+			if c.IsSolid(tx, ty) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Slide di chuyển hình chữ nhật (x, y, w, h) một đoạn (dx, dy), xử lý riêng từng trục:
+// trục nào đâm vào tường thì dừng sát mép tường, trục còn lại vẫn đi tiếp (trượt dọc tường).
+// Mỗi bước nên đi ít hơn 1 tile để không xuyên qua tường mỏng.
+func (c *CollisionGrid) Slide(x, y, w, h, dx, dy float64) (nx, ny float64, blockedX, blockedY bool) {
+	if c == nil {
+		return x + dx, y + dy, false, false
+	}
+
+	// Trục X
+	nx = x + dx
+	if dx != 0 && c.Overlaps(nx, y, w, h) {
+		blockedX = true
+		if dx > 0 {
+			nx = math.Floor((nx+w)/c.TileW)*c.TileW - w
+		} else {
+			nx = (math.Floor(nx/c.TileW) + 1) * c.TileW
+		}
+		// Vẫn chạm (vd. đang kẹt sẵn trong tường) thì đứng yên
+		if c.Overlaps(nx, y, w, h) {
+			nx = x
+		}
+	}
+
+	// Trục Y
+	ny = y + dy
+	if dy != 0 && c.Overlaps(nx, ny, w, h) {
+		blockedY = true
+		if dy > 0 {
+			ny = math.Floor((ny+h)/c.TileH)*c.TileH - h
+		} else {
+			ny = (math.Floor(ny/c.TileH) + 1) * c.TileH
+		}
+		if c.Overlaps(nx, ny, w, h) {
+			ny = y
+		}
+	}
+	return nx, ny, blockedX, blockedY
+}
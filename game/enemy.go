@@ -1,35 +1,32 @@
 package game
 
-import (
-	"image"
-	"math"
-
-	"github.com/hajimehoshi/ebiten/v2"
-)
+import "math"
 
 // Enemy đại diện cho quái vật
 type Enemy struct {
 	X, Y       float64
 	Health     float64
 	MaxHealth  float64
-	Speed      float64
-	Damage     float64
-	Img        *ebiten.Image
+	Speed      float64 // px mỗi giây
+	Damage     float64 // Sát thương mỗi giây khi chạm vào player
+	Sprite     string  // Sprite ID để renderer tra ảnh
 	Width      float64
 	Height     float64
 	Active     bool
-	FollowDist float64 // Khoảng cách bắt đầu đuổi theo player
-	State      int     // 0: Đứng nghỉ, 1: Lao tới
-	Timer      float64 // Bộ đếm thời gian cho trạng thái hiện tại
+	FollowDist float64         // Khoảng cách bắt đầu đuổi theo player
+	SpawnTimer float64         // Thời gian đứng yên còn lại sau khi sinh ra
+	Behavior   Behavior        // AI điều khiển quái
+	Weapon     *WeaponDef      // Vũ khí bắn xa (nil nếu không có)
+	FireTimer  float64         // Thời gian chờ còn lại trước lần bắn kế tiếp
+	Archetype  *EnemyArchetype // Loại quái (nil nếu tạo trực tiếp bằng NewEnemy)
+	Action     Action          // Hướng quay mặt và dáng đang làm, quyết định clip animation
+	Anim       *Animator       // Animation của sprite
+	Fit        SpriteFit       // Cách đặt sheet của sprite lên khung va chạm (world gán khi spawn)
 }
 
-const (
-	StateRest = 0
-	StateDash = 1
-)
-
-// NewEnemy tạo enemy mới
-func NewEnemy(img *ebiten.Image, x, y, maxHealth, speed, damage, followDist float64) *Enemy {
+// NewEnemy tạo enemy mới với AI lao tới (dasher) mặc định
+func NewEnemy(sprite string, x, y, maxHealth, speed, damage, followDist float64) *Enemy {
+	timings := DefaultArchetype().Timings
 	return &Enemy{
 		X:          x,
 		Y:          y,
@@ -37,62 +34,88 @@ func NewEnemy(img *ebiten.Image, x, y, maxHealth, speed, damage, followDist floa
 		MaxHealth:  maxHealth,
 		Speed:      speed,
 		Damage:     damage,
-		Img:        img,
+		Sprite:     sprite,
 		Width:      16.0,
 		Height:     16.0,
 		Active:     true,
 		FollowDist: followDist,
-		State:      0,
-		Timer:      1.0, // 1 giây sau khi sinh ra mới bắt đầu lao tới
+		SpawnTimer: timings.SpawnDelay, // 1 giây sau khi sinh ra mới bắt đầu hoạt động
+		Behavior:   NewDasher(timings),
+		Anim:       NewAnimator(characterClips),
 	}
 }
 
-func (e *Enemy) Update(playerX, playerY float64, screenWidth, screenHeight float64) {
+// Update cập nhật enemy, việc di chuyển do Behavior quyết định
+func (e *Enemy) Update(ctx *BehaviorContext) {
 	if !e.Active || e.Health <= 0 {
 		e.Active = false
 		return
 	}
 
-	// 1. Cập nhật bộ đếm thời gian
-	e.Timer -= 1.0 / 60.0 // Giả sử game chạy 60 FPS
-
-	// 2. Kiểm tra đổi trạng thái
-	if e.Timer <= 0 {
-		if e.State == 0 { // Nếu đang nghỉ -> Chuyển sang Lao tới
-			e.State = 1
-			e.Timer = 1.2 // Lao tới trong 1.2 giây
-		} else { // Nếu đang lao tới -> Chuyển sang Nghỉ
-			e.State = 0
-			e.Timer = 0.8 // Nghỉ trong 0.8 giây
+	e.Action.Moving = false
+	// Mới sinh ra thì đứng yên một lúc
+	if e.SpawnTimer > 0 {
+		e.SpawnTimer -= ctx.DT
+	} else {
+		if e.Behavior != nil {
+			e.Behavior.Update(e, ctx)
 		}
+		e.updateWeapon(ctx)
 	}
+	e.animate(ctx.DT)
+}
 
-	// 3. Xử lý di chuyển dựa trên trạng thái
-	if e.State == 1 { // Chỉ di chuyển khi ở trạng thái Lao tới
-		dx := playerX - e.X
-		dy := playerY - e.Y
-		distance := math.Sqrt(dx*dx + dy*dy)
-
-		// Chỉ lao tới nếu trong tầm nhìn (FollowDist)
-		if distance < e.FollowDist && distance > 0 {
-			dx /= distance
-			dy /= distance
-
-			// Khi lao tới, có thể tăng tốc độ lên một chút (ví dụ e.Speed * 1.5)
-			speedMultiplier := 1.8
-			newX := e.X + dx*e.Speed*speedMultiplier
-			newY := e.Y + dy*e.Speed*speedMultiplier
-
-			// Giới hạn trong bản đồ
-			if newX >= 0 && newX <= screenWidth-e.Width {
-				e.X = newX
-			}
-			if newY >= 0 && newY <= screenHeight-e.Height {
-				e.Y = newY
-			}
-		}
+// animate chọn clip theo trạng thái; boss đang báo hiệu đòn thì giữ dáng đánh
+func (e *Enemy) animate(dt float64) {
+	if boss := AsBoss(e); boss != nil && boss.Telegraphing() {
+		e.Action.AttackTimer = max(e.Action.AttackTimer, attackTime)
+	}
+	e.Action.animate(e.Anim, dt, e.IsAlive())
+}
+
+// UpdateDeath tua animation chết của quái đã bị hạ, trả về false khi đã chạy xong
+func (e *Enemy) UpdateDeath(dt float64) bool {
+	if e.Anim == nil {
+		return false
+	}
+	e.Action.animate(e.Anim, dt, false)
+	return !e.Anim.Finished()
+}
+
+// updateWeapon bắn vào player khi hết cooldown và player trong tầm,
+// đạn bắn ra được gom vào ctx.Shots để World thêm vào danh sách projectile
+func (e *Enemy) updateWeapon(ctx *BehaviorContext) {
+	if e.Weapon == nil {
+		return
+	}
+	if e.FireTimer > 0 {
+		e.FireTimer -= ctx.DT
+		return
+	}
+
+	cx, cy := e.GetCenter()
+	if e.GetDistanceTo(ctx.PlayerCenterX, ctx.PlayerCenterY) > e.Weapon.Range {
+		return
+	}
+	ctx.Shots = append(ctx.Shots, e.Weapon.Fire(cx, cy, ctx.PlayerCenterX, ctx.PlayerCenterY)...)
+	e.FireTimer = e.Weapon.Cooldown
+	e.Action.Attacked(ctx.PlayerCenterX-cx, ctx.PlayerCenterY-cy)
+}
+
+// Move di chuyển enemy theo hướng (dx, dy) đã chuẩn hóa một đoạn dist (px),
+// trượt dọc tường và mỗi trục được giới hạn riêng trong bản đồ
+func (e *Enemy) Move(dx, dy, dist float64, ctx *BehaviorContext) {
+	e.Action.Moving = dist > 0
+	e.Action.Face(dx, dy)
+	newX, newY, _, _ := ctx.Collision.Slide(e.X, e.Y, e.Width, e.Height, dx*dist, dy*dist)
+
+	// Giới hạn trong bản đồ
+	if newX >= 0 && newX <= ctx.MapWidth-e.Width {
+		e.X = newX
+	}
+	if newY >= 0 && newY <= ctx.MapHeight-e.Height {
+		e.Y = newY
 	}
-	// Nếu e.State == 0 (Nghỉ), quái sẽ đứng yên tại chỗ vì code không thay đổi e.X, e.Y
 }
 
 // GetCenter trả về tọa độ trung tâm của enemy
@@ -102,6 +125,9 @@ func (e *Enemy) GetCenter() (float64, float64) {
 
 // TakeDamage nhận sát thương
 func (e *Enemy) TakeDamage(amount float64) {
+	if amount > 0 {
+		e.Action.Hurt()
+	}
 	e.Health -= amount
 	if e.Health <= 0 {
 		e.Health = 0
@@ -114,23 +140,6 @@ func (e *Enemy) IsAlive() bool {
 	return e.Active && e.Health > 0
 }
 
-// Draw vẽ enemy lên màn hình
-func (e *Enemy) Draw(screen *ebiten.Image, cameraX, cameraY float64) {
-	if !e.IsAlive() {
-		return
-	}
-
-	opts := &ebiten.DrawImageOptions{}
-	opts.GeoM.Translate(e.X-cameraX, e.Y-cameraY)
-
-	// Vẽ sprite từ spritesheet (16x16 đầu tiên)
-	screen.DrawImage(
-		e.Img.SubImage(image.Rect(0, 0, 16, 16)).(*ebiten.Image),
-		opts,
-	)
-	DrawHealthBar(screen, e.X-cameraX, e.Y-cameraY-5, e.Width, 2, e.Health/30.0, true)
-}
-
 // GetDistanceTo tính khoảng cách đến một điểm
 func (e *Enemy) GetDistanceTo(x, y float64) float64 {
 	ex, ey := e.GetCenter()
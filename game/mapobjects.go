@@ -0,0 +1,221 @@
+package game
+
+// Loại object trên map (trường "type"/"class" của object trong Tiled)
+const (
+	ObjectPlayerStart = "player_start" // Điểm xuất phát của player
+	ObjectSpawn       = "spawn"        // Điểm spawn quái, tên object dùng cho "point" trong file wave
+	ObjectGate        = "gate"         // Cổng chuyển map, thuộc tính "destination" là map đích
+	ObjectTrigger     = "trigger"      // Vùng kích hoạt sự kiện khi player bước vào
+)
+
+// TiledPoint là 1 đỉnh của polygon, tọa độ tương đối so với object
+type TiledPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// TiledProperty là 1 thuộc tính tùy chỉnh của object
+type TiledProperty struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+// TiledObject là 1 object trong object layer: điểm, hình chữ nhật, ellipse hoặc polygon.
+// Bỏ qua góc xoay (rotation) của object.
+type TiledObject struct {
+	ID         int             `json:"id"`
+	Name       string          `json:"name"`
+	Type       string          `json:"type"`
+	Class      string          `json:"class"` // Tiled 1.9 lưu loại object ở "class" thay vì "type"
+	X          float64         `json:"x"`
+	Y          float64         `json:"y"`
+	Width      float64         `json:"width"`
+	Height     float64         `json:"height"`
+	Point      bool            `json:"point"`
+	Ellipse    bool            `json:"ellipse"`
+	Polygon    []TiledPoint    `json:"polygon"`
+	Properties []TiledProperty `json:"properties"`
+}
+
+// Kind trả về loại object (type hoặc class tùy phiên bản Tiled)
+func (o *TiledObject) Kind() string {
+	if o.Type != "" {
+		return o.Type
+	}
+	return o.Class
+}
+
+// Property trả về giá trị thuộc tính theo tên
+func (o *TiledObject) Property(name string) (any, bool) {
+	for _, p := range o.Properties {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+	return nil, false
+}
+
+// StringProperty trả về thuộc tính dạng chuỗi ("" nếu không có)
+func (o *TiledObject) StringProperty(name string) string {
+	v, _ := o.Property(name)
+	s, _ := v.(string)
+	return s
+}
+
+// FloatProperty trả về thuộc tính dạng số (def nếu không có)
+func (o *TiledObject) FloatProperty(name string, def float64) float64 {
+	v, _ := o.Property(name)
+	if f, ok := v.(float64); ok {
+		return f
+	}
+	return def
+}
+
+// BoolProperty trả về thuộc tính dạng bool (def nếu không có)
+func (o *TiledObject) BoolProperty(name string, def bool) bool {
+	v, _ := o.Property(name)
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	return def
+}
+
+// Center trả về tâm của object (với điểm thì là chính điểm đó)
+func (o *TiledObject) Center() (float64, float64) {
+	if len(o.Polygon) > 0 {
+		var sx, sy float64
+		for _, p := range o.Polygon {
+			sx += p.X
+			sy += p.Y
+		}
+		n := float64(len(o.Polygon))
+		return o.X + sx/n, o.Y + sy/n
+	}
+	return o.X + o.Width/2, o.Y + o.Height/2
+}
+
+// Contains kiểm tra điểm (x, y) có nằm trong vùng của object không.
+// Object dạng điểm không có diện tích nên luôn trả về false.
+func (o *TiledObject) Contains(x, y float64) bool {
+	switch {
+	case o.Point:
+		return false
+	case len(o.Polygon) > 0:
+		return pointInPolygon(x-o.X, y-o.Y, o.Polygon)
+	case o.Ellipse:
+		if o.Width == 0 || o.Height == 0 {
+			return false
+		}
+		rx, ry := o.Width/2, o.Height/2
+		dx := (x - o.X - rx) / rx
+		dy := (y - o.Y - ry) / ry
+		return dx*dx+dy*dy <= 1
+	default:
+		return x >= o.X && x < o.X+o.Width && y >= o.Y && y < o.Y+o.Height
+	}
+}
+
+// pointInPolygon kiểm tra điểm trong polygon bằng thuật toán bắn tia (ray casting)
+func pointInPolygon(x, y float64, poly []TiledPoint) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a.Y > y) != (b.Y > y) && x < (b.X-a.X)*(y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Gate là cổng chuyển map đọc từ object layer
+type Gate struct {
+	Name        string
+	Destination string // Map đích (đường dẫn tương đối so với file map hiện tại)
+	Area        TiledObject
+}
+
+// Trigger là vùng kích hoạt sự kiện khi player bước vào
+type Trigger struct {
+	Name   string
+	Event  string // Tên sự kiện (thuộc tính "event"), game tự quyết định cách xử lý
+	Once   bool   // Chỉ kích hoạt 1 lần (thuộc tính "once")
+	Area   TiledObject
+	Inside bool // Player đang đứng trong vùng
+	Fired  bool // Đã kích hoạt ít nhất 1 lần
+}
+
+// TriggerEvent là sự kiện phát ra khi player bước vào 1 trigger
+type TriggerEvent struct {
+	Trigger *Trigger
+	Event   string
+}
+
+// loadMapObjects đọc điểm xuất phát, điểm spawn, cổng và trigger từ object layer của map
+func (w *World) loadMapObjects() {
+	w.SpawnPoints = nil
+	w.Gates = nil
+	w.Triggers = nil
+	w.HasPlayerStart = false
+	if w.Tilemap == nil {
+		return
+	}
+
+	for _, o := range w.Tilemap.Objects() {
+		switch o.Kind() {
+		case ObjectPlayerStart:
+			w.PlayerStartX, w.PlayerStartY = o.Center()
+			w.HasPlayerStart = true
+		case ObjectSpawn:
+			x, y := o.Center()
+			w.SpawnPoints = append(w.SpawnPoints, SpawnPoint{Name: o.Name, X: x, Y: y})
+		case ObjectGate:
+			w.Gates = append(w.Gates, &Gate{
+				Name:        o.Name,
+				Destination: o.StringProperty("destination"),
+				Area:        o,
+			})
+		case ObjectTrigger:
+			w.Triggers = append(w.Triggers, &Trigger{
+				Name:  o.Name,
+				Event: o.StringProperty("event"),
+				Once:  o.BoolProperty("once", false),
+				Area:  o,
+			})
+		}
+	}
+}
+
+// CurrentGate trả về cổng mà player đang đứng trên (nil nếu không có)
+func (w *World) CurrentGate() *Gate {
+	if w.Player == nil {
+		return nil
+	}
+	px, py := w.Player.GetCenter()
+	for _, g := range w.Gates {
+		if g.Area.Contains(px, py) {
+			return g
+		}
+	}
+	return nil
+}
+
+// updateTriggers phát sự kiện khi player vừa bước vào vùng trigger
+func (w *World) updateTriggers() {
+	px, py := w.Player.GetCenter()
+	for _, t := range w.Triggers {
+		inside := t.Area.Contains(px, py)
+		if inside && !t.Inside && !(t.Once && t.Fired) {
+			t.Fired = true
+			w.Events = append(w.Events, TriggerEvent{Trigger: t, Event: t.Event})
+		}
+		t.Inside = inside
+	}
+}
+
+// TakeEvents trả về và xóa các sự kiện trigger chưa được xử lý
+func (w *World) TakeEvents() []TriggerEvent {
+	events := w.Events
+	w.Events = nil
+	return events
+}
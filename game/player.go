@@ -1,32 +1,33 @@
 package game
 
-import (
-	"image"
-	"math"
+import "math"
 
-	"github.com/hajimehoshi/ebiten/v2"
-)
+// DefaultPlayerSpeed là tốc độ chạy mặc định của player (px mỗi giây)
+const DefaultPlayerSpeed = 192
 
 // Player đại diện cho nhân vật người chơi
 type Player struct {
 	X, Y         float64
 	Health       float64
 	MaxHealth    float64
-	Speed        float64 // px mỗi frame
+	Speed        float64 // px mỗi giây
 	AttackDamage float64
 	AttackSpeed  float64
 	AttackTimer  float64
-	Img          *ebiten.Image
+	Sprite       string // Sprite ID để renderer tra ảnh
 	Width        float64
 	Height       float64
 	Skills       []Skill
+	Action       Action    // Hướng quay mặt và dáng đang làm, quyết định clip animation
+	Anim         *Animator // Animation của sprite
+	Fit          SpriteFit // Cách đặt sheet của sprite lên khung va chạm (world gán khi nhận player)
 }
 
 // NewPlayer tạo player mới
-func NewPlayer(img *ebiten.Image, x, y, maxHealth, speed, attackDamage, attackSpeed float64) *Player {
-	// Fix speed cứng ở đây nếu muốn mặc định (vd 3.2 mượt hơn, game archero thật thường > 3)
-	if speed < 2.7 {
-		speed = 3.2
+func NewPlayer(sprite string, x, y, maxHealth, speed, attackDamage, attackSpeed float64) *Player {
+	// Fix speed cứng ở đây nếu muốn mặc định (vd 192 px/s mượt hơn, game archero thật thường > 180)
+	if speed < 162 {
+		speed = 192
 	}
 	return &Player{
 		X:            x,
@@ -37,17 +38,19 @@ func NewPlayer(img *ebiten.Image, x, y, maxHealth, speed, attackDamage, attackSp
 		AttackDamage: attackDamage,
 		AttackSpeed:  attackSpeed,
 		AttackTimer:  0.0,
-		Img:          img,
+		Sprite:       sprite,
 		Width:        16.0,
 		Height:       16.0,
+		Anim:         NewAnimator(characterClips),
 	}
 }
 
 // Update cập nhật trạng thái player
-func (p *Player) Update() {
+func (p *Player) Update(clock *Clock) {
 	if p.AttackTimer > 0 {
-		p.AttackTimer -= 1.0 / 60.0 // Giả sử 60 FPS
+		p.AttackTimer -= clock.DT()
 	}
+	p.Action.animate(p.Anim, clock.DT(), p.IsAlive())
 }
 
 // CanAttack kiểm tra xem player có thể tấn công không
@@ -55,16 +58,19 @@ func (p *Player) CanAttack() bool {
 	return p.AttackTimer <= 0
 }
 
-// Attack thực hiện tấn công và reset timer
-func (p *Player) Attack() {
+// Attack thực hiện tấn công về phía (dx, dy) và reset timer
+func (p *Player) Attack(dx, dy float64) {
 	p.AttackTimer = 1.0 / p.AttackSpeed
+	p.Action.Attacked(dx, dy)
 }
 
-// Move di chuyển player mượt mà hơn
-func (p *Player) Move(dx, dy float64, mapWidth, mapHeight float64) {
+// Move di chuyển player mượt mà hơn, đâm vào tường thì trượt dọc theo tường
+func (p *Player) Move(clock *Clock, dx, dy float64, mapWidth, mapHeight float64, grid *CollisionGrid) {
 	// Tính toán vị trí mới tiềm năng
-	newX := p.X + dx*p.Speed
-	newY := p.Y + dy*p.Speed
+	p.Action.Moving = dx != 0 || dy != 0
+	p.Action.Face(dx, dy)
+	step := p.Speed * clock.DT()
+	newX, newY, _, _ := grid.Slide(p.X, p.Y, p.Width, p.Height, dx*step, dy*step)
 
 	// Kiểm tra và cập nhật X riêng biệt
 	if newX >= 0 && newX <= mapWidth-p.Width {
@@ -82,19 +88,6 @@ func (p *Player) GetCenter() (float64, float64) {
 	return p.X + p.Width/2, p.Y + p.Height/2
 }
 
-// Draw vẽ player lên màn hình
-func (p *Player) Draw(screen *ebiten.Image, cameraX, cameraY float64) {
-	opts := &ebiten.DrawImageOptions{}
-	opts.GeoM.Translate(p.X-cameraX, p.Y-cameraY)
-
-	// Vẽ sprite từ spritesheet (16x16 đầu tiên)
-	screen.DrawImage(
-		p.Img.SubImage(image.Rect(0, 0, 16, 16)).(*ebiten.Image),
-		opts,
-	)
-	DrawHealthBar(screen, p.X-cameraX, p.Y-cameraY-8, p.Width, 3, p.Health/p.MaxHealth, false)
-}
-
 // GetDistanceTo tính khoảng cách đến một điểm
 func (p *Player) GetDistanceTo(x, y float64) float64 {
 	px, py := p.GetCenter()
@@ -105,6 +98,9 @@ func (p *Player) GetDistanceTo(x, y float64) float64 {
 
 // TakeDamage nhận sát thương
 func (p *Player) TakeDamage(amount float64) {
+	if amount > 0 {
+		p.Action.Hurt()
+	}
 	p.Health -= amount
 	if p.Health < 0 {
 		p.Health = 0
@@ -133,7 +129,7 @@ func (p *Player) LearnSkill(s Skill) {
 	case AttackBoost:
 		p.AttackDamage *= 1.2
 	case SpeedBoost:
-		p.Speed += 0.5
+		p.Speed += 30
 	}
 }
 
@@ -0,0 +1,22 @@
+package game
+
+// Potion là bình máu rơi ra khi quái chết
+type Potion struct {
+	X, Y   float64
+	Sprite string // Sprite ID để renderer tra ảnh
+	Width  float64
+	Height float64
+	Heal   float64 // Lượng máu hồi khi nhặt
+}
+
+// NewPotion tạo bình máu mới tại vị trí (x, y)
+func NewPotion(sprite string, x, y float64) *Potion {
+	return &Potion{
+		X:      x,
+		Y:      y,
+		Sprite: sprite,
+		Width:  16, // Điều chỉnh kích thước tùy theo asset của bạn
+		Height: 16,
+		Heal:   20,
+	}
+}
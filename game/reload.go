@@ -0,0 +1,43 @@
+package game
+
+// Các hàm Reload* thay map và dữ liệu của world đang chạy (chế độ dev, khi file asset thay đổi)
+// mà không bắt đầu lại lượt chơi: player, quái đang sống, đạn và tiến độ wave được giữ nguyên.
+
+// ReloadTilemap thay map hiện tại bằng bản vừa load lại. Player và quái được đưa vào trong
+// map mới nếu map bị thu nhỏ.
+func (w *World) ReloadTilemap(tilemap *TilemapJSON) {
+	w.setTilemap(tilemap)
+	if w.Wave != nil {
+		w.Wave.ScreenWidth, w.Wave.ScreenHeight = w.MapWidth, w.MapHeight
+	}
+	if p := w.Player; p != nil {
+		p.X, p.Y = w.clampToMap(p.X, p.Y, p.Width, p.Height)
+	}
+	for _, e := range w.Enemies {
+		e.X, e.Y = w.clampToMap(e.X, e.Y, e.Width, e.Height)
+	}
+}
+
+// ReloadChapter thay chapter bằng bản vừa load lại, vẫn ở phòng hiện tại (hoặc phòng cuối
+// nếu chapter mới ít phòng hơn). Map của phòng được thay ngay, wave mới áp dụng từ wave kế tiếp.
+func (w *World) ReloadChapter(chapter *ChapterDef) {
+	if chapter == nil || w.Chapter == nil {
+		w.SetChapter(chapter)
+		return
+	}
+	w.Chapter = chapter
+	w.Room = min(w.Room, len(chapter.Rooms)-1)
+	w.ReloadTilemap(w.roomTilemap(w.Room))
+	if w.Wave != nil {
+		w.Wave.Script = chapter.Rooms[w.Room].Script()
+	}
+}
+
+// ReloadWaveScript thay kịch bản wave, áp dụng từ wave kế tiếp (wave đang chạy không bị lên lịch lại).
+// Khi chơi chapter, wave lấy theo phòng nên chỉ lưu lại kịch bản.
+func (w *World) ReloadWaveScript(script *WaveScript) {
+	w.WaveScript = script
+	if w.Wave != nil && w.Chapter == nil {
+		w.Wave.Script = script
+	}
+}
@@ -0,0 +1,389 @@
+package game
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// replayMagic đánh dấu đầu file replay
+const replayMagic = "ARPL"
+
+// replayVersion là phiên bản định dạng file replay.
+// Bản 2 thêm file chapter vào header, bản 3 thêm nội dung file chapter để replay tự chứa đủ
+// luật sinh phòng, bản 4 thêm nội dung file quái, boss và wave. File bản cũ vẫn đọc được
+// (bản 1 coi như không chơi chapter, bản 2 đọc chapter từ đĩa, bản 3 trở xuống đọc dữ liệu từ đĩa).
+const replayVersion = 4
+
+// Giới hạn khi đọc replay, để file hỏng hoặc cố tình sửa không làm game cấp phát quá nhiều bộ nhớ
+const (
+	maxReplayMapLen  = 4 << 10 // Độ dài tối đa của đường dẫn map và chapter (byte)
+	maxReplayFileLen = 1 << 20 // Kích thước tối đa của nội dung mỗi file lưu kèm: chapter, quái, boss, wave (byte)
+	maxReplayInputs  = 1 << 24 // Tổng số bước tối đa sau khi bung các run (~77 giờ ở 60 bước/giây)
+)
+
+// Các bit cờ của 1 input trong file replay
+const (
+	replaySkillMask  = 0x03 // 2 bit thấp: SkillPick (0..3)
+	replayReroll     = 1 << 2
+	replayInteract   = 1 << 3
+	replayOpenSkills = 1 << 4
+)
+
+// ReplayStart là trạng thái player lúc bắt đầu lượt chơi được ghi
+type ReplayStart struct {
+	X, Y         float64
+	MaxHealth    float64
+	AttackDamage float64
+	AttackSpeed  float64
+}
+
+// GameData là nội dung các file dữ liệu gameplay: quái, boss và kịch bản wave.
+// File trống (nil) nghĩa là không có dữ liệu, world dùng quái, boss mặc định và wave endless.
+type GameData struct {
+	Enemies []byte
+	Bosses  []byte
+	Waves   []byte
+}
+
+// Replay lưu seed, trạng thái ban đầu và input của từng bước mô phỏng.
+// Chạy lại cùng replay trên cùng map sẽ cho ra đúng lượt chơi đã ghi.
+type Replay struct {
+	Seed     uint64
+	TickRate int
+	Map      string // Đường dẫn map lúc ghi
+	Chapter  string // File chapter lúc ghi, rỗng = chơi 1 map với wave endless
+	// Nội dung file chapter lúc ghi. Phòng sinh ngẫu nhiên phụ thuộc seed và luật trong chapter,
+	// nên lưu kèm để sửa file chapter sau khi ghi không làm replay lệch.
+	ChapterData []byte
+	// Nội dung file quái, boss và wave lúc ghi, để sửa cân bằng game sau khi ghi không làm replay lệch.
+	// nil = replay bản cũ, dữ liệu phải đọc lại từ đĩa.
+	Data   *GameData
+	Start  ReplayStart
+	Inputs []Input // Input của từng bước, theo thứ tự
+}
+
+// NewReplay tạo replay rỗng cho world vừa Reset trên map mapPath.
+// Chapter đang chơi (nếu có) được lưu kèm cả đường dẫn lẫn nội dung.
+func NewReplay(w *World, mapPath string) *Replay {
+	r := &Replay{
+		Seed:     w.Seed,
+		TickRate: int(math.Round(1 / w.Clock.Step)),
+		Map:      mapPath,
+		Start: ReplayStart{
+			X:            w.Player.X,
+			Y:            w.Player.Y,
+			MaxHealth:    w.Player.MaxHealth,
+			AttackDamage: w.Player.AttackDamage,
+			AttackSpeed:  w.Player.AttackSpeed,
+		},
+	}
+	if w.Chapter != nil {
+		r.Chapter = w.Chapter.Path
+		r.ChapterData = w.Chapter.Source
+	}
+	data := w.Data
+	r.Data = &data
+	return r
+}
+
+// LoadChapter trả về chapter lúc ghi (nil nếu lúc ghi không chơi chapter).
+// Dùng nội dung lưu trong replay nếu có, chỉ replay bản cũ mới đọc lại file chapter từ đĩa.
+func (r *Replay) LoadChapter() (*ChapterDef, error) {
+	switch {
+	case r.ChapterData != nil:
+		return ParseChapter(r.Chapter, r.ChapterData)
+	case r.Chapter != "":
+		return LoadChapter(r.Chapter)
+	}
+	return nil, nil
+}
+
+// Record ghi lại input của 1 bước mô phỏng
+func (r *Replay) Record(in Input) {
+	r.Inputs = append(r.Inputs, in)
+}
+
+// NewWorld tạo world từ replay: cùng seed, cùng tick rate và cùng player ban đầu.
+// sprites phải giống lúc ghi vì hitbox của sheet quyết định khung va chạm (nil = không có sheet nào).
+func (r *Replay) NewWorld(tilemap *TilemapJSON, sprites *SpriteLibrary) *World {
+	w := NewWorld(tilemap, r.Seed)
+	w.Clock = NewClock(r.TickRate)
+	w.Sprites = sprites
+	w.Reset(NewPlayer(
+		SpritePlayer,
+		r.Start.X,
+		r.Start.Y,
+		r.Start.MaxHealth,
+		DefaultPlayerSpeed,
+		r.Start.AttackDamage,
+		r.Start.AttackSpeed,
+	))
+	return w
+}
+
+// Run chạy toàn bộ input của replay trên world (không cần cửa sổ)
+func (r *Replay) Run(w *World) {
+	for _, in := range r.Inputs {
+		w.Step(in)
+	}
+}
+
+// ReplayCursor đọc lần lượt input của replay, mỗi bước 1 input
+type ReplayCursor struct {
+	Replay *Replay
+	Pos    int
+}
+
+// Next trả về input của bước kế tiếp, false nếu replay đã hết
+func (c *ReplayCursor) Next() (Input, bool) {
+	if c.Pos >= len(c.Replay.Inputs) {
+		return Input{}, false
+	}
+	in := c.Replay.Inputs[c.Pos]
+	c.Pos++
+	return in, true
+}
+
+// Done kiểm tra replay đã chạy hết chưa
+func (c *ReplayCursor) Done() bool {
+	return c.Pos >= len(c.Replay.Inputs)
+}
+
+// packedInput là input đã nén còn 3 byte: hướng X, hướng Y và các cờ
+type packedInput [3]byte
+
+// replayRun là 1 đoạn các bước liên tiếp có cùng input
+type replayRun struct {
+	Count uint64
+	Input packedInput
+}
+
+func packInput(in Input) packedInput {
+	var flags byte
+	if in.SkillPick >= 1 && in.SkillPick <= 3 {
+		flags |= byte(in.SkillPick)
+	}
+	if in.Reroll {
+		flags |= replayReroll
+	}
+	if in.Interact {
+		flags |= replayInteract
+	}
+	if in.OpenSkills {
+		flags |= replayOpenSkills
+	}
+	return packedInput{byte(packAxis(in.MoveX)), byte(packAxis(in.MoveY)), flags}
+}
+
+func unpackInput(p packedInput) Input {
+	return Input{
+		MoveX:      float64(int8(p[0])) / 127,
+		MoveY:      float64(int8(p[1])) / 127,
+		SkillPick:  int(p[2] & replaySkillMask),
+		Reroll:     p[2]&replayReroll != 0,
+		Interact:   p[2]&replayInteract != 0,
+		OpenSkills: p[2]&replayOpenSkills != 0,
+	}
+}
+
+// packAxis lượng tử hóa hướng (-1..1) thành int8, phím bấm (-1, 0, 1) giữ nguyên chính xác
+func packAxis(v float64) int8 {
+	v = math.Max(-1, math.Min(1, v))
+	return int8(math.Round(v * 127))
+}
+
+// Encode ghi replay ra writer.
+// Input được nén theo kiểu run-length: (số bước lặp, input 3 byte).
+func (r *Replay) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString(replayMagic)
+	bw.WriteByte(replayVersion)
+
+	var buf [binary.MaxVarintLen64]byte
+	putUvarint := func(v uint64) {
+		n := binary.PutUvarint(buf[:], v)
+		bw.Write(buf[:n])
+	}
+
+	binary.Write(bw, binary.LittleEndian, r.Seed)
+	putUvarint(uint64(r.TickRate))
+	putUvarint(uint64(len(r.Map)))
+	bw.WriteString(r.Map)
+	putUvarint(uint64(len(r.Chapter)))
+	bw.WriteString(r.Chapter)
+	putUvarint(uint64(len(r.ChapterData)))
+	bw.Write(r.ChapterData)
+	var data GameData
+	if r.Data != nil {
+		data = *r.Data
+	}
+	for _, b := range [][]byte{data.Enemies, data.Bosses, data.Waves} {
+		putUvarint(uint64(len(b)))
+		bw.Write(b)
+	}
+	binary.Write(bw, binary.LittleEndian, r.Start)
+
+	// Gom các input giống nhau liên tiếp thành 1 run
+	var runs []replayRun
+	for i := 0; i < len(r.Inputs); {
+		p := packInput(r.Inputs[i])
+		j := i + 1
+		for j < len(r.Inputs) && packInput(r.Inputs[j]) == p {
+			j++
+		}
+		runs = append(runs, replayRun{Count: uint64(j - i), Input: p})
+		i = j
+	}
+
+	putUvarint(uint64(len(runs)))
+	for _, run := range runs {
+		putUvarint(run.Count)
+		bw.Write(run.Input[:])
+	}
+
+	return bw.Flush()
+}
+
+// DecodeReplay đọc replay từ reader
+func DecodeReplay(rd io.Reader) (*Replay, error) {
+	br := bufio.NewReader(rd)
+
+	header := make([]byte, len(replayMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(replayMagic)]) != replayMagic {
+		return nil, errors.New("replay: sai định dạng file")
+	}
+	version := header[len(replayMagic)]
+	if version < 1 || version > replayVersion {
+		return nil, fmt.Errorf("replay: không hỗ trợ phiên bản %d", version)
+	}
+
+	r := &Replay{}
+	if err := binary.Read(br, binary.LittleEndian, &r.Seed); err != nil {
+		return nil, err
+	}
+	tickRate, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	r.TickRate = int(tickRate)
+
+	if r.Map, err = readReplayString(br, "map"); err != nil {
+		return nil, err
+	}
+	if version >= 2 {
+		if r.Chapter, err = readReplayString(br, "chapter"); err != nil {
+			return nil, err
+		}
+	}
+	if version >= 3 {
+		if r.ChapterData, err = readReplayBytes(br, "nội dung chapter", maxReplayFileLen); err != nil {
+			return nil, err
+		}
+	}
+	if version >= 4 {
+		r.Data = &GameData{}
+		for _, f := range []struct {
+			name string
+			dst  *[]byte
+		}{
+			{"dữ liệu quái", &r.Data.Enemies},
+			{"dữ liệu boss", &r.Data.Bosses},
+			{"kịch bản wave", &r.Data.Waves},
+		} {
+			if *f.dst, err = readReplayBytes(br, f.name, maxReplayFileLen); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := binary.Read(br, binary.LittleEndian, &r.Start); err != nil {
+		return nil, err
+	}
+
+	runCount, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	total := uint64(0)
+	for i := uint64(0); i < runCount; i++ {
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		// Mỗi run có ít nhất 1 bước nên số run cũng bị giới hạn theo tổng số bước
+		if n == 0 {
+			return nil, fmt.Errorf("replay: run %d rỗng", i)
+		}
+		if total += n; n > maxReplayInputs || total > maxReplayInputs {
+			return nil, fmt.Errorf("replay: quá %d bước", maxReplayInputs)
+		}
+		var p packedInput
+		if _, err := io.ReadFull(br, p[:]); err != nil {
+			return nil, err
+		}
+		in := unpackInput(p)
+		for k := uint64(0); k < n; k++ {
+			r.Inputs = append(r.Inputs, in)
+		}
+	}
+
+	return r, nil
+}
+
+// readReplayString đọc 1 đường dẫn (độ dài varint rồi nội dung) trong header, tối đa maxReplayMapLen byte
+func readReplayString(br *bufio.Reader, name string) (string, error) {
+	b, err := readReplayBytes(br, "đường dẫn "+name, maxReplayMapLen)
+	return string(b), err
+}
+
+// readReplayBytes đọc 1 khối byte (độ dài varint rồi nội dung) trong header, tối đa limit byte.
+// Khối rỗng trả về nil.
+func readReplayBytes(br *bufio.Reader, name string, limit uint64) ([]byte, error) {
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if n > limit {
+		return nil, fmt.Errorf("replay: %s dài %d byte, tối đa %d", name, n, limit)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(br, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// SaveReplay ghi replay ra file
+func SaveReplay(path string, r *Replay) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.Encode(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadReplay đọc replay từ file
+func LoadReplay(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return DecodeReplay(f)
+}
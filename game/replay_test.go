@@ -0,0 +1,313 @@
+package game
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "ghi lại các file golden trong testdata")
+
+const (
+	goldenReplayPath   = "testdata/golden.rpl"
+	goldenSnapshotPath = "testdata/golden.txt"
+)
+
+func TestReplayRoundTrip(t *testing.T) {
+	r := &Replay{
+		Seed:        0xdeadbeefcafe,
+		TickRate:    120,
+		Map:         "assets/maps/spawn.json",
+		Chapter:     "assets/data/chapter1.json",
+		ChapterData: []byte(`{"rooms": []}`),
+		Data:        &GameData{Enemies: []byte(`{"enemies": []}`), Waves: []byte(`{"waves": []}`)},
+		Start:       ReplayStart{X: 10.5, Y: -3, MaxHealth: 100, AttackDamage: 12.5, AttackSpeed: 1.25},
+	}
+	for _, in := range []Input{
+		{},
+		{MoveX: 1},
+		{MoveX: 1},
+		{MoveX: -1, MoveY: 1},
+		{MoveX: 0.5, MoveY: -0.25, SkillPick: 3},
+		{Reroll: true, Interact: true, OpenSkills: true},
+		{SkillPick: 1},
+		{SkillPick: 2},
+		{MoveY: -1},
+	} {
+		r.Record(in)
+	}
+	for i := 0; i < 1000; i++ {
+		r.Record(Input{MoveY: -1})
+	}
+
+	var buf bytes.Buffer
+	if err := r.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.Bytes()
+	got, err := DecodeReplay(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Hướng được lượng tử hóa còn 1/127 nên so với input đã qua pack/unpack
+	want := *r
+	want.Inputs = nil
+	for _, in := range r.Inputs {
+		want.Inputs = append(want.Inputs, unpackInput(packInput(in)))
+	}
+	if !reflect.DeepEqual(got, &want) {
+		t.Fatalf("giải mã ra %+v, muốn %+v", got, &want)
+	}
+	if in := got.Inputs[4]; math.Abs(in.MoveX-0.5) > 1.0/127 || math.Abs(in.MoveY+0.25) > 1.0/127 {
+		t.Errorf("hướng lệch quá 1 bậc lượng tử: %+v", in)
+	}
+
+	// Ghi lại bản đã giải mã phải ra đúng từng byte
+	var again bytes.Buffer
+	if err := got.Encode(&again); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Bytes(), encoded) {
+		t.Error("ghi lại replay đã giải mã cho ra byte khác")
+	}
+}
+
+// rawReplay ghi header replay với đường dẫn map dài mapLen byte (chỉ ghi tối đa len(mapName) byte),
+// không có chapter, và các run (số bước, input) cho trước, để dựng file hỏng
+func rawReplay(mapLen uint64, mapName string, runs ...uint64) []byte {
+	return rawReplayVersion(replayVersion, mapLen, mapName, runs...)
+}
+
+// rawReplayVersion giống rawReplay nhưng ghi theo phiên bản định dạng cho trước
+func rawReplayVersion(version byte, mapLen uint64, mapName string, runs ...uint64) []byte {
+	var b bytes.Buffer
+	b.WriteString(replayMagic)
+	b.WriteByte(version)
+	binary.Write(&b, binary.LittleEndian, uint64(1))
+	b.Write(binary.AppendUvarint(nil, DefaultTickRate))
+	b.Write(binary.AppendUvarint(nil, mapLen))
+	b.WriteString(mapName)
+	if version >= 2 {
+		b.WriteByte(0) // Không có chapter
+	}
+	if version >= 3 {
+		b.WriteByte(0) // Không có nội dung chapter
+	}
+	if version >= 4 {
+		b.Write([]byte{0, 0, 0}) // Không có dữ liệu quái, boss, wave
+	}
+	binary.Write(&b, binary.LittleEndian, ReplayStart{})
+	b.Write(binary.AppendUvarint(nil, uint64(len(runs))))
+	for _, n := range runs {
+		b.Write(binary.AppendUvarint(nil, n))
+		b.Write([]byte{0, 0, 0})
+	}
+	return b.Bytes()
+}
+
+// encodedReplay ghi replay ra byte
+func encodedReplay(tb testing.TB, r *Replay) []byte {
+	tb.Helper()
+	var buf bytes.Buffer
+	if err := r.Encode(&buf); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeReplayLimits(t *testing.T) {
+	longMap := strings.Repeat("a", maxReplayMapLen)
+	chapterData := func(n int) []byte {
+		return encodedReplay(t, &Replay{Chapter: "c.json", ChapterData: bytes.Repeat([]byte{' '}, n), Inputs: []Input{{}}})
+	}
+	bossData := func(n int) []byte {
+		return encodedReplay(t, &Replay{Data: &GameData{Bosses: bytes.Repeat([]byte{' '}, n)}, Inputs: []Input{{}}})
+	}
+	tests := []struct {
+		name string
+		data []byte
+		ok   bool
+	}{
+		{"hợp lệ", rawReplay(3, "map", 5, 7), true},
+		{"bản 1 không có chapter", rawReplayVersion(1, 3, "map", 5, 7), true},
+		{"bản 2 không có nội dung chapter", rawReplayVersion(2, 3, "map", 5, 7), true},
+		{"bản 3 không có dữ liệu", rawReplayVersion(3, 3, "map", 5, 7), true},
+		{"phiên bản mới hơn", rawReplayVersion(replayVersion+1, 3, "map", 5, 7), false},
+		{"map dài đúng giới hạn", rawReplay(maxReplayMapLen, longMap, 1), true},
+		{"map quá dài", rawReplay(maxReplayMapLen+1, longMap+"a", 1), false},
+		{"độ dài map khổng lồ", rawReplay(1<<62, "", 1), false},
+		{"chapter lớn đúng giới hạn", chapterData(maxReplayFileLen), true},
+		{"chapter quá lớn", chapterData(maxReplayFileLen + 1), false},
+		{"dữ liệu boss lớn đúng giới hạn", bossData(maxReplayFileLen), true},
+		{"dữ liệu boss quá lớn", bossData(maxReplayFileLen + 1), false},
+		{"tổng số bước quá giới hạn", rawReplay(0, "", 1, maxReplayInputs), false},
+		{"1 run khổng lồ", rawReplay(0, "", math.MaxUint64), false},
+		{"nhiều run cộng tràn số", rawReplay(0, "", math.MaxUint64/2+1, math.MaxUint64/2+1), false},
+		{"run rỗng", rawReplay(0, "", 0), false},
+		{"cắt cụt", rawReplay(3, "map", 5)[:30], false},
+		{"sai magic", append([]byte("XXXX"), rawReplay(0, "", 1)[4:]...), false},
+	}
+	for _, tt := range tests {
+		r, err := DecodeReplay(bytes.NewReader(tt.data))
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: lỗi = %v, muốn ok = %v", tt.name, err, tt.ok)
+		}
+		if err == nil && len(r.Inputs) > maxReplayInputs {
+			t.Errorf("%s: bung ra %d bước", tt.name, len(r.Inputs))
+		}
+	}
+}
+
+// recordGoldenReplay ghi 1 lượt chơi 30 giây trên map spawn bằng input giả lập
+func recordGoldenReplay(t *testing.T) *Replay {
+	w := newTestWorld(t, 99, "")
+	r := NewReplay(w, "assets/maps/spawn.json")
+	for _, in := range scriptedInputs(30*DefaultTickRate, 3) {
+		w.Step(in)
+		r.Record(in)
+	}
+	return r
+}
+
+// TestReplayGolden chạy lại file replay đã lưu và so trạng thái cuối với bản đã lưu.
+// Đổi gameplay có chủ ý thì chạy go test ./game -run Golden -update để ghi lại.
+func TestReplayGolden(t *testing.T) {
+	if *updateGolden {
+		var buf bytes.Buffer
+		if err := recordGoldenReplay(t).Encode(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(goldenReplayPath, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := LoadReplay(goldenReplayPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Data == nil {
+		t.Fatal("file golden không lưu dữ liệu quái, boss, wave")
+	}
+	archetypes, bosses, script := parseTestData(t, *r.Data)
+	tilemap, err := LoadTilemap(filepath.Join("..", r.Map))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := r.NewWorld(tilemap, nil)
+	replayed.SetArchetypes(archetypes)
+	replayed.SetBosses(bosses)
+	replayed.SetWaveScript(script)
+	r.Run(replayed)
+	got := worldSnapshot(replayed)
+
+	if *updateGolden {
+		if err := os.WriteFile(goldenSnapshotPath, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(goldenSnapshotPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("replay cho kết quả khác bản đã lưu:\n%s\n---\n%s", got, want)
+	}
+}
+
+// TestReplayEmbedsChapter ghi 1 lượt chơi chapter có phòng sinh ngẫu nhiên, rồi phát lại khi file chapter
+// không còn ở chỗ cũ: replay phải tự dựng lại chapter và cho đúng lượt chơi đã ghi
+func TestReplayEmbedsChapter(t *testing.T) {
+	const chapterPath = "../assets/data/chapter1.json"
+	w := newTestWorld(t, 5, chapterPath)
+	r := NewReplay(w, "../assets/maps/spawn.json")
+	if r.Chapter != chapterPath || len(r.ChapterData) == 0 {
+		t.Fatalf("replay không lưu chapter: %q, %d byte", r.Chapter, len(r.ChapterData))
+	}
+	for _, in := range scriptedInputs(20*DefaultTickRate, 11) {
+		w.Step(in)
+		r.Record(in)
+	}
+
+	decoded, err := DecodeReplay(bytes.NewReader(encodedReplay(t, r)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Đổi tên file chapter nhưng giữ thư mục, map của phòng vẫn tìm được tương đối so với đó
+	decoded.Chapter = "../assets/data/da_doi_ten.json"
+	chapter, err := decoded.LoadChapter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *chapter.Rooms[0].Generate != *w.Chapter.Rooms[0].Generate {
+		t.Fatalf("luật sinh phòng khác: %+v, muốn %+v", chapter.Rooms[0].Generate, w.Chapter.Rooms[0].Generate)
+	}
+
+	tilemap, err := LoadTilemap(decoded.Map)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := decoded.NewWorld(tilemap, nil)
+	replayed.SetArchetypes(w.Archetypes)
+	replayed.SetBosses(w.Bosses)
+	replayed.SetWaveScript(w.WaveScript)
+	replayed.SetChapter(chapter)
+	decoded.Run(replayed)
+	if got, want := worldSnapshot(replayed), worldSnapshot(w); got != want {
+		t.Errorf("phát lại khác lượt chơi đã ghi:\n%s\n---\n%s", got, want)
+	}
+
+	// Replay không chơi chapter thì không có chapter
+	if chapter, err := NewReplay(newTestWorld(t, 5, ""), "").LoadChapter(); chapter != nil || err != nil {
+		t.Errorf("replay không có chapter trả về %v, %v", chapter, err)
+	}
+}
+
+// TestReplayEmbedsData ghi 1 lượt chơi rồi phát lại chỉ bằng dữ liệu quái, boss, wave lưu trong replay:
+// sửa cân bằng game sau khi ghi không được làm replay lệch
+func TestReplayEmbedsData(t *testing.T) {
+	w := newTestWorld(t, 9, "")
+	r := NewReplay(w, "../assets/maps/spawn.json")
+	for _, in := range scriptedInputs(20*DefaultTickRate, 5) {
+		w.Step(in)
+		r.Record(in)
+	}
+
+	decoded, err := DecodeReplay(bytes.NewReader(encodedReplay(t, r)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Data == nil || !reflect.DeepEqual(*decoded.Data, readTestData(t)) {
+		t.Fatal("replay không lưu đúng nội dung file quái, boss, wave")
+	}
+
+	archetypes, bosses, script := parseTestData(t, *decoded.Data)
+	tilemap, err := LoadTilemap(decoded.Map)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := decoded.NewWorld(tilemap, nil)
+	replayed.SetArchetypes(archetypes)
+	replayed.SetBosses(bosses)
+	replayed.SetWaveScript(script)
+	decoded.Run(replayed)
+	if got, want := worldSnapshot(replayed), worldSnapshot(w); got != want {
+		t.Errorf("phát lại khác lượt chơi đã ghi:\n%s\n---\n%s", got, want)
+	}
+
+	// Replay bản cũ không có dữ liệu, phải đọc lại từ đĩa
+	old, err := DecodeReplay(bytes.NewReader(rawReplayVersion(3, 0, "", 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old.Data != nil {
+		t.Errorf("replay bản 3 có dữ liệu %+v", old.Data)
+	}
+}
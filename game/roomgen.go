@@ -0,0 +1,321 @@
+package game
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+// RoomRules là luật sinh phòng ngẫu nhiên (kích thước tính bằng tile)
+type RoomRules struct {
+	Width       int `json:"width"` // Tính cả tường bao quanh
+	Height      int `json:"height"`
+	Obstacles   int `json:"obstacles"`   // Số cụm vật cản (theo các mẫu obstaclePatterns)
+	Pits        int `json:"pits"`        // Số hố nước
+	SpawnPoints int `json:"spawnPoints"` // Số điểm spawn quái (chưa tính điểm "boss")
+	FloorGID    int `json:"floorGid"`    // Tile nền
+	PitGID      int `json:"pitGid"`      // Tile nước
+	Attempts    int `json:"attempts"`    // Số lần sinh lại khi phòng không hợp lệ
+}
+
+// DefaultRoomRules trả về luật sinh phòng mặc định, vừa khung hình và dùng tile của TilesetFloor
+func DefaultRoomRules() RoomRules {
+	return RoomRules{
+		Width:       60,
+		Height:      40,
+		Obstacles:   14,
+		Pits:        4,
+		SpawnPoints: 4,
+		FloorGID:    246,
+		PitGID:      486,
+		Attempts:    20,
+	}
+}
+
+// applyDefaults điền giá trị mặc định cho các trường bị bỏ trống
+func (r *RoomRules) applyDefaults() {
+	def := DefaultRoomRules()
+	if r.Width == 0 {
+		r.Width = def.Width
+	}
+	if r.Height == 0 {
+		r.Height = def.Height
+	}
+	if r.SpawnPoints == 0 {
+		r.SpawnPoints = def.SpawnPoints
+	}
+	if r.FloorGID == 0 {
+		r.FloorGID = def.FloorGID
+	}
+	if r.PitGID == 0 {
+		r.PitGID = def.PitGID
+	}
+	if r.Attempts == 0 {
+		r.Attempts = def.Attempts
+	}
+}
+
+func (r *RoomRules) validate() error {
+	if r.Width < 12 || r.Height < 12 {
+		return fmt.Errorf("phòng phải rộng ít nhất 12x12 tile")
+	}
+	if r.Obstacles < 0 || r.Pits < 0 || r.SpawnPoints < 0 {
+		return fmt.Errorf("obstacles, pits và spawnPoints không được âm")
+	}
+	return nil
+}
+
+// Loại ô khi sinh phòng
+const (
+	cellFloor = iota
+	cellWall
+	cellPit
+)
+
+// obstaclePatterns là các mẫu vật cản ('#' là ô chặn)
+var obstaclePatterns = [][]string{
+	{"##", "##"},
+	{"####"},
+	{"#", "#", "#", "#"},
+	{"###", "#..", "#.."},
+	{".#.", "###", ".#."},
+	{"#.#", "...", "#.#"},
+}
+
+// roomLayout là lưới ô của phòng đang sinh
+type roomLayout struct {
+	w, h   int
+	cells  []int
+	startX int // Ô xuất phát của player
+	startY int
+	exitX  int // Ô góc trên trái của cổng ra (cổng rộng 2x2 ô)
+	exitY  int
+}
+
+func (l *roomLayout) at(x, y int) int {
+	if x < 0 || y < 0 || x >= l.w || y >= l.h {
+		return cellWall
+	}
+	return l.cells[y*l.w+x]
+}
+
+func (l *roomLayout) set(x, y, c int) {
+	if x > 0 && y > 0 && x < l.w-1 && y < l.h-1 {
+		l.cells[y*l.w+x] = c
+	}
+}
+
+// reserved kiểm tra ô nằm trong vùng phải để trống quanh điểm xuất phát và cổng ra
+func (l *roomLayout) reserved(x, y int) bool {
+	near := func(cx, cy, r int) bool {
+		return x >= cx-r && x <= cx+r && y >= cy-r && y <= cy+r
+	}
+	return near(l.startX, l.startY, 2) || near(l.exitX, l.exitY, 2) || near(l.exitX+1, l.exitY+1, 2)
+}
+
+// RoomSeed tính seed riêng cho phòng thứ room của lượt chơi có seed cho trước
+func RoomSeed(seed uint64, room int) uint64 {
+	return seed ^ (uint64(room+1) * 0x9e3779b97f4a7c15)
+}
+
+// GenerateRoom sinh 1 phòng theo luật, dùng tileset và kích thước tile của map mẫu.
+// Cùng seed cho ra cùng phòng. Phòng chỉ được trả về khi cổng ra đi tới được từ điểm xuất phát
+// và đủ chỗ cho các điểm spawn; sinh hỏng quá Attempts lần thì báo lỗi.
+func GenerateRoom(template *TilemapJSON, rules RoomRules, seed uint64) (*TilemapJSON, error) {
+	rules.applyDefaults()
+	if err := rules.validate(); err != nil {
+		return nil, err
+	}
+
+	rng := NewRand(seed)
+	for attempt := 0; attempt < rules.Attempts; attempt++ {
+		layout := newRoomLayout(rng, rules)
+		dist := layout.distances()
+		if dist[layout.exitY*layout.w+layout.exitX] < 0 {
+			continue
+		}
+		spawns, ok := layout.pickSpawns(rng, dist, rules.SpawnPoints)
+		if !ok {
+			continue
+		}
+		layout.fillUnreachable(dist)
+		return layout.toTilemap(template, rules, spawns), nil
+	}
+	return nil, fmt.Errorf("không sinh được phòng hợp lệ sau %d lần thử", rules.Attempts)
+}
+
+// newRoomLayout sinh lưới ô: tường bao, vật cản và hố nước đặt ngẫu nhiên,
+// chừa trống quanh điểm xuất phát (giữa cạnh dưới) và cổng ra (giữa cạnh trên)
+func newRoomLayout(rng *rand.Rand, rules RoomRules) *roomLayout {
+	l := &roomLayout{
+		w:      rules.Width,
+		h:      rules.Height,
+		cells:  make([]int, rules.Width*rules.Height),
+		startX: rules.Width / 2,
+		startY: rules.Height - 3,
+		exitX:  rules.Width/2 - 1,
+		exitY:  1,
+	}
+	for y := 0; y < l.h; y++ {
+		for x := 0; x < l.w; x++ {
+			if x == 0 || y == 0 || x == l.w-1 || y == l.h-1 {
+				l.cells[y*l.w+x] = cellWall
+			}
+		}
+	}
+
+	for i := 0; i < rules.Obstacles; i++ {
+		pattern := obstaclePatterns[rng.IntN(len(obstaclePatterns))]
+		ox := 2 + rng.IntN(l.w-4)
+		oy := 2 + rng.IntN(l.h-4)
+		for py, row := range pattern {
+			for px, ch := range row {
+				if ch == '#' && !l.reserved(ox+px, oy+py) {
+					l.set(ox+px, oy+py, cellWall)
+				}
+			}
+		}
+	}
+
+	for i := 0; i < rules.Pits; i++ {
+		pw := 2 + rng.IntN(3)
+		ph := 2 + rng.IntN(2)
+		ox := 2 + rng.IntN(l.w-4-pw)
+		oy := 2 + rng.IntN(l.h-4-ph)
+		for y := oy; y < oy+ph; y++ {
+			for x := ox; x < ox+pw; x++ {
+				if !l.reserved(x, y) && l.at(x, y) == cellFloor {
+					l.set(x, y, cellPit)
+				}
+			}
+		}
+	}
+	return l
+}
+
+// distances tính số bước đi (BFS 4 hướng) từ ô xuất phát tới mọi ô, -1 nếu không tới được
+func (l *roomLayout) distances() []int {
+	dist := make([]int, len(l.cells))
+	for i := range dist {
+		dist[i] = -1
+	}
+	start := l.startY*l.w + l.startX
+	dist[start] = 0
+	queue := []int{start}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		cx, cy := cur%l.w, cur/l.w
+		for _, d := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			nx, ny := cx+d[0], cy+d[1]
+			if l.at(nx, ny) != cellFloor {
+				continue
+			}
+			next := ny*l.w + nx
+			if dist[next] < 0 {
+				dist[next] = dist[cur] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return dist
+}
+
+// fillUnreachable lấp các khoảng nền bị vật cản bao kín thành tường,
+// để quái spawn ngẫu nhiên không kẹt ở chỗ player không bắn tới được
+func (l *roomLayout) fillUnreachable(dist []int) {
+	for i, c := range l.cells {
+		if c == cellFloor && dist[i] < 0 {
+			l.cells[i] = cellWall
+		}
+	}
+}
+
+// pickSpawns chọn count ô spawn đi tới được và cách xa điểm xuất phát.
+// Phần tử cuối là điểm "boss": ô đi tới được gần tâm phòng nhất.
+func (l *roomLayout) pickSpawns(rng *rand.Rand, dist []int, count int) ([][2]int, bool) {
+	const minDist = 8
+	var candidates []int
+	for i, d := range dist {
+		if d >= minDist {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) < count+1 {
+		return nil, false
+	}
+
+	var spawns [][2]int
+	rng.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	for _, c := range candidates[:count] {
+		spawns = append(spawns, [2]int{c % l.w, c / l.w})
+	}
+
+	best, bestD := -1, 0
+	for i, d := range dist {
+		if d < 0 {
+			continue
+		}
+		dx, dy := i%l.w-l.w/2, i/l.w-l.h/2
+		if dd := dx*dx + dy*dy; best < 0 || dd < bestD {
+			best, bestD = i, dd
+		}
+	}
+	spawns = append(spawns, [2]int{best % l.w, best / l.w})
+	return spawns, true
+}
+
+// toTilemap đổi lưới ô thành map: layer nền, layer Collision và Pits (ẩn) và object layer
+// (điểm xuất phát, cổng ra, các điểm spawn)
+func (l *roomLayout) toTilemap(template *TilemapJSON, rules RoomRules, spawns [][2]int) *TilemapJSON {
+	floor := make([]int, len(l.cells))
+	collision := make([]int, len(l.cells))
+	pits := make([]int, len(l.cells))
+	for i, c := range l.cells {
+		switch c {
+		case cellFloor:
+			floor[i] = rules.FloorGID
+		case cellPit:
+			floor[i] = rules.PitGID
+			pits[i] = 1
+		case cellWall:
+			collision[i] = 1
+		}
+	}
+
+	tw, th := float64(template.TileW), float64(template.TileH)
+	objects := []TiledObject{
+		{ID: 1, Name: "start", Type: ObjectPlayerStart, Point: true, X: (float64(l.startX) + 0.5) * tw, Y: (float64(l.startY) + 0.5) * th},
+		{ID: 2, Name: "exit", Type: ObjectGate, X: float64(l.exitX) * tw, Y: float64(l.exitY) * th, Width: 2 * tw, Height: 2 * th},
+	}
+	for i, sp := range spawns {
+		name := fmt.Sprintf("spawn%d", i+1)
+		if i == len(spawns)-1 {
+			name = "boss"
+		}
+		objects = append(objects, TiledObject{
+			ID:    len(objects) + 1,
+			Name:  name,
+			Type:  ObjectSpawn,
+			Point: true,
+			X:     (float64(sp[0]) + 0.5) * tw,
+			Y:     (float64(sp[1]) + 0.5) * th,
+		})
+	}
+
+	return &TilemapJSON{
+		Layers: []TilemapLayerJSON{
+			{Name: "Floor", Type: LayerTiles, Data: floor, Width: l.w, Height: l.h, Visible: true},
+			{Name: CollisionLayerName, Type: LayerTiles, Data: collision, Width: l.w, Height: l.h},
+			{Name: PitLayerName, Type: LayerTiles, Data: pits, Width: l.w, Height: l.h},
+			{Name: "Objects", Type: LayerObjects, Visible: true, Objects: objects},
+		},
+		Tilesets: template.Tilesets,
+		Width:    l.w,
+		Height:   l.h,
+		TileW:    template.TileW,
+		TileH:    template.TileH,
+		Path:     template.Path,
+	}
+}
@@ -0,0 +1,140 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// roomBytes ghi toàn bộ nội dung phòng sinh ra (data các layer và object) thành JSON để so từng byte
+func roomBytes(t *testing.T, tm *TilemapJSON) []byte {
+	t.Helper()
+	type layer struct {
+		Name    string
+		Data    []int
+		Objects []TiledObject
+	}
+	var layers []layer
+	for _, l := range tm.Layers {
+		layers = append(layers, layer{l.Name, l.Data, l.Objects})
+	}
+	b, err := json.Marshal(struct {
+		Width, Height int
+		Layers        []layer
+	}{tm.Width, tm.Height, layers})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// walkDistances tính số bước đi (BFS 4 hướng) qua các ô không chặn của lưới va chạm, -1 nếu không tới được
+func walkDistances(grid *CollisionGrid, sx, sy int) []int {
+	dist := make([]int, grid.Width*grid.Height)
+	for i := range dist {
+		dist[i] = -1
+	}
+	dist[sy*grid.Width+sx] = 0
+	queue := []int{sy*grid.Width + sx}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		cx, cy := cur%grid.Width, cur/grid.Width
+		for _, d := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			nx, ny := cx+d[0], cy+d[1]
+			if grid.IsSolid(nx, ny) {
+				continue
+			}
+			if next := ny*grid.Width + nx; dist[next] < 0 {
+				dist[next] = dist[cur] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return dist
+}
+
+func TestGenerateRoomDeterministic(t *testing.T) {
+	template := loadTestTilemap(t)
+	rules := DefaultRoomRules()
+	for seed := uint64(0); seed < 50; seed++ {
+		a, err := GenerateRoom(template, rules, RoomSeed(seed, 1))
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		b, err := GenerateRoom(template, rules, RoomSeed(seed, 1))
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if !bytes.Equal(roomBytes(t, a), roomBytes(t, b)) {
+			t.Fatalf("seed %d: cùng seed nhưng phòng khác nhau", seed)
+		}
+	}
+
+	a, _ := GenerateRoom(template, rules, 1)
+	b, _ := GenerateRoom(template, rules, 2)
+	if bytes.Equal(roomBytes(t, a), roomBytes(t, b)) {
+		t.Error("2 seed khác nhau sinh ra cùng 1 phòng")
+	}
+}
+
+func TestGenerateRoomReachable(t *testing.T) {
+	const minSpawnDist = 8
+	template := loadTestTilemap(t)
+	for seed := uint64(0); seed < 200; seed++ {
+		rules := DefaultRoomRules()
+		if seed%2 == 1 {
+			// Phòng nhỏ và chật để hay phải sinh lại
+			rules.Width, rules.Height, rules.Obstacles, rules.Pits = 24, 20, 12, 3
+		}
+		room, err := GenerateRoom(template, rules, seed)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		grid := NewCollisionGrid(room, room.TileCollides)
+		tileAt := func(o TiledObject) (int, int) {
+			return int(o.X) / room.TileW, int(o.Y) / room.TileH
+		}
+
+		var start *TiledObject
+		var gates, spawns []TiledObject
+		for _, o := range room.Objects() {
+			switch o.Kind() {
+			case ObjectPlayerStart:
+				start = &o
+			case ObjectGate:
+				gates = append(gates, o)
+			case ObjectSpawn:
+				spawns = append(spawns, o)
+			}
+		}
+		if start == nil || len(gates) != 1 || len(spawns) != rules.SpawnPoints+1 {
+			t.Fatalf("seed %d: thiếu object (start %v, %d cổng, %d spawn)", seed, start != nil, len(gates), len(spawns))
+		}
+		if boss := spawns[len(spawns)-1]; boss.Name != "boss" {
+			t.Fatalf("seed %d: spawn cuối là %q, muốn \"boss\"", seed, boss.Name)
+		}
+
+		sx, sy := tileAt(*start)
+		dist := walkDistances(grid, sx, sy)
+		reach := func(tx, ty int) int {
+			if tx < 0 || ty < 0 || tx >= grid.Width || ty >= grid.Height {
+				return -1
+			}
+			return dist[ty*grid.Width+tx]
+		}
+
+		if gx, gy := tileAt(gates[0]); reach(gx, gy) < 0 {
+			t.Errorf("seed %d: cổng (%d, %d) không đi tới được", seed, gx, gy)
+		}
+		for i, sp := range spawns {
+			tx, ty := tileAt(sp)
+			d := reach(tx, ty)
+			if d < 0 {
+				t.Errorf("seed %d: spawn %q (%d, %d) không đi tới được", seed, sp.Name, tx, ty)
+			} else if i < len(spawns)-1 && d < minSpawnDist {
+				t.Errorf("seed %d: spawn %q chỉ cách điểm xuất phát %d ô", seed, sp.Name, d)
+			}
+		}
+	}
+}
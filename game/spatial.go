@@ -0,0 +1,195 @@
+package game
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultCellSize là cạnh ô (pixel) của lưới spatial hash: vài lần kích thước quái thường,
+// để mỗi truy vấn chỉ xét vài ô
+const DefaultCellSize = 64
+
+// spatialRect là khung của 1 phần tử trong SpatialHash
+type spatialRect struct {
+	X, Y, W, H float64
+}
+
+// SpatialHash là lưới đều chia map thành các ô CellSize x CellSize, mỗi ô giữ ID các phần tử
+// có khung chạm ô đó. Dùng làm broadphase: truy vấn chỉ xét các phần tử trong ô gần,
+// thay vì duyệt hết danh sách.
+//
+// ID là thứ tự Insert (0, 1, 2...) nên kết quả truy vấn sắp theo ID giữ đúng thứ tự
+// của danh sách gốc, mô phỏng vẫn xác định như khi duyệt tuần tự.
+// Phần tử nằm ngoài map được xếp vào ô ở rìa gần nhất nên vẫn được tìm thấy.
+type SpatialHash struct {
+	CellSize   float64
+	cols, rows int
+	cells      [][]int
+	rects      []spatialRect
+	seen       []uint32 // Lần truy vấn gần nhất đã gặp phần tử, để mỗi phần tử chỉ xét 1 lần
+	query      uint32
+}
+
+// NewSpatialHash tạo lưới rỗng phủ map width x height pixel với ô cạnh cellSize (0 = DefaultCellSize)
+func NewSpatialHash(width, height, cellSize float64) *SpatialHash {
+	if cellSize <= 0 {
+		cellSize = DefaultCellSize
+	}
+	h := &SpatialHash{CellSize: cellSize}
+	h.Reset(width, height)
+	return h
+}
+
+// Reset xóa mọi phần tử (giữ lại bộ nhớ để dựng lại mỗi bước không phải cấp phát),
+// đổi kích thước lưới nếu map đổi kích thước
+func (h *SpatialHash) Reset(width, height float64) {
+	cols := max(int(math.Ceil(width/h.CellSize)), 1)
+	rows := max(int(math.Ceil(height/h.CellSize)), 1)
+	if cols != h.cols || rows != h.rows {
+		h.cols, h.rows = cols, rows
+		h.cells = make([][]int, cols*rows)
+	} else {
+		for i := range h.cells {
+			h.cells[i] = h.cells[i][:0]
+		}
+	}
+	h.rects = h.rects[:0]
+}
+
+// Len trả về số phần tử trong lưới
+func (h *SpatialHash) Len() int {
+	return len(h.rects)
+}
+
+// Insert thêm phần tử có khung (x, y, w, hgt) và trả về ID của nó
+func (h *SpatialHash) Insert(x, y, w, hgt float64) int {
+	id := len(h.rects)
+	h.rects = append(h.rects, spatialRect{x, y, w, hgt})
+	if len(h.seen) < len(h.rects) {
+		h.seen = append(h.seen, 0)
+	}
+	c0, r0, c1, r1 := h.cellRange(x, y, w, hgt)
+	for r := r0; r <= r1; r++ {
+		for c := c0; c <= c1; c++ {
+			i := r*h.cols + c
+			h.cells[i] = append(h.cells[i], id)
+		}
+	}
+	return id
+}
+
+// cellRange trả về các ô (cột, hàng) mà khung chạm tới, kẹp trong lưới
+func (h *SpatialHash) cellRange(x, y, w, hgt float64) (c0, r0, c1, r1 int) {
+	return h.col(x), h.row(y), h.col(x + w), h.row(y + hgt)
+}
+
+func (h *SpatialHash) col(x float64) int {
+	return min(max(int(math.Floor(x/h.CellSize)), 0), h.cols-1)
+}
+
+func (h *SpatialHash) row(y float64) int {
+	return min(max(int(math.Floor(y/h.CellSize)), 0), h.rows-1)
+}
+
+// nextQuery bắt đầu 1 lần truy vấn mới cho việc đánh dấu phần tử đã gặp
+func (h *SpatialHash) nextQuery() uint32 {
+	h.query++
+	if h.query == 0 {
+		// Bộ đếm quay vòng: xóa dấu cũ để không nhầm với lần truy vấn mới
+		clear(h.seen)
+		h.query = 1
+	}
+	return h.query
+}
+
+// Query thêm vào out ID các phần tử có khung chồng lên khung (x, y, w, hgt) (chạm mép không tính,
+// giống CheckCollision) và trả về out đã sắp theo ID tăng dần
+func (h *SpatialHash) Query(x, y, w, hgt float64, out []int) []int {
+	mark := h.nextQuery()
+	start := len(out)
+	c0, r0, c1, r1 := h.cellRange(x, y, w, hgt)
+	for r := r0; r <= r1; r++ {
+		for c := c0; c <= c1; c++ {
+			for _, id := range h.cells[r*h.cols+c] {
+				if h.seen[id] == mark {
+					continue
+				}
+				h.seen[id] = mark
+				e := h.rects[id]
+				if e.X < x+w && e.X+e.W > x && e.Y < y+hgt && e.Y+e.H > y {
+					out = append(out, id)
+				}
+			}
+		}
+	}
+	sort.Ints(out[start:])
+	return out
+}
+
+// First trả về ID nhỏ nhất trong các phần tử có khung chồng lên khung (x, y, w, hgt) và được
+// accept chấp nhận. Giống Query rồi lấy phần tử đầu, nhưng không phải gom và sắp hết kết quả.
+func (h *SpatialHash) First(x, y, w, hgt float64, accept func(id int) bool) (int, bool) {
+	mark := h.nextQuery()
+	best := -1
+	c0, r0, c1, r1 := h.cellRange(x, y, w, hgt)
+	for r := r0; r <= r1; r++ {
+		for c := c0; c <= c1; c++ {
+			for _, id := range h.cells[r*h.cols+c] {
+				if h.seen[id] == mark || (best >= 0 && id >= best) {
+					continue
+				}
+				h.seen[id] = mark
+				e := h.rects[id]
+				if e.X < x+w && e.X+e.W > x && e.Y < y+hgt && e.Y+e.H > y && accept(id) {
+					best = id
+				}
+			}
+		}
+	}
+	return best, best >= 0
+}
+
+// Nearest tìm phần tử gần điểm (x, y) nhất, xét các ô theo từng vòng tỏa ra từ ô chứa điểm.
+// dist trả về khoảng cách từ (x, y) tới 1 điểm nằm trong khung của phần tử, ok = false để bỏ qua
+// phần tử (vd quái đã chết). Khoảng cách bằng nhau thì chọn ID nhỏ hơn.
+func (h *SpatialHash) Nearest(x, y float64, dist func(id int) (float64, bool)) (int, bool) {
+	if len(h.rects) == 0 {
+		return -1, false
+	}
+	mark := h.nextQuery()
+	best, bestDist := -1, math.MaxFloat64
+	cx, cy := h.col(x), h.row(y)
+	maxRing := max(cx, h.cols-1-cx, cy, h.rows-1-cy)
+	for ring := 0; ring <= maxRing; ring++ {
+		// Phần tử chưa gặp có tâm ở vòng thứ ring trở ra, cách điểm ít nhất ring-1 ô
+		if best >= 0 && bestDist < float64(ring-1)*h.CellSize {
+			break
+		}
+		for r := cy - ring; r <= cy+ring; r++ {
+			if r < 0 || r >= h.rows {
+				continue
+			}
+			// Hàng trên và dưới cùng của vòng xét cả hàng, các hàng giữa chỉ xét 2 ô ở mép
+			step := 2 * ring
+			if r == cy-ring || r == cy+ring || ring == 0 {
+				step = 1
+			}
+			for c := cx - ring; c <= cx+ring; c += step {
+				if c < 0 || c >= h.cols {
+					continue
+				}
+				for _, id := range h.cells[r*h.cols+c] {
+					if h.seen[id] == mark {
+						continue
+					}
+					h.seen[id] = mark
+					d, ok := dist(id)
+					if ok && (d < bestDist || (d == bestDist && id < best)) {
+						best, bestDist = id, d
+					}
+				}
+			}
+		}
+	}
+	return best, best >= 0
+}
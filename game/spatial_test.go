@@ -0,0 +1,325 @@
+package game
+
+import (
+	"io"
+	"log"
+	"math"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Mô phỏng ghi log khi vào phòng, hạ boss... không cần trong output test
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func TestSpatialHashQuery(t *testing.T) {
+	h := NewSpatialHash(256, 256, 64)
+	ids := []int{
+		h.Insert(60, 60, 8, 8),     // 0: nằm trên 4 ô quanh (64, 64)
+		h.Insert(64, 0, 16, 16),    // 1: mép trái đúng bằng mép ô
+		h.Insert(-40, -40, 16, 16), // 2: ngoài map phía trên trái
+		h.Insert(300, 10, 16, 16),  // 3: ngoài map bên phải
+		h.Insert(100, 100, 16, 16), // 4
+	}
+	for i, id := range ids {
+		if id != i {
+			t.Fatalf("Insert thứ %d trả về ID %d", i, id)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		x, y, w, h float64
+		want       []int
+	}{
+		{"trùng nhiều ô", 62, 62, 4, 4, []int{0}},
+		{"chạm mép không tính", 48, 0, 16, 16, nil},
+		{"chồng qua mép ô", 50, 0, 16, 16, []int{1}},
+		{"ngoài map trên trái", -50, -50, 20, 20, []int{2}},
+		{"ngoài map bên phải", 310, 12, 4, 4, []int{3}},
+		{"vùng lớn, sắp theo ID", -100, -100, 500, 500, []int{0, 1, 2, 3, 4}},
+		{"ô trống", 200, 200, 10, 10, nil},
+	}
+	for _, tt := range tests {
+		got := h.Query(tt.x, tt.y, tt.w, tt.h, nil)
+		if !equalInts(got, tt.want) {
+			t.Errorf("%s: Query = %v, muốn %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Query nối thêm vào out sẵn có
+	out := h.Query(100, 100, 1, 1, []int{42})
+	if !equalInts(out, []int{42, 4}) {
+		t.Errorf("Query với out sẵn = %v", out)
+	}
+
+	h.Reset(256, 256)
+	if h.Len() != 0 || len(h.Query(-100, -100, 500, 500, nil)) != 0 {
+		t.Errorf("Reset không xóa phần tử")
+	}
+}
+
+func TestSpatialHashFirst(t *testing.T) {
+	h := NewSpatialHash(256, 256, 64)
+	h.Insert(100, 100, 16, 16) // 0
+	h.Insert(96, 96, 16, 16)   // 1
+	h.Insert(60, 60, 60, 60)   // 2: phủ nhiều ô
+	h.Insert(-20, 100, 16, 16) // 3: ngoài map
+
+	accept := func(skip ...int) func(int) bool {
+		return func(id int) bool {
+			for _, s := range skip {
+				if s == id {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	tests := []struct {
+		name       string
+		x, y, w, h float64
+		skip       []int
+		want       int
+		ok         bool
+	}{
+		{"ID nhỏ nhất", 100, 100, 4, 4, nil, 0, true},
+		{"bỏ qua ID bị từ chối", 100, 100, 4, 4, []int{0}, 1, true},
+		{"chỉ còn phần tử phủ nhiều ô", 100, 100, 4, 4, []int{0, 1}, 2, true},
+		{"không ai được nhận", 100, 100, 4, 4, []int{0, 1, 2}, -1, false},
+		{"ngoài map", -30, 104, 20, 4, nil, 3, true},
+		{"không chồng", 200, 10, 4, 4, nil, -1, false},
+	}
+	for _, tt := range tests {
+		got, ok := h.First(tt.x, tt.y, tt.w, tt.h, accept(tt.skip...))
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: First = %d, %v, muốn %d, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSpatialHashNearest(t *testing.T) {
+	h := NewSpatialHash(640, 480, 64)
+	if _, ok := h.Nearest(10, 10, nil); ok {
+		t.Fatal("lưới rỗng không được có phần tử gần nhất")
+	}
+
+	// Ngẫu nhiên, gồm cả phần tử và điểm truy vấn ngoài map, so với duyệt tuần tự
+	rng := NewRand(7)
+	for round := 0; round < 200; round++ {
+		h.Reset(640, 480)
+		var rects []spatialRect
+		n := rng.IntN(40)
+		for i := 0; i < n; i++ {
+			r := spatialRect{rng.Float64()*800 - 80, rng.Float64()*640 - 80, 8 + rng.Float64()*40, 8 + rng.Float64()*40}
+			rects = append(rects, r)
+			h.Insert(r.X, r.Y, r.W, r.H)
+		}
+		dead := map[int]bool{}
+		for i := 0; i < n/4; i++ {
+			dead[rng.IntN(n)] = true
+		}
+		x, y := rng.Float64()*900-130, rng.Float64()*700-110
+		dist := func(id int) (float64, bool) {
+			r := rects[id]
+			return math.Hypot(x-(r.X+r.W/2), y-(r.Y+r.H/2)), !dead[id]
+		}
+
+		want, bestDist := -1, math.MaxFloat64
+		for id := range rects {
+			if d, ok := dist(id); ok && d < bestDist {
+				want, bestDist = id, d
+			}
+		}
+		got, ok := h.Nearest(x, y, dist)
+		if got != want || ok != (want >= 0) {
+			t.Fatalf("lượt %d: Nearest(%.1f, %.1f) = %d, muốn %d", round, x, y, got, want)
+		}
+	}
+}
+
+func TestSpatialHashNearestTie(t *testing.T) {
+	h := NewSpatialHash(256, 256, 64)
+	// 2 phần tử cách đều điểm (128, 128), nằm ở 2 ô khác nhau: chọn ID nhỏ hơn dù ô của nó xét sau
+	h.Insert(184, 120, 16, 16) // 0: tâm (192, 128)
+	h.Insert(56, 120, 16, 16)  // 1: tâm (64, 128)
+	got, _ := h.Nearest(128, 128, func(id int) (float64, bool) {
+		return 64, true
+	})
+	if got != 0 {
+		t.Errorf("Nearest khi bằng nhau = %d, muốn 0", got)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// loadTestTilemap load map spawn dùng chung cho các test mô phỏng
+func loadTestTilemap(tb testing.TB) *TilemapJSON {
+	tb.Helper()
+	tm, err := LoadTilemap("../assets/maps/spawn.json")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return tm
+}
+
+// crowdedWorld tạo world có enemies quái và projectiles đạn của player rải ngẫu nhiên trên map
+func crowdedWorld(tm *TilemapJSON, enemies, projectiles int, seed uint64) *World {
+	w := NewWorld(tm, seed)
+	w.Reset(NewPlayer(SpritePlayer, w.MapWidth/2, w.MapHeight/2, 1e9, DefaultPlayerSpeed, 10, 1))
+	rng := NewRand(seed)
+	a := DefaultArchetype()
+	for i := 0; i < enemies; i++ {
+		e := a.NewEnemy(rng.Float64()*(w.MapWidth-16), rng.Float64()*(w.MapHeight-16))
+		e.SpawnTimer = 0
+		w.Enemies = append(w.Enemies, e)
+	}
+	for i := 0; i < projectiles; i++ {
+		w.Projectiles = append(w.Projectiles, NewProjectileAngle(SpriteProjectile,
+			rng.Float64()*w.MapWidth, rng.Float64()*w.MapHeight, rng.Float64()*2*math.Pi, 300, 10, 16, FactionPlayer))
+	}
+	return w
+}
+
+// Cách cũ: mỗi truy vấn duyệt hết Enemies
+
+func linearHit(w *World, p *Projectile) *Enemy {
+	for _, e := range w.Enemies {
+		if e.IsAlive() && p.CheckCollision(e.X, e.Y, e.Width, e.Height) {
+			return e
+		}
+	}
+	return nil
+}
+
+func linearNearest(w *World) *Enemy {
+	px, py := w.Player.GetCenter()
+	var best *Enemy
+	bestDist := math.MaxFloat64
+	for _, e := range w.Enemies {
+		if e.IsAlive() {
+			if d := e.GetDistanceTo(px, py); d < bestDist {
+				best, bestDist = e, d
+			}
+		}
+	}
+	return best
+}
+
+func linearContact(w *World) float64 {
+	p := w.Player
+	damage := 0.0
+	for _, e := range w.Enemies {
+		if e.IsAlive() && e.CheckCollision(p.X, p.Y, p.Width, p.Height) {
+			damage += e.Damage * w.Clock.DT()
+		}
+	}
+	return damage
+}
+
+func gridHit(w *World, p *Projectile) *Enemy {
+	id, ok := w.enemyGrid.First(p.X, p.Y, p.Width, p.Height, func(id int) bool {
+		return w.gridEnemies[id].IsAlive()
+	})
+	if !ok {
+		return nil
+	}
+	return w.gridEnemies[id]
+}
+
+func gridContact(w *World) float64 {
+	before := w.Player.Health
+	w.handleContactDamage()
+	damage := before - w.Player.Health
+	w.Player.Health = before
+	return damage
+}
+
+func TestEnemyGridMatchesLinear(t *testing.T) {
+	tm := loadTestTilemap(t)
+	for seed := uint64(1); seed <= 10; seed++ {
+		w := crowdedWorld(tm, 300, 500, seed)
+		// Quái chồng lên player để có sát thương chạm
+		for i, e := range w.Enemies[:20] {
+			e.X, e.Y = w.Player.X+float64(i%8), w.Player.Y
+		}
+		for _, e := range w.Enemies[20:40] {
+			e.Health = 0
+		}
+		w.indexEnemies()
+
+		for i, p := range w.Projectiles {
+			if got, want := gridHit(w, p), linearHit(w, p); got != want {
+				t.Fatalf("seed %d: đạn %d trúng %p, muốn %p", seed, i, got, want)
+			}
+		}
+		if got, want := w.FindNearestEnemy(), linearNearest(w); got != want {
+			t.Fatalf("seed %d: quái gần nhất %p, muốn %p", seed, got, want)
+		}
+		if got, want := gridContact(w), linearContact(w); got != want {
+			t.Fatalf("seed %d: sát thương chạm %v, muốn %v", seed, got, want)
+		}
+	}
+}
+
+// benchmarkWorldStep đo 1 bước mô phỏng đầy đủ. World được dựng lại mỗi lần (không tính giờ)
+// vì sau 1 bước đạn và quái đã thay đổi.
+func benchmarkWorldStep(b *testing.B, enemies, projectiles int) {
+	tm := loadTestTilemap(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		w := crowdedWorld(tm, enemies, projectiles, uint64(i))
+		b.StartTimer()
+		w.Step(Input{})
+	}
+}
+
+func BenchmarkWorldStep500Enemies2000Projectiles(b *testing.B) {
+	benchmarkWorldStep(b, 500, 2000)
+}
+
+func BenchmarkWorldStep50Enemies200Projectiles(b *testing.B) {
+	benchmarkWorldStep(b, 50, 200)
+}
+
+// Các truy vấn va chạm của 1 bước (đạn trúng quái, quái gần nhất, quái chạm player)
+// trên cùng 1 world, cách cũ duyệt tuần tự và cách dùng lưới (gồm cả dựng lại lưới)
+
+func BenchmarkCollisionQueries500x2000Linear(b *testing.B) {
+	w := crowdedWorld(loadTestTilemap(b), 500, 2000, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range w.Projectiles {
+			linearHit(w, p)
+		}
+		linearNearest(w)
+		linearContact(w)
+	}
+}
+
+func BenchmarkCollisionQueries500x2000Grid(b *testing.B) {
+	w := crowdedWorld(loadTestTilemap(b), 500, 2000, 1)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.indexEnemies()
+		for _, p := range w.Projectiles {
+			gridHit(w, p)
+		}
+		w.FindNearestEnemy()
+		gridContact(w)
+	}
+}
@@ -0,0 +1,107 @@
+package game
+
+import (
+	"image"
+	"path/filepath"
+)
+
+// SpriteLibrary là clip và sheet riêng của từng sprite ID (vd lấy từ sheet JSON hoặc file Aseprite).
+// Sprite không có trong thư viện dùng bộ clip nhân vật mặc định và được co giãn vừa khung va chạm.
+// Thư viện nil dùng được như thư viện rỗng.
+type SpriteLibrary struct {
+	clips  map[string]ClipSet
+	sheets map[string]*SpriteSheet
+}
+
+// NewSpriteLibrary tạo thư viện sprite rỗng
+func NewSpriteLibrary() *SpriteLibrary {
+	return &SpriteLibrary{
+		clips:  map[string]ClipSet{},
+		sheets: map[string]*SpriteSheet{},
+	}
+}
+
+// AddClips đăng ký bộ clip cho sprite
+func (l *SpriteLibrary) AddClips(sprite string, clips ClipSet) {
+	l.clips[sprite] = clips
+}
+
+// AddSheet đăng ký sheet cho sprite: clip của sheet thành clip của sprite,
+// hitbox quyết định khung va chạm của entity (xem Fit)
+func (l *SpriteLibrary) AddSheet(sprite string, sheet *SpriteSheet) {
+	l.sheets[sprite] = sheet
+	l.AddClips(sprite, sheet.Clips)
+}
+
+// Clips trả về bộ clip của sprite, sprite chưa đăng ký thì dùng bộ clip nhân vật mặc định
+func (l *SpriteLibrary) Clips(sprite string) ClipSet {
+	if l != nil {
+		if clips, ok := l.clips[sprite]; ok {
+			return clips
+		}
+	}
+	return characterClips
+}
+
+// Sheet trả về sheet của sprite (nil nếu sprite là ảnh thường)
+func (l *SpriteLibrary) Sheet(sprite string) *SpriteSheet {
+	if l == nil {
+		return nil
+	}
+	return l.sheets[sprite]
+}
+
+// Load tìm sprite trong thư mục dir và đăng ký vào thư viện. Thứ tự ưu tiên:
+//   - <sprite>.json: sheet ghép từ các strip, có origin, hitbox và bóng
+//   - <sprite>.aseprite hoặc .ase: các frame được ghép thành dải ảnh, tag thành clip animation
+//
+// Trả về ảnh đã ghép, nil nếu không có file nào ở trên (sprite là ảnh PNG thường).
+func (l *SpriteLibrary) Load(dir, sprite string) (image.Image, error) {
+	sheetPath := filepath.Join(dir, sprite+".json")
+	if AssetExists(sheetPath) {
+		sheet, err := LoadSpriteSheet(sheetPath)
+		if err != nil {
+			return nil, err
+		}
+		l.AddSheet(sprite, sheet)
+		return sheet.Image, nil
+	}
+	for _, ext := range []string{".aseprite", ".ase"} {
+		path := filepath.Join(dir, sprite+ext)
+		if !AssetExists(path) {
+			continue
+		}
+		file, err := LoadAseprite(path)
+		if err != nil {
+			return nil, err
+		}
+		l.AddClips(sprite, file.Clips())
+		return file.Sheet(), nil
+	}
+	return nil, nil
+}
+
+// SpriteFit là cách đặt frame của sheet lên khung va chạm của entity
+type SpriteFit struct {
+	Sheet            *SpriteSheet // nil = sprite thường, ảnh được co giãn vừa khung
+	Scale            float64      // Số lần phóng frame
+	OriginX, OriginY float64      // Vị trí origin của sheet so với góc trên trái khung va chạm
+}
+
+// Fit tính khung va chạm và cách đặt sheet cho entity dùng sprite, kích thước khai báo width x height.
+// Sprite có sheet thì hitbox được phóng cho bằng chiều rộng khai báo, chiều cao khung lấy theo
+// tỉ lệ của hitbox, và hitbox nằm đúng trên khung. Sprite thường giữ nguyên kích thước khai báo.
+func (l *SpriteLibrary) Fit(sprite string, width, height float64) (float64, float64, SpriteFit) {
+	sheet := l.Sheet(sprite)
+	if sheet == nil {
+		return width, height, SpriteFit{}
+	}
+	hb := sheet.Hitbox
+	scale := width / float64(hb.Width)
+	return width, float64(hb.Height) * scale, SpriteFit{
+		Sheet:   sheet,
+		Scale:   scale,
+		OriginX: (sheet.Origin.X - float64(hb.X)) * scale,
+		OriginY: (sheet.Origin.Y - float64(hb.Y)) * scale,
+	}
+}
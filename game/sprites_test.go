@@ -0,0 +1,93 @@
+package game
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// orcLibrary tạo thư viện chỉ có sheet orc của bộ asset (hitbox 14x14 tại (43, 43), origin (50, 57))
+func orcLibrary(tb testing.TB) *SpriteLibrary {
+	tb.Helper()
+	sprites := NewSpriteLibrary()
+	if _, err := sprites.Load("../assets/images", "orc"); err != nil {
+		tb.Fatal(err)
+	}
+	if sprites.Sheet("orc") == nil {
+		tb.Fatal("không load được sheet orc")
+	}
+	return sprites
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestSpriteLibraryFit(t *testing.T) {
+	sprites := orcLibrary(t)
+
+	// Hitbox 14x14 được phóng cho rộng bằng 18, khung cao theo tỉ lệ hitbox
+	w, h, fit := sprites.Fit("orc", 18, 30)
+	scale := 18.0 / 14
+	if w != 18 || !near(h, 18) {
+		t.Errorf("khung %vx%v, muốn 18x18", w, h)
+	}
+	if fit.Sheet != sprites.Sheet("orc") || !near(fit.Scale, scale) {
+		t.Errorf("sheet %p scale %v, muốn sheet orc scale %v", fit.Sheet, fit.Scale, scale)
+	}
+	if !near(fit.OriginX, 7*scale) || !near(fit.OriginY, 14*scale) {
+		t.Errorf("origin (%v, %v), muốn (%v, %v)", fit.OriginX, fit.OriginY, 7*scale, 14*scale)
+	}
+	if !reflect.DeepEqual(sprites.Clips("orc"), sprites.Sheet("orc").Clips) {
+		t.Error("clip của sprite phải là clip của sheet")
+	}
+
+	// Sprite thường và thư viện nil giữ kích thước khai báo, dùng clip mặc định
+	for _, lib := range []*SpriteLibrary{sprites, nil} {
+		if w, h, fit := lib.Fit("skeleton", 16, 20); w != 16 || h != 20 || fit != (SpriteFit{}) {
+			t.Errorf("sprite thường: %vx%v %+v", w, h, fit)
+		}
+		if !reflect.DeepEqual(lib.Clips("skeleton"), characterClips) {
+			t.Error("sprite thường phải dùng clip nhân vật mặc định")
+		}
+	}
+}
+
+func TestWorldSetSprites(t *testing.T) {
+	w := newTestWorld(t, 1, "")
+	orc := w.Archetypes["orc"]
+	if orc == nil || orc.Sprite != "orc" {
+		t.Fatal("enemies.json không còn loại quái orc")
+	}
+	// Khai báo cao hơn hitbox để thấy khung bị đổi
+	orc.Height = 30
+
+	// Chưa có sheet: giữ kích thước khai báo
+	e := w.addEnemy(orc.NewEnemy(100, 100), orc.Width, orc.Height)
+	if e.Width != 18 || e.Height != 30 || e.Fit.Sheet != nil {
+		t.Fatalf("chưa có sheet: khung %vx%v sheet %p", e.Width, e.Height, e.Fit.Sheet)
+	}
+	footX, footY := e.X+e.Width/2, e.Y+e.Height
+
+	// Đổi thư viện: khung tính lại theo hitbox, chân giữ nguyên chỗ, animator dùng clip của sheet
+	sprites := orcLibrary(t)
+	w.SetSprites(sprites)
+	if !near(e.Height, 18) || e.Fit.Sheet != sprites.Sheet("orc") {
+		t.Errorf("sau SetSprites: khung %vx%v sheet %p", e.Width, e.Height, e.Fit.Sheet)
+	}
+	if !near(e.X+e.Width/2, footX) || !near(e.Y+e.Height, footY) {
+		t.Errorf("chân dời từ (%v, %v) sang (%v, %v)", footX, footY, e.X+e.Width/2, e.Y+e.Height)
+	}
+	if !reflect.DeepEqual(e.Anim.Clips, sprites.Sheet("orc").Clips) {
+		t.Error("animator chưa dùng clip của sheet")
+	}
+	if w.Player.Fit.Sheet != nil || w.Player.Anim.Clips == nil {
+		t.Error("người chơi không có sheet phải giữ sprite thường")
+	}
+
+	// Quái sinh sau đó có ngay khung theo sheet
+	spawned := w.addEnemy(orc.NewEnemy(200, 200), orc.Width, orc.Height)
+	if !near(spawned.Height, 18) || spawned.Fit.Sheet == nil {
+		t.Errorf("quái mới: khung %vx%v sheet %p", spawned.Width, spawned.Height, spawned.Fit.Sheet)
+	}
+}
@@ -0,0 +1,283 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/png" // Giải mã ảnh strip PNG
+	"path/filepath"
+	"sort"
+)
+
+// DefaultFrameDuration là thời gian mỗi frame (giây) khi sheet không khai báo
+const DefaultFrameDuration = 0.1
+
+// SpriteRect là hình chữ nhật (pixel) trên 1 frame
+type SpriteRect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// SpritePoint là 1 điểm (pixel) trên 1 frame
+type SpritePoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// StripDef là 1 clip lấy từ 1 ảnh strip: các frame nằm ngang, cùng kích thước
+type StripDef struct {
+	Image     string    `json:"image"`     // Đường dẫn tương đối so với file JSON
+	Shadow    string    `json:"shadow"`    // Strip bóng riêng của clip (trống = bóng chung của sheet)
+	Frames    int       `json:"frames"`    // Số frame (0 = chia đều theo chiều rộng ảnh)
+	Duration  float64   `json:"duration"`  // Thời gian mỗi frame (0 = theo sheet)
+	Durations []float64 `json:"durations"` // Thời gian riêng từng frame, ưu tiên hơn Duration
+	Loop      *bool     `json:"loop"`      // nil = tự chọn: clip đánh/bị đánh/chết chạy 1 lượt, còn lại lặp
+}
+
+// SpriteSheetDef là file JSON đi kèm các ảnh strip của 1 nhân vật
+type SpriteSheetDef struct {
+	FrameWidth  int                  `json:"frameWidth"`
+	FrameHeight int                  `json:"frameHeight"`
+	Origin      SpritePoint          `json:"origin"`   // Điểm chân nhân vật trên frame, đặt trùng chân của entity
+	Hitbox      SpriteRect           `json:"hitbox"`   // Thân nhân vật trên frame, co giãn cho khớp khung va chạm của entity
+	Duration    float64              `json:"duration"` // Thời gian mỗi frame mặc định (giây)
+	Shadow      string               `json:"shadow"`   // Strip bóng chung: 1 frame dùng cho mọi frame, hoặc nhiều frame chạy theo clip
+	FlipLeft    bool                 `json:"flipLeft"` // Strip chỉ vẽ hướng phải, quay trái thì lật ngang quanh Origin
+	Clips       map[string]*StripDef `json:"clips"`    // Tên clip (idle, walk, attack, hurt, death...) -> strip
+}
+
+// SpriteSheet là sheet đã load: các strip được xếp chồng thành 1 ảnh (mỗi clip 1 hàng),
+// ảnh bóng có cùng bố cục nên frame của clip dùng chung vùng trên cả 2 ảnh
+type SpriteSheet struct {
+	SpriteSheetDef
+	Image  *image.NRGBA
+	Shadow *image.NRGBA // nil nếu không có bóng
+	Clips  ClipSet
+}
+
+// LoadSpriteSheet đọc file JSON của sheet và ghép các ảnh strip
+func LoadSpriteSheet(path string) (*SpriteSheet, error) {
+	contents, err := ReadAsset(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var def SpriteSheetDef
+	if err := json.Unmarshal(contents, &def); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	def.applyDefaults()
+	if err := def.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	sheet, err := def.build(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return sheet, nil
+}
+
+// applyDefaults điền giá trị mặc định cho các trường bị bỏ trống
+func (d *SpriteSheetDef) applyDefaults() {
+	if d.Duration == 0 {
+		d.Duration = DefaultFrameDuration
+	}
+	// Không khai báo hitbox thì cả frame là thân, chân ở giữa cạnh dưới hitbox
+	if d.Hitbox.Width == 0 || d.Hitbox.Height == 0 {
+		d.Hitbox = SpriteRect{Width: d.FrameWidth, Height: d.FrameHeight}
+	}
+	if d.Origin == (SpritePoint{}) {
+		d.Origin = SpritePoint{
+			X: float64(d.Hitbox.X) + float64(d.Hitbox.Width)/2,
+			Y: float64(d.Hitbox.Y + d.Hitbox.Height),
+		}
+	}
+}
+
+func (d *SpriteSheetDef) validate() error {
+	if d.FrameWidth <= 0 || d.FrameHeight <= 0 {
+		return fmt.Errorf("frameWidth và frameHeight phải > 0")
+	}
+	if d.Duration < 0 {
+		return fmt.Errorf("duration không được âm")
+	}
+	h := d.Hitbox
+	if h.X < 0 || h.Y < 0 || h.Width <= 0 || h.Height <= 0 || h.X+h.Width > d.FrameWidth || h.Y+h.Height > d.FrameHeight {
+		return fmt.Errorf("hitbox (%d, %d, %d, %d) nằm ngoài frame %dx%d", h.X, h.Y, h.Width, h.Height, d.FrameWidth, d.FrameHeight)
+	}
+	if _, ok := d.Clips[ClipIdle]; !ok {
+		return fmt.Errorf("thiếu clip %q", ClipIdle)
+	}
+	for _, name := range d.clipNames() {
+		strip := d.Clips[name]
+		if strip == nil || strip.Image == "" {
+			return fmt.Errorf("clip %q: thiếu image", name)
+		}
+		if strip.Frames < 0 || strip.Duration < 0 {
+			return fmt.Errorf("clip %q: frames và duration không được âm", name)
+		}
+		if len(strip.Durations) > 0 && strip.Frames > 0 && len(strip.Durations) != strip.Frames {
+			return fmt.Errorf("clip %q: có %d durations cho %d frame", name, len(strip.Durations), strip.Frames)
+		}
+		for i, duration := range strip.Durations {
+			if duration <= 0 {
+				return fmt.Errorf("clip %q: durations của frame %d phải > 0", name, i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// clipNames trả về tên các clip theo thứ tự ABC, để bố cục ảnh ghép luôn cố định
+func (d *SpriteSheetDef) clipNames() []string {
+	names := make([]string, 0, len(d.Clips))
+	for name := range d.Clips {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// build load các strip rồi xếp clip thứ i vào hàng i của ảnh ghép
+func (d *SpriteSheetDef) build(dir string) (*SpriteSheet, error) {
+	names := d.clipNames()
+	images := map[string]image.Image{}
+	load := func(file string) (image.Image, error) {
+		if img, ok := images[file]; ok {
+			return img, nil
+		}
+		img, err := loadImage(filepath.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+		images[file] = img
+		return img, nil
+	}
+
+	// Đếm frame của từng clip trước để biết kích thước ảnh ghép
+	frames := make([]int, len(names))
+	maxFrames := 0
+	for i, name := range names {
+		strip := d.Clips[name]
+		img, err := load(strip.Image)
+		if err != nil {
+			return nil, fmt.Errorf("clip %q: %w", name, err)
+		}
+		n, err := d.stripFrames(img, strip.Frames)
+		if err != nil {
+			return nil, fmt.Errorf("clip %q: %s: %w", name, strip.Image, err)
+		}
+		if len(strip.Durations) > 0 && len(strip.Durations) != n {
+			return nil, fmt.Errorf("clip %q: có %d durations cho %d frame", name, len(strip.Durations), n)
+		}
+		frames[i] = n
+		maxFrames = max(maxFrames, n)
+	}
+
+	bounds := image.Rect(0, 0, maxFrames*d.FrameWidth, len(names)*d.FrameHeight)
+	sheet := &SpriteSheet{
+		SpriteSheetDef: *d,
+		Image:          image.NewNRGBA(bounds),
+		Clips:          ClipSet{},
+	}
+	for i, name := range names {
+		strip := d.Clips[name]
+		img, _ := load(strip.Image)
+		row := i * d.FrameHeight
+		draw.Draw(sheet.Image, image.Rect(0, row, frames[i]*d.FrameWidth, row+d.FrameHeight), img, img.Bounds().Min, draw.Src)
+
+		shadowFile := strip.Shadow
+		if shadowFile == "" {
+			shadowFile = d.Shadow
+		}
+		if shadowFile != "" {
+			shadow, err := load(shadowFile)
+			if err != nil {
+				return nil, fmt.Errorf("clip %q: bóng: %w", name, err)
+			}
+			if sheet.Shadow == nil {
+				sheet.Shadow = image.NewNRGBA(bounds)
+			}
+			if err := d.drawShadow(sheet.Shadow, shadow, row, frames[i]); err != nil {
+				return nil, fmt.Errorf("clip %q: %s: %w", name, shadowFile, err)
+			}
+		}
+
+		sheet.Clips[name] = d.clip(name, strip, row, frames[i])
+	}
+	return sheet, nil
+}
+
+// stripFrames tính số frame của strip và kiểm tra ảnh đủ rộng
+func (d *SpriteSheetDef) stripFrames(img image.Image, declared int) (int, error) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if h < d.FrameHeight {
+		return 0, fmt.Errorf("ảnh cao %d, frame cao %d", h, d.FrameHeight)
+	}
+	if declared == 0 {
+		if w%d.FrameWidth != 0 {
+			return 0, fmt.Errorf("ảnh rộng %d không chia hết cho frame rộng %d", w, d.FrameWidth)
+		}
+		declared = w / d.FrameWidth
+	}
+	if declared == 0 || declared*d.FrameWidth > w {
+		return 0, fmt.Errorf("ảnh rộng %d không đủ %d frame", w, declared)
+	}
+	return declared, nil
+}
+
+// drawShadow vẽ bóng cho n frame của hàng row. Strip bóng ít frame hơn clip
+// (thường là 1 frame) thì lặp vòng các frame bóng.
+func (d *SpriteSheetDef) drawShadow(dst *image.NRGBA, shadow image.Image, row, n int) error {
+	count, err := d.stripFrames(shadow, 0)
+	if err != nil {
+		return err
+	}
+	start := shadow.Bounds().Min
+	for i := 0; i < n; i++ {
+		src := image.Pt(start.X+(i%count)*d.FrameWidth, start.Y)
+		rect := image.Rect(i*d.FrameWidth, row, (i+1)*d.FrameWidth, row+d.FrameHeight)
+		draw.Draw(dst, rect, shadow, src, draw.Src)
+	}
+	return nil
+}
+
+// clip tạo clip cho hàng row của ảnh ghép
+func (d *SpriteSheetDef) clip(name string, strip *StripDef, row, n int) *Animation {
+	anim := &Animation{Name: name, Loop: !oneShotClip(name)}
+	if strip.Loop != nil {
+		anim.Loop = *strip.Loop
+	}
+	for i := 0; i < n; i++ {
+		duration := d.Duration
+		switch {
+		case len(strip.Durations) > 0:
+			duration = strip.Durations[i]
+		case strip.Duration > 0:
+			duration = strip.Duration
+		}
+		anim.Frames = append(anim.Frames, AnimFrame{
+			X: i * d.FrameWidth, Y: row, W: d.FrameWidth, H: d.FrameHeight,
+			Duration: duration,
+		})
+	}
+	return anim
+}
+
+// loadImage đọc và giải mã 1 file ảnh
+func loadImage(path string) (image.Image, error) {
+	f, err := OpenAsset(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return img, nil
+}
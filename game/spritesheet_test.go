@@ -0,0 +1,72 @@
+package game
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSheet ghi 1 strip 2 frame 8x8 cùng file sheet JSON vào thư mục tạm và trả về đường dẫn sheet
+func writeSheet(tb testing.TB, sheet string) string {
+	tb.Helper()
+	dir := tb.TempDir()
+	f, err := os.Create(filepath.Join(dir, "idle.png"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, image.NewNRGBA(image.Rect(0, 0, 16, 8))); err != nil {
+		tb.Fatal(err)
+	}
+	path := filepath.Join(dir, "sheet.json")
+	if err := os.WriteFile(path, []byte(sheet), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadSpriteSheetDurations(t *testing.T) {
+	tests := []struct {
+		name   string
+		sheet  string // Các trường thêm vào sheet
+		idle   string // Các trường thêm vào clip idle
+		frames []float64
+	}{
+		{"mặc định", ``, ``, []float64{DefaultFrameDuration, DefaultFrameDuration}},
+		{"duration của sheet", `"duration": 0.2,`, ``, []float64{0.2, 0.2}},
+		{"duration của clip", `"duration": 0.2,`, `, "duration": 0.05`, []float64{0.05, 0.05}},
+		{"durations từng frame", ``, `, "durations": [0.3, 0.1]`, []float64{0.3, 0.1}},
+		{"duration sheet âm", `"duration": -0.1,`, ``, nil},
+		{"duration clip âm", ``, `, "duration": -0.1`, nil},
+		{"durations có frame 0", ``, `, "durations": [0.1, 0]`, nil},
+		{"durations có frame âm", ``, `, "durations": [-0.1, 0.1]`, nil},
+		{"durations sai số frame", ``, `, "durations": [0.1]`, nil},
+	}
+	for _, tt := range tests {
+		path := writeSheet(t, `{"frameWidth": 8, "frameHeight": 8, `+tt.sheet+`
+			"clips": {"idle": {"image": "idle.png"`+tt.idle+`}}}`)
+		sheet, err := LoadSpriteSheet(path)
+		if tt.frames == nil {
+			if err == nil {
+				t.Errorf("%s: không báo lỗi", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		clip := sheet.Clips[ClipIdle]
+		if len(clip.Frames) != len(tt.frames) {
+			t.Errorf("%s: %d frame, muốn %d", tt.name, len(clip.Frames), len(tt.frames))
+			continue
+		}
+		for i, f := range clip.Frames {
+			if f.Duration != tt.frames[i] {
+				t.Errorf("%s: frame %d dài %v, muốn %v", tt.name, i+1, f.Duration, tt.frames[i])
+			}
+		}
+	}
+}
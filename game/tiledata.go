@@ -0,0 +1,167 @@
+package game
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Các bit cờ lật/xoay Tiled lưu ở các bit cao của GID
+const (
+	FlipHorizontal = 0x80000000 // Lật ngang
+	FlipVertical   = 0x40000000 // Lật dọc
+	FlipDiagonal   = 0x20000000 // Lật chéo (đổi trục x và y), kết hợp với 2 cờ trên để xoay 90 độ
+	flipHexRotate  = 0x10000000 // Xoay 120 độ (chỉ dùng cho map lục giác, bỏ qua)
+
+	gidMask = 0x0FFFFFFF
+)
+
+// TileFlip là các cờ lật của 1 ô trên map
+type TileFlip struct {
+	Horizontal bool
+	Vertical   bool
+	Diagonal   bool
+}
+
+// Any kiểm tra ô có bị lật/xoay không
+func (f TileFlip) Any() bool {
+	return f.Horizontal || f.Vertical || f.Diagonal
+}
+
+// SplitGID tách GID thật và các cờ lật từ giá trị thô trong data của layer
+func SplitGID(raw int) (int, TileFlip) {
+	flip := TileFlip{
+		Horizontal: raw&FlipHorizontal != 0,
+		Vertical:   raw&FlipVertical != 0,
+		Diagonal:   raw&FlipDiagonal != 0,
+	}
+	return raw & gidMask, flip
+}
+
+// Các kiểu mã hóa và nén data của layer
+const (
+	EncodingCSV    = "csv"
+	EncodingBase64 = "base64"
+
+	CompressionZlib = "zlib"
+	CompressionGzip = "gzip"
+)
+
+// maxLayerTiles là số ô tối đa của 1 layer hoặc chunk, để file map hỏng hoặc cố tình sửa
+// không làm game cấp phát quá nhiều bộ nhớ
+const maxLayerTiles = 1 << 24
+
+// tileCount trả về số ô của vùng width x height, lỗi nếu kích thước âm hoặc quá maxLayerTiles
+func tileCount(width, height int) (int, error) {
+	if width < 0 || height < 0 || (width > 0 && height > maxLayerTiles/width) {
+		return 0, fmt.Errorf("kích thước %dx%d không hợp lệ (tối đa %d ô)", width, height, maxLayerTiles)
+	}
+	return width * height, nil
+}
+
+// decodeTileData giải mã data của tile layer (hoặc chunk) về mảng GID thô (còn bit lật).
+// raw là giá trị "data" trong JSON: mảng số, chuỗi CSV hoặc chuỗi base64 (có thể nén zlib/gzip).
+// cells là số ô của layer, data nén không được bung ra quá số ô này.
+func decodeTileData(raw json.RawMessage, encoding, compression string, cells int) ([]int, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	// Mảng số (mặc định của Tiled JSON)
+	if raw[0] == '[' {
+		var data []int
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return nil, err
+	}
+	return decodeTileText(text, encoding, compression, cells)
+}
+
+// decodeTileText giải mã data dạng chuỗi (CSV hoặc base64), dùng chung cho JSON và TMX
+func decodeTileText(text, encoding, compression string, cells int) ([]int, error) {
+	switch encoding {
+	case EncodingBase64:
+		return decodeBase64Data(text, compression, cells)
+	case EncodingCSV, "":
+		return decodeCSVData(text)
+	default:
+		return nil, fmt.Errorf("không hỗ trợ encoding %q", encoding)
+	}
+}
+
+// decodeCSVData đọc data dạng "1,2,3,\n4,5,6"
+func decodeCSVData(text string) ([]int, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r' || r == ' ' || r == '\t'
+	})
+	data := make([]int, len(fields))
+	for i, f := range fields {
+		v, err := strconv.ParseUint(f, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("csv: %w", err)
+		}
+		data[i] = int(v)
+	}
+	return data, nil
+}
+
+// decodeBase64Data đọc data base64: mỗi ô là 1 số uint32 little-endian, có thể được nén trước khi mã hóa.
+// Đọc tối đa cells ô, để data nén nhỏ không bung ra hàng GB lúc load map.
+func decodeBase64Data(text, compression string, cells int) ([]int, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+	if err != nil {
+		return nil, fmt.Errorf("base64: %w", err)
+	}
+
+	var r io.Reader = bytes.NewReader(b)
+	switch compression {
+	case "":
+	case CompressionZlib:
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("zlib: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	case CompressionGzip:
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		defer gr.Close()
+		r = gr
+	default:
+		return nil, fmt.Errorf("không hỗ trợ compression %q", compression)
+	}
+
+	limit := int64(cells) * 4
+	raw, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(raw)) > limit {
+		return nil, fmt.Errorf("base64: data dài hơn %d ô", cells)
+	}
+	if len(raw)%4 != 0 {
+		return nil, fmt.Errorf("base64: độ dài data %d không chia hết cho 4", len(raw))
+	}
+
+	data := make([]int, len(raw)/4)
+	for i := range data {
+		data[i] = int(binary.LittleEndian.Uint32(raw[i*4:]))
+	}
+	return data, nil
+}
@@ -0,0 +1,73 @@
+package game
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// zlibTiles nén data GID (uint32 little-endian) bằng zlib rồi mã hóa base64
+func zlibTiles(tb testing.TB, gids []uint32) string {
+	tb.Helper()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if err := binary.Write(zw, binary.LittleEndian, gids); err != nil {
+		tb.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		tb.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// layerMap tạo file map JSON width x height có 1 tile layer với data và encoding cho trước
+func layerMap(tb testing.TB, width, height int, data, encoding, compression string) string {
+	tb.Helper()
+	return writeAsset(tb, "map.json", fmt.Sprintf(`{"width": %d, "height": %d, "tilewidth": 16, "tileheight": 16,
+		"layers": [{"name": "Floor", "type": "tilelayer", "width": %d, "height": %d,
+			"data": %s, "encoding": %q, "compression": %q}]}`,
+		width, height, width, height, data, encoding, compression))
+}
+
+func TestLayerDataSize(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		ok   bool
+	}{
+		{"mảng đủ ô", layerMap(t, 2, 2, `[1, 2, 3, 4]`, "", ""), true},
+		{"mảng thiếu ô", layerMap(t, 2, 2, `[1, 2, 3]`, "", ""), false},
+		{"mảng thừa ô", layerMap(t, 2, 2, `[1, 2, 3, 4, 5]`, "", ""), false},
+		{"csv thiếu ô", layerMap(t, 2, 2, `"1,2,3"`, EncodingCSV, ""), false},
+		{"zlib đủ ô", layerMap(t, 2, 2, `"`+zlibTiles(t, []uint32{1, 2, 3, 4})+`"`, EncodingBase64, CompressionZlib), true},
+		{"zlib thiếu ô", layerMap(t, 2, 2, `"`+zlibTiles(t, []uint32{1, 2})+`"`, EncodingBase64, CompressionZlib), false},
+		{"kích thước âm", layerMap(t, -2, 2, `[]`, "", ""), false},
+		{"kích thước khổng lồ", layerMap(t, 1<<20, 1<<20, `[]`, "", ""), false},
+	}
+	for _, tt := range tests {
+		if _, err := LoadTilemap(tt.path); (err == nil) != tt.ok {
+			t.Errorf("%s: lỗi = %v, muốn ok = %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+// TestLayerInflateLimit: layer zlib nhỏ bung ra 16 MiB phải bị từ chối mà không cấp phát hết chỗ đó
+func TestLayerInflateLimit(t *testing.T) {
+	path := layerMap(t, 2, 2, `"`+zlibTiles(t, make([]uint32, 4<<20))+`"`, EncodingBase64, CompressionZlib)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	_, err := LoadTilemap(path)
+	runtime.ReadMemStats(&after)
+	if err == nil {
+		t.Fatal("layer bung quá số ô không bị từ chối")
+	}
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 1<<20 {
+		t.Errorf("cấp phát %d byte khi đọc layer 2x2", alloc)
+	}
+}
@@ -0,0 +1,144 @@
+package game
+
+import "math"
+
+// CacheRegionTiles là cạnh (tính bằng tile) của 1 vùng vẽ sẵn với layer thường.
+// Layer chia chunk thì mỗi chunk là 1 vùng.
+const CacheRegionTiles = 16
+
+// Drawable kiểm tra layer có cần vẽ không.
+// Layer ẩn (vd. layer Collision) chỉ dùng cho logic.
+func (l *TilemapLayerJSON) Drawable() bool {
+	return l.Visible && l.Type != LayerObjects
+}
+
+// TileOverdraw là số pixel tile cao nhất vẽ tràn lên trên ô lưới, dùng để mở rộng vùng cull
+func (t *TilemapJSON) TileOverdraw() int {
+	overdraw := 0
+	for _, ts := range t.Tilesets {
+		overdraw = max(overdraw, ts.TileH-t.TileH)
+	}
+	return overdraw
+}
+
+// EachTileIn gọi fn cho các ô khác 0 trong khung tile [tx0, tx1] x [ty0, ty1] của layer thường
+func (l *TilemapLayerJSON) EachTileIn(tx0, ty0, tx1, ty1 int, fn func(tx, ty, gid int)) {
+	for ty := ty0; ty <= ty1; ty++ {
+		for tx := tx0; tx <= tx1; tx++ {
+			idx := ty*l.Width + tx
+			if idx >= len(l.Data) {
+				return
+			}
+			if gid := l.Data[idx]; gid != 0 {
+				fn(tx, ty, gid)
+			}
+		}
+	}
+}
+
+// EachVisibleTile gọi fn cho mọi ô khác 0 của các layer cần vẽ nằm trong khung nhìn (x, y, w, h) pixel,
+// theo thứ tự vẽ. Layer thường chỉ duyệt các ô trong khung, layer chia chunk bỏ qua chunk ngoài khung;
+// chunk lọt vào khung mà chưa được stream thì nạp ngay.
+func (t *TilemapJSON) EachVisibleTile(x, y, w, h float64, fn func(layer, tx, ty, gid int)) {
+	overdraw := float64(t.TileOverdraw())
+	for i := range t.Layers {
+		layer := &t.Layers[i]
+		if !layer.Drawable() {
+			continue
+		}
+
+		if layer.Chunked() {
+			for _, c := range layer.Chunks {
+				cx, cy, cw, ch := c.Bounds(t.TileW, t.TileH)
+				if !rectsOverlap(cx, cy-overdraw, cw, ch+overdraw, x, y, w, h) {
+					continue
+				}
+				if err := c.Load(); err != nil {
+					continue
+				}
+				for idx, gid := range c.Data {
+					if gid != 0 {
+						fn(i, c.X+idx%c.Width, c.Y+idx/c.Width, gid)
+					}
+				}
+			}
+			continue
+		}
+
+		tx0 := max(0, int(math.Floor(x/float64(t.TileW))))
+		ty0 := max(0, int(math.Floor(y/float64(t.TileH))))
+		tx1 := min(layer.Width-1, int(math.Floor((x+w)/float64(t.TileW))))
+		ty1 := min(layer.Height-1, int(math.Floor((y+h+overdraw)/float64(t.TileH))))
+		layer.EachTileIn(tx0, ty0, tx1, ty1, func(tx, ty, gid int) {
+			fn(i, tx, ty, gid)
+		})
+	}
+}
+
+// TileRegion là 1 vùng vẽ sẵn được của layer: khối CacheRegionTiles x CacheRegionTiles ô
+// với layer thường, hoặc cả 1 chunk với layer chia chunk
+type TileRegion struct {
+	Layer         int // Chỉ số layer trong map
+	X, Y          int // Tile góc trên trái
+	Width, Height int // Số tile theo mỗi chiều
+	chunk         *TileChunk
+}
+
+// EachTile gọi fn cho các ô khác 0 của vùng.
+// Chunk chưa nạp được giải mã tạm rồi bỏ, nên dựng ảnh vùng không cần giữ chunk trong bộ nhớ.
+func (r TileRegion) EachTile(t *TilemapJSON, fn func(tx, ty, gid int)) {
+	if r.chunk == nil {
+		t.Layers[r.Layer].EachTileIn(r.X, r.Y, r.X+r.Width-1, r.Y+r.Height-1, fn)
+		return
+	}
+	data, err := r.chunk.Tiles()
+	if err != nil {
+		return
+	}
+	for idx, gid := range data {
+		if gid != 0 {
+			fn(r.X+idx%r.Width, r.Y+idx/r.Width, gid)
+		}
+	}
+}
+
+// EachVisibleRegion gọi fn cho mọi vùng của các layer cần vẽ giao với khung nhìn (x, y, w, h) pixel,
+// theo thứ tự vẽ
+func (t *TilemapJSON) EachVisibleRegion(x, y, w, h float64, fn func(TileRegion)) {
+	overdraw := float64(t.TileOverdraw())
+	for i := range t.Layers {
+		layer := &t.Layers[i]
+		if !layer.Drawable() {
+			continue
+		}
+
+		if layer.Chunked() {
+			for _, c := range layer.Chunks {
+				cx, cy, cw, ch := c.Bounds(t.TileW, t.TileH)
+				if rectsOverlap(cx, cy-overdraw, cw, ch+overdraw, x, y, w, h) {
+					fn(TileRegion{Layer: i, X: c.X, Y: c.Y, Width: c.Width, Height: c.Height, chunk: c})
+				}
+			}
+			continue
+		}
+
+		regionW := float64(CacheRegionTiles * t.TileW)
+		regionH := float64(CacheRegionTiles * t.TileH)
+		rx0 := max(0, int(math.Floor(x/regionW)))
+		ry0 := max(0, int(math.Floor(y/regionH)))
+		rx1 := min((layer.Width-1)/CacheRegionTiles, int(math.Floor((x+w)/regionW)))
+		ry1 := min((layer.Height-1)/CacheRegionTiles, int(math.Floor((y+h+overdraw)/regionH)))
+		for ry := ry0; ry <= ry1; ry++ {
+			for rx := rx0; rx <= rx1; rx++ {
+				tx0, ty0 := rx*CacheRegionTiles, ry*CacheRegionTiles
+				fn(TileRegion{
+					Layer:  i,
+					X:      tx0,
+					Y:      ty0,
+					Width:  min(CacheRegionTiles, layer.Width-tx0),
+					Height: min(CacheRegionTiles, layer.Height-ty0),
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package game
+
+import (
+	"testing"
+)
+
+// Khung nhìn dùng trong test, bằng màn hình của game
+const (
+	testViewW = 960
+	testViewH = 540
+)
+
+// largeTilemap tạo map size x size tile gồm layer nền kín ô, layer trang trí thưa và layer Collision ẩn,
+// dùng tileset của map spawn
+func largeTilemap(tb testing.TB, size int) *TilemapJSON {
+	tb.Helper()
+	spawn := loadTestTilemap(tb)
+	ground := make([]int, size*size)
+	decor := make([]int, size*size)
+	collision := make([]int, size*size)
+	rng := NewRand(1)
+	for i := range ground {
+		ground[i] = 246
+		if rng.IntN(8) == 0 {
+			decor[i] = 155 + rng.IntN(3)
+		}
+		if rng.IntN(16) == 0 {
+			collision[i] = 1
+		}
+	}
+	return &TilemapJSON{
+		Layers: []TilemapLayerJSON{
+			{Name: "Ground", Type: LayerTiles, Data: ground, Width: size, Height: size, Visible: true},
+			{Name: "Decor", Type: LayerTiles, Data: decor, Width: size, Height: size, Visible: true},
+			{Name: CollisionLayerName, Type: LayerTiles, Data: collision, Width: size, Height: size},
+		},
+		Tilesets: spawn.Tilesets,
+		Width:    size,
+		Height:   size,
+		TileW:    spawn.TileW,
+		TileH:    spawn.TileH,
+	}
+}
+
+func TestEachVisibleTile(t *testing.T) {
+	tm := largeTilemap(t, 100)
+	views := [][4]float64{
+		{0, 0, testViewW, testViewH},
+		{333.5, 77.25, testViewW, testViewH},
+		{-100, -50, 200, 100},              // Lệch ra ngoài mép trên trái
+		{1500, 1500, testViewW, testViewH}, // Lệch ra ngoài mép dưới phải
+		{0, 0, 16, 16},                     // Đúng 1 ô
+		{3000, 3000, testViewW, testViewH}, // Hoàn toàn ngoài map
+	}
+	for _, v := range views {
+		x, y, w, h := v[0], v[1], v[2], v[3]
+		want := map[[3]int]int{}
+		for i, layer := range tm.Layers {
+			if !layer.Drawable() {
+				continue
+			}
+			_ = layer.EachTile(func(tx, ty, gid int) {
+				if rectsOverlap(float64(tx*16), float64(ty*16), 16, 16, x, y, w, h) {
+					want[[3]int{i, tx, ty}] = gid
+				}
+			})
+		}
+
+		got := map[[3]int]int{}
+		tm.EachVisibleTile(x, y, w, h, func(layer, tx, ty, gid int) {
+			got[[3]int{layer, tx, ty}] = gid
+		})
+		// Khung nhìn tính cả mép phải/dưới nên có thể vẽ thừa 1 hàng/cột ô chỉ chạm mép, nhưng không được thiếu
+		for k, gid := range want {
+			if got[k] != gid {
+				t.Fatalf("khung %v: thiếu ô %v", v, k)
+			}
+		}
+		if len(got) > len(want)+2*int(w/16+h/16+2) {
+			t.Errorf("khung %v: duyệt %d ô, chỉ cần %d", v, len(got), len(want))
+		}
+
+		// Các vùng giao khung nhìn phủ hết các ô cần vẽ
+		covered := map[[3]int]bool{}
+		tm.EachVisibleRegion(x, y, w, h, func(r TileRegion) {
+			r.EachTile(tm, func(tx, ty, gid int) {
+				covered[[3]int{r.Layer, tx, ty}] = true
+			})
+		})
+		for k := range want {
+			if !covered[k] {
+				t.Fatalf("khung %v: ô %v không thuộc vùng nào", v, k)
+			}
+		}
+	}
+}
+
+// BenchmarkDrawLargeMap đo 1 khung hình vẽ map 1024x1024 tile trong khi camera lia qua map:
+// số lần DrawImage (draws/op) và cấp phát của phần chọn ô/vùng cần vẽ.
+//   - all: vẽ mọi ô của mọi layer (cách cũ)
+//   - culled: chỉ vẽ ô trong camera
+//   - cached: vẽ các vùng dựng sẵn, mỗi vùng có ô là 1 lần vẽ (vùng được dựng 1 lần rồi dùng lại)
+func BenchmarkDrawLargeMap(b *testing.B) {
+	tm := largeTilemap(b, 1024)
+	view := func(i int) (float64, float64) {
+		maxX := float64(tm.Width*tm.TileW) - testViewW
+		maxY := float64(tm.Height*tm.TileH) - testViewH
+		return float64(i*37%int(maxX)) + 0.5, float64(i*23%int(maxY)) + 0.25
+	}
+
+	b.Run("all", func(b *testing.B) {
+		b.ReportAllocs()
+		draws := 0
+		for i := 0; i < b.N; i++ {
+			for l := range tm.Layers {
+				if tm.Layers[l].Drawable() {
+					_ = tm.Layers[l].EachTile(func(tx, ty, gid int) { draws++ })
+				}
+			}
+		}
+		b.ReportMetric(float64(draws)/float64(b.N), "draws/op")
+	})
+
+	b.Run("culled", func(b *testing.B) {
+		b.ReportAllocs()
+		draws := 0
+		for i := 0; i < b.N; i++ {
+			x, y := view(i)
+			tm.EachVisibleTile(x, y, testViewW, testViewH, func(layer, tx, ty, gid int) { draws++ })
+		}
+		b.ReportMetric(float64(draws)/float64(b.N), "draws/op")
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		// Vùng đã dựng: true nếu vùng có ô (ảnh vùng khác nil)
+		built := map[TileRegion]bool{}
+		draws := 0
+		for i := 0; i < b.N; i++ {
+			x, y := view(i)
+			tm.EachVisibleRegion(x, y, testViewW, testViewH, func(r TileRegion) {
+				filled, ok := built[r]
+				if !ok {
+					r.EachTile(tm, func(tx, ty, gid int) { filled = true })
+					built[r] = filled
+				}
+				if filled {
+					draws++
+				}
+			})
+		}
+		b.ReportMetric(float64(draws)/float64(b.N), "draws/op")
+	})
+}
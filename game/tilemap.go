@@ -2,31 +2,89 @@ package game
 
 import (
 	"encoding/json"
-	"image"
-	"os"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
 
-	"github.com/hajimehoshi/ebiten/v2"
+// Các loại layer trong map Tiled
+const (
+	LayerTiles   = "tilelayer"
+	LayerObjects = "objectgroup"
 )
 
-// TilemapLayerJSON đại diện cho 1 layer trong map
+// TilemapLayerJSON đại diện cho 1 layer trong map.
+// Tile layer dùng Data (hoặc Chunks nếu là map vô hạn), object layer dùng Objects.
 type TilemapLayerJSON struct {
-	Data   []int `json:"data"`
-	Width  int   `json:"width"`
-	Height int   `json:"height"`
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Data        []int           `json:"-"`    // GID thô của từng ô (còn bit lật, xem SplitGID)
+	RawData     json.RawMessage `json:"data"` // Data như trong file: mảng số, CSV hoặc base64
+	Encoding    string          `json:"encoding"`
+	Compression string          `json:"compression"`
+	Chunks      []*TileChunk    `json:"chunks"` // Chỉ có ở map vô hạn
+	StartX      int             `json:"startx"` // Tile góc trên trái của vùng chunk (map vô hạn)
+	StartY      int             `json:"starty"`
+	Width       int             `json:"width"`
+	Height      int             `json:"height"`
+	Visible     bool            `json:"visible"`
+	Objects     []TiledObject   `json:"objects"`
 }
 
-// TilemapJSON chứa toàn bộ layer của map
+// decode giải mã data của layer theo encoding/compression
+func (l *TilemapLayerJSON) decode() error {
+	cells, err := tileCount(l.Width, l.Height)
+	if err != nil {
+		return fmt.Errorf("layer %q: %w", l.Name, err)
+	}
+	data, err := decodeTileData(l.RawData, l.Encoding, l.Compression, cells)
+	if err != nil {
+		return fmt.Errorf("layer %q: %w", l.Name, err)
+	}
+	// Thiếu ô thì EachTileIn lặng lẽ bỏ qua phần cuối layer, nên báo lỗi ngay lúc load
+	if data != nil && len(data) != cells {
+		return fmt.Errorf("layer %q: có %d ô, cần %d", l.Name, len(data), cells)
+	}
+	l.Data = data
+	l.RawData = nil
+
+	// Chunk chỉ được giải mã thử để báo lỗi sớm, data thật nạp khi cần (xem StreamChunks)
+	for _, c := range l.Chunks {
+		cells, err := tileCount(c.Width, c.Height)
+		if err != nil {
+			return fmt.Errorf("layer %q: chunk (%d, %d): %w", l.Name, c.X, c.Y, err)
+		}
+		raw, encoding, compression := c.RawData, l.Encoding, l.Compression
+		c.RawData = nil
+		c.source = func() ([]int, error) {
+			return decodeTileData(raw, encoding, compression, cells)
+		}
+		if _, err := c.decode(); err != nil {
+			return fmt.Errorf("layer %q: %w", l.Name, err)
+		}
+	}
+	return nil
+}
+
+// TilemapJSON chứa toàn bộ layer và tileset của map
 type TilemapJSON struct {
-	Layers []TilemapLayerJSON `json:"layers"`
-	Width  int                `json:"width"`
-	Height int                `json:"height"`
-	TileW  int                `json:"tilewidth"`
-	TileH  int                `json:"tileheight"`
+	Layers   []TilemapLayerJSON `json:"layers"`
+	Tilesets []*Tileset         `json:"tilesets"` // Theo thứ tự firstgid tăng dần
+	Width    int                `json:"width"`
+	Height   int                `json:"height"`
+	TileW    int                `json:"tilewidth"`
+	TileH    int                `json:"tileheight"`
+	Infinite bool               `json:"infinite"`
+	Path     string             `json:"-"` // File map đã load
+
+	// Tile gốc (trong Tiled) của map vô hạn sau khi dời về (0, 0)
+	OriginX int `json:"-"`
+	OriginY int `json:"-"`
 }
 
-// NewTilemapJSON đọc file map JSON (Tiled) và parse
-func NewTilemapJSON(filepath string) (*TilemapJSON, error) {
-	contents, err := os.ReadFile(filepath)
+// NewTilemapJSON đọc file map JSON (Tiled) và parse, kể cả các tileset ngoài (TSX)
+func NewTilemapJSON(path string) (*TilemapJSON, error) {
+	contents, err := ReadAsset(path)
 	if err != nil {
 		return nil, err
 	}
@@ -36,45 +94,73 @@ func NewTilemapJSON(filepath string) (*TilemapJSON, error) {
 		return nil, err
 	}
 
+	tilemap.Path = path
+	for i := range tilemap.Layers {
+		if err := tilemap.Layers[i].decode(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if tilemap.Infinite {
+		tilemap.normalizeInfinite()
+	}
+	if err := tilemap.resolveTilesets(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
 	return &tilemap, nil
 }
 
-// DrawTilemap vẽ map với camera offset
-func DrawTilemap(screen *ebiten.Image, tilemap *TilemapJSON, tileset *ebiten.Image, cameraX, cameraY float64) {
-	if tilemap == nil || tileset == nil {
-		return
+// resolveTilesets load các tileset ngoài và sắp xếp theo firstgid
+func (t *TilemapJSON) resolveTilesets(baseDir string) error {
+	for _, ts := range t.Tilesets {
+		if err := ts.resolve(baseDir); err != nil {
+			return err
+		}
 	}
+	sort.SliceStable(t.Tilesets, func(i, j int) bool {
+		return t.Tilesets[i].FirstGID < t.Tilesets[j].FirstGID
+	})
+	return nil
+}
 
-	opts := ebiten.DrawImageOptions{}
-	tileW := tilemap.TileW
-	tileH := tilemap.TileH
-	tilesPerRow := tileset.Bounds().Dx() / tileW
-
-	for _, layer := range tilemap.Layers {
-		for idx, id := range layer.Data {
-			if id == 0 {
-				continue
-			}
-
-			x := idx % layer.Width
-			y := idx / layer.Width
-
-			dstX := float64(x*tileW) - cameraX
-			dstY := float64(y*tileH) - cameraY
+// TilesetFor trả về tileset chứa gid và ID cục bộ của tile trong tileset đó (nil nếu không có).
+// Bit lật trong gid được bỏ qua.
+func (t *TilemapJSON) TilesetFor(gid int) (*Tileset, int) {
+	gid &= gidMask
+	if gid <= 0 {
+		return nil, 0
+	}
+	// Tileset có firstgid lớn nhất mà vẫn <= gid
+	for i := len(t.Tilesets) - 1; i >= 0; i-- {
+		ts := t.Tilesets[i]
+		if gid >= ts.FirstGID {
+			return ts, gid - ts.FirstGID
+		}
+	}
+	return nil, 0
+}
 
-			srcX := (id - 1) % tilesPerRow
-			srcY := (id - 1) / tilesPerRow
+// TileInfo trả về dữ liệu riêng (thuộc tính, animation) của tile theo gid, nil nếu không có
+func (t *TilemapJSON) TileInfo(gid int) *TileInfo {
+	ts, local := t.TilesetFor(gid)
+	if ts == nil {
+		return nil
+	}
+	return ts.Tile(local)
+}
 
-			srcRect := image.Rect(
-				srcX*tileW,
-				srcY*tileH,
-				srcX*tileW+tileW,
-				srcY*tileH+tileH,
-			)
+// TileCollides kiểm tra tile có thuộc tính "collides" = true trong tileset không
+func (t *TilemapJSON) TileCollides(gid int) bool {
+	info := t.TileInfo(gid)
+	return info != nil && info.BoolProperty("collides", false)
+}
 
-			opts.GeoM.Translate(dstX, dstY)
-			screen.DrawImage(tileset.SubImage(srcRect).(*ebiten.Image), &opts)
-			opts.GeoM.Reset()
+// Objects trả về toàn bộ object trong các object layer của map
+func (t *TilemapJSON) Objects() []TiledObject {
+	var objects []TiledObject
+	for _, layer := range t.Layers {
+		if layer.Type == LayerObjects {
+			objects = append(objects, layer.Objects...)
 		}
 	}
+	return objects
 }
@@ -0,0 +1,271 @@
+package game
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strconv"
+)
+
+// TileFrame là 1 frame trong animation của tile
+type TileFrame struct {
+	TileID   int `json:"tileid"`   // ID cục bộ của tile trong tileset
+	Duration int `json:"duration"` // Mili giây
+}
+
+// TileInfo là dữ liệu riêng của 1 tile trong tileset: loại, thuộc tính tùy chỉnh và animation
+type TileInfo struct {
+	ID         int             `json:"id"` // ID cục bộ (gid - firstgid)
+	Type       string          `json:"type"`
+	Class      string          `json:"class"`
+	Properties []TiledProperty `json:"properties"`
+	Animation  []TileFrame     `json:"animation"`
+}
+
+// Property trả về giá trị thuộc tính của tile theo tên
+func (t *TileInfo) Property(name string) (any, bool) {
+	for _, p := range t.Properties {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+	return nil, false
+}
+
+// BoolProperty trả về thuộc tính dạng bool của tile (def nếu không có)
+func (t *TileInfo) BoolProperty(name string, def bool) bool {
+	v, _ := t.Property(name)
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	return def
+}
+
+// Animated kiểm tra tile có animation không
+func (t *TileInfo) Animated() bool {
+	return len(t.Animation) > 0
+}
+
+// FrameAt trả về ID cục bộ của frame animation tại thời điểm seconds (tính bằng giây, lặp vòng)
+func (t *TileInfo) FrameAt(seconds float64) int {
+	if len(t.Animation) == 0 {
+		return t.ID
+	}
+	total := 0
+	for _, f := range t.Animation {
+		total += f.Duration
+	}
+	if total <= 0 {
+		return t.Animation[0].TileID
+	}
+
+	ms := int(seconds*1000) % total
+	for _, f := range t.Animation {
+		if ms < f.Duration {
+			return f.TileID
+		}
+		ms -= f.Duration
+	}
+	return t.Animation[len(t.Animation)-1].TileID
+}
+
+// Tileset là 1 tileset của map, nhúng trong map hoặc đọc từ file TSX ngoài
+type Tileset struct {
+	FirstGID  int         `json:"firstgid"`
+	Source    string      `json:"source"` // File TSX ngoài (rỗng nếu tileset nhúng trong map)
+	Name      string      `json:"name"`
+	TileW     int         `json:"tilewidth"`
+	TileH     int         `json:"tileheight"`
+	TileCount int         `json:"tilecount"`
+	Columns   int         `json:"columns"`
+	Spacing   int         `json:"spacing"`
+	Margin    int         `json:"margin"`
+	Image     string      `json:"image"` // Đường dẫn ảnh (sau khi load là đường dẫn tính từ thư mục chạy game)
+	ImageW    int         `json:"imagewidth"`
+	ImageH    int         `json:"imageheight"`
+	Tiles     []*TileInfo `json:"tiles"`
+
+	tiles map[int]*TileInfo
+}
+
+// resolve đọc file TSX (nếu là tileset ngoài), tính đường dẫn ảnh và dựng bảng tra tile.
+// baseDir là thư mục chứa file map.
+func (ts *Tileset) resolve(baseDir string) error {
+	imageDir := baseDir
+	if ts.Source != "" {
+		path := filepath.Join(baseDir, ts.Source)
+		if err := ts.loadTSX(path); err != nil {
+			return err
+		}
+		// Ảnh của tileset ngoài tính tương đối với file TSX
+		imageDir = filepath.Dir(path)
+	}
+	if ts.Image != "" {
+		ts.Image = filepath.Join(imageDir, ts.Image)
+	}
+	ts.index()
+	return nil
+}
+
+// index dựng bảng tra tile theo ID cục bộ và tính số cột nếu thiếu
+func (ts *Tileset) index() {
+	if ts.Columns == 0 && ts.TileW > 0 {
+		ts.Columns = (ts.ImageW - 2*ts.Margin + ts.Spacing) / (ts.TileW + ts.Spacing)
+	}
+	ts.tiles = make(map[int]*TileInfo, len(ts.Tiles))
+	for _, t := range ts.Tiles {
+		ts.tiles[t.ID] = t
+	}
+}
+
+// Contains kiểm tra gid có thuộc tileset này không
+func (ts *Tileset) Contains(gid int) bool {
+	return gid >= ts.FirstGID && gid < ts.FirstGID+ts.TileCount
+}
+
+// Tile trả về dữ liệu riêng của tile theo ID cục bộ (nil nếu tile không có dữ liệu gì)
+func (ts *Tileset) Tile(localID int) *TileInfo {
+	return ts.tiles[localID]
+}
+
+// AnimatedTile trả về ID cục bộ của tile cần vẽ tại thời điểm seconds:
+// frame hiện tại nếu tile có animation, còn lại chính là localID
+func (ts *Tileset) AnimatedTile(localID int, seconds float64) int {
+	if info := ts.Tile(localID); info != nil && info.Animated() {
+		return info.FrameAt(seconds)
+	}
+	return localID
+}
+
+// SourceRect trả về vùng (x0, y0, x1, y1) của tile trong ảnh tileset
+func (ts *Tileset) SourceRect(localID int) (int, int, int, int) {
+	if ts.Columns <= 0 {
+		return 0, 0, 0, 0
+	}
+	col := localID % ts.Columns
+	row := localID / ts.Columns
+	x := ts.Margin + col*(ts.TileW+ts.Spacing)
+	y := ts.Margin + row*(ts.TileH+ts.Spacing)
+	return x, y, x + ts.TileW, y + ts.TileH
+}
+
+// xmlTileset là cấu trúc XML của tileset (file TSX hoặc thẻ <tileset> trong TMX)
+type xmlTileset struct {
+	FirstGID   int    `xml:"firstgid,attr"`
+	Source     string `xml:"source,attr"`
+	Name       string `xml:"name,attr"`
+	TileWidth  int    `xml:"tilewidth,attr"`
+	TileHeight int    `xml:"tileheight,attr"`
+	TileCount  int    `xml:"tilecount,attr"`
+	Columns    int    `xml:"columns,attr"`
+	Spacing    int    `xml:"spacing,attr"`
+	Margin     int    `xml:"margin,attr"`
+	Image      struct {
+		Source string `xml:"source,attr"`
+		Width  int    `xml:"width,attr"`
+		Height int    `xml:"height,attr"`
+	} `xml:"image"`
+	Tiles []struct {
+		ID         int           `xml:"id,attr"`
+		Type       string        `xml:"type,attr"`
+		Class      string        `xml:"class,attr"`
+		Properties []xmlProperty `xml:"properties>property"`
+		Frames     []struct {
+			TileID   int `xml:"tileid,attr"`
+			Duration int `xml:"duration,attr"`
+		} `xml:"animation>frame"`
+	} `xml:"tile"`
+}
+
+// xmlProperty là 1 thuộc tính tùy chỉnh trong XML của Tiled
+type xmlProperty struct {
+	Name  string `xml:"name,attr"`
+	Type  string `xml:"type,attr"`
+	Value string `xml:"value,attr"`
+	Text  string `xml:",chardata"` // Chuỗi nhiều dòng được lưu trong nội dung thẻ
+}
+
+// toProperty đổi thuộc tính XML (luôn là chuỗi) về đúng kiểu như trong file JSON:
+// bool -> bool, int/float -> float64, còn lại -> string
+func (p xmlProperty) toProperty() TiledProperty {
+	raw := p.Value
+	if raw == "" {
+		raw = p.Text
+	}
+	prop := TiledProperty{Name: p.Name, Type: p.Type, Value: raw}
+	if prop.Type == "" {
+		// TMX bỏ qua type của thuộc tính chuỗi, JSON thì ghi rõ "string"
+		prop.Type = "string"
+	}
+	switch p.Type {
+	case "bool":
+		prop.Value = raw == "true"
+	case "int", "float", "object":
+		if f, err := strconv.ParseFloat(raw, 64); err == nil {
+			prop.Value = f
+		}
+	}
+	return prop
+}
+
+// toProperties đổi danh sách thuộc tính XML
+func toProperties(props []xmlProperty) []TiledProperty {
+	if len(props) == 0 {
+		return nil
+	}
+	out := make([]TiledProperty, len(props))
+	for i, p := range props {
+		out[i] = p.toProperty()
+	}
+	return out
+}
+
+// toTileset đổi tileset XML về model chung. Đường dẫn ảnh chưa được resolve.
+func (x *xmlTileset) toTileset() *Tileset {
+	ts := &Tileset{
+		FirstGID:  x.FirstGID,
+		Source:    x.Source,
+		Name:      x.Name,
+		TileW:     x.TileWidth,
+		TileH:     x.TileHeight,
+		TileCount: x.TileCount,
+		Columns:   x.Columns,
+		Spacing:   x.Spacing,
+		Margin:    x.Margin,
+		Image:     x.Image.Source,
+		ImageW:    x.Image.Width,
+		ImageH:    x.Image.Height,
+	}
+	for _, t := range x.Tiles {
+		info := &TileInfo{
+			ID:         t.ID,
+			Type:       t.Type,
+			Class:      t.Class,
+			Properties: toProperties(t.Properties),
+		}
+		for _, f := range t.Frames {
+			info.Animation = append(info.Animation, TileFrame{TileID: f.TileID, Duration: f.Duration})
+		}
+		ts.Tiles = append(ts.Tiles, info)
+	}
+	return ts
+}
+
+// loadTSX đọc file tileset ngoài (TSX) vào ts, giữ nguyên FirstGID và Source của map
+func (ts *Tileset) loadTSX(path string) error {
+	contents, err := ReadAsset(path)
+	if err != nil {
+		return err
+	}
+
+	var x xmlTileset
+	if err := xml.Unmarshal(contents, &x); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	loaded := x.toTileset()
+	loaded.FirstGID = ts.FirstGID
+	loaded.Source = ts.Source
+	*ts = *loaded
+	return nil
+}
@@ -0,0 +1,257 @@
+package game
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LoadTilemap đọc map Tiled theo đuôi file: .tmx (XML) hoặc JSON
+func LoadTilemap(path string) (*TilemapJSON, error) {
+	if strings.EqualFold(filepath.Ext(path), ".tmx") {
+		return NewTilemapTMX(path)
+	}
+	return NewTilemapJSON(path)
+}
+
+// NewTilemapTMX đọc file map TMX (XML của Tiled) về cùng model với NewTilemapJSON
+func NewTilemapTMX(path string) (*TilemapJSON, error) {
+	contents, err := ReadAsset(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var x xmlMap
+	if err := xml.Unmarshal(contents, &x); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	tilemap, err := x.toTilemap()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	tilemap.Path = path
+	if tilemap.Infinite {
+		tilemap.normalizeInfinite()
+	}
+	if err := tilemap.resolveTilesets(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tilemap, nil
+}
+
+// xmlMap là thẻ <map> của file TMX
+type xmlMap struct {
+	Width      int           `xml:"width,attr"`
+	Height     int           `xml:"height,attr"`
+	TileWidth  int           `xml:"tilewidth,attr"`
+	TileHeight int           `xml:"tileheight,attr"`
+	Infinite   int           `xml:"infinite,attr"`
+	Tilesets   []*xmlTileset `xml:"tileset"`
+	// Tile layer và object layer nằm xen kẽ, giữ nguyên thứ tự trong file
+	Layers []xmlLayer `xml:",any"`
+}
+
+// xmlLayer là thẻ <layer> hoặc <objectgroup>
+type xmlLayer struct {
+	XMLName xml.Name
+	Name    string `xml:"name,attr"`
+	Width   int    `xml:"width,attr"`
+	Height  int    `xml:"height,attr"`
+	Visible string `xml:"visible,attr"` // Không có thuộc tính nghĩa là hiện
+	Data    struct {
+		Encoding    string     `xml:"encoding,attr"`
+		Compression string     `xml:"compression,attr"`
+		Text        string     `xml:",chardata"`
+		Tiles       []xmlTile  `xml:"tile"`
+		Chunks      []xmlChunk `xml:"chunk"` // Chỉ có ở map vô hạn
+	} `xml:"data"`
+	Objects []xmlObject `xml:"object"`
+}
+
+// xmlTile là 1 ô của data không mã hóa (mỗi ô là 1 thẻ <tile>)
+type xmlTile struct {
+	GID uint32 `xml:"gid,attr"`
+}
+
+// xmlChunk là 1 chunk của tile layer trong map vô hạn, mã hóa giống data của layer
+type xmlChunk struct {
+	X      int       `xml:"x,attr"`
+	Y      int       `xml:"y,attr"`
+	Width  int       `xml:"width,attr"`
+	Height int       `xml:"height,attr"`
+	Text   string    `xml:",chardata"`
+	Tiles  []xmlTile `xml:"tile"`
+}
+
+// xmlObject là 1 object trong <objectgroup>
+type xmlObject struct {
+	ID         int           `xml:"id,attr"`
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	X          float64       `xml:"x,attr"`
+	Y          float64       `xml:"y,attr"`
+	Width      float64       `xml:"width,attr"`
+	Height     float64       `xml:"height,attr"`
+	Point      *struct{}     `xml:"point"`
+	Ellipse    *struct{}     `xml:"ellipse"`
+	Polygon    *xmlPolygon   `xml:"polygon"`
+	Properties []xmlProperty `xml:"properties>property"`
+}
+
+// xmlPolygon lưu các đỉnh dạng "x1,y1 x2,y2 ..."
+type xmlPolygon struct {
+	Points string `xml:"points,attr"`
+}
+
+// toTilemap đổi map XML về model chung. Tileset chưa được resolve.
+func (x *xmlMap) toTilemap() (*TilemapJSON, error) {
+	tilemap := &TilemapJSON{
+		Width:    x.Width,
+		Height:   x.Height,
+		TileW:    x.TileWidth,
+		TileH:    x.TileHeight,
+		Infinite: x.Infinite != 0,
+	}
+	for _, ts := range x.Tilesets {
+		tilemap.Tilesets = append(tilemap.Tilesets, ts.toTileset())
+	}
+	for _, l := range x.Layers {
+		var layer TilemapLayerJSON
+		var err error
+		switch l.XMLName.Local {
+		case "layer":
+			layer, err = l.toTileLayer()
+		case "objectgroup":
+			layer, err = l.toObjectLayer()
+		default:
+			// Image layer, group layer... chưa hỗ trợ
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("layer %q: %w", l.Name, err)
+		}
+		tilemap.Layers = append(tilemap.Layers, layer)
+	}
+	return tilemap, nil
+}
+
+// toTileLayer đổi thẻ <layer> về tile layer
+func (l *xmlLayer) toTileLayer() (TilemapLayerJSON, error) {
+	layer := TilemapLayerJSON{
+		Name:        l.Name,
+		Type:        LayerTiles,
+		Encoding:    l.Data.Encoding,
+		Compression: l.Data.Compression,
+		Width:       l.Width,
+		Height:      l.Height,
+		Visible:     l.Visible != "0",
+	}
+	encoding, compression := l.Data.Encoding, l.Data.Compression
+	for _, xc := range l.Data.Chunks {
+		text, tiles := xc.Text, xc.Tiles
+		c := &TileChunk{X: xc.X, Y: xc.Y, Width: xc.Width, Height: xc.Height}
+		cells, err := tileCount(c.Width, c.Height)
+		if err != nil {
+			return layer, fmt.Errorf("chunk (%d, %d): %w", c.X, c.Y, err)
+		}
+		c.source = func() ([]int, error) {
+			return decodeXMLTiles(text, tiles, encoding, compression, cells)
+		}
+		if _, err := c.decode(); err != nil {
+			return layer, err
+		}
+		layer.Chunks = append(layer.Chunks, c)
+	}
+	if len(layer.Chunks) > 0 {
+		layer.StartX, layer.StartY = layer.Chunks[0].X, layer.Chunks[0].Y
+		for _, c := range layer.Chunks {
+			layer.StartX = min(layer.StartX, c.X)
+			layer.StartY = min(layer.StartY, c.Y)
+		}
+		return layer, nil
+	}
+
+	cells, err := tileCount(l.Width, l.Height)
+	if err != nil {
+		return layer, err
+	}
+	data, err := decodeXMLTiles(l.Data.Text, l.Data.Tiles, encoding, compression, cells)
+	if err != nil {
+		return layer, err
+	}
+	if len(data) != cells {
+		return layer, fmt.Errorf("có %d ô, cần %d", len(data), cells)
+	}
+	layer.Data = data
+	return layer, nil
+}
+
+// decodeXMLTiles giải mã data XML: chuỗi CSV/base64, hoặc danh sách thẻ <tile> nếu không mã hóa
+func decodeXMLTiles(text string, tiles []xmlTile, encoding, compression string, cells int) ([]int, error) {
+	if encoding == "" {
+		data := make([]int, len(tiles))
+		for i, t := range tiles {
+			data[i] = int(t.GID)
+		}
+		return data, nil
+	}
+	return decodeTileText(text, encoding, compression, cells)
+}
+
+// toObjectLayer đổi thẻ <objectgroup> về object layer
+func (l *xmlLayer) toObjectLayer() (TilemapLayerJSON, error) {
+	layer := TilemapLayerJSON{
+		Name:    l.Name,
+		Type:    LayerObjects,
+		Visible: l.Visible != "0",
+	}
+	for _, o := range l.Objects {
+		obj := TiledObject{
+			ID:         o.ID,
+			Name:       o.Name,
+			Type:       o.Type,
+			Class:      o.Class,
+			X:          o.X,
+			Y:          o.Y,
+			Width:      o.Width,
+			Height:     o.Height,
+			Point:      o.Point != nil,
+			Ellipse:    o.Ellipse != nil,
+			Properties: toProperties(o.Properties),
+		}
+		if o.Polygon != nil {
+			points, err := parsePolygon(o.Polygon.Points)
+			if err != nil {
+				return layer, fmt.Errorf("object %d: %w", o.ID, err)
+			}
+			obj.Polygon = points
+		}
+		layer.Objects = append(layer.Objects, obj)
+	}
+	return layer, nil
+}
+
+// parsePolygon đọc danh sách đỉnh "x1,y1 x2,y2 ..."
+func parsePolygon(s string) ([]TiledPoint, error) {
+	var points []TiledPoint
+	for _, pair := range strings.Fields(s) {
+		xs, ys, ok := strings.Cut(pair, ",")
+		if !ok {
+			return nil, fmt.Errorf("polygon: đỉnh %q không hợp lệ", pair)
+		}
+		px, err := strconv.ParseFloat(xs, 64)
+		if err != nil {
+			return nil, fmt.Errorf("polygon: %w", err)
+		}
+		py, err := strconv.ParseFloat(ys, 64)
+		if err != nil {
+			return nil, fmt.Errorf("polygon: %w", err)
+		}
+		points = append(points, TiledPoint{X: px, Y: py})
+	}
+	return points, nil
+}
@@ -0,0 +1,114 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestTMXMatchesJSON load cùng 1 map ở dạng JSON và TMX (mỗi kiểu mã hóa data) rồi so từng phần
+func TestTMXMatchesJSON(t *testing.T) {
+	tests := []struct {
+		json, tmx string
+	}{
+		{"../assets/maps/spawn.json", "../assets/maps/spawn.tmx"},
+		{"testdata/flipped.json", "testdata/flipped_zlib.tmx"},
+		{"testdata/flipped.json", "testdata/flipped_gzip.tmx"},
+		{"testdata/flipped.json", "testdata/flipped_csv.tmx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tmx, func(t *testing.T) {
+			want, err := LoadTilemap(tt.json)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadTilemap(tt.tmx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			compareTilemaps(t, got, want)
+		})
+	}
+}
+
+func compareTilemaps(t *testing.T, got, want *TilemapJSON) {
+	t.Helper()
+	if got.Width != want.Width || got.Height != want.Height || got.TileW != want.TileW || got.TileH != want.TileH {
+		t.Errorf("kích thước %dx%d (%dx%d), muốn %dx%d (%dx%d)",
+			got.Width, got.Height, got.TileW, got.TileH, want.Width, want.Height, want.TileW, want.TileH)
+	}
+
+	if len(got.Layers) != len(want.Layers) {
+		t.Fatalf("%d layer, muốn %d", len(got.Layers), len(want.Layers))
+	}
+	for i := range want.Layers {
+		g, w := &got.Layers[i], &want.Layers[i]
+		if g.Name != w.Name || g.Type != w.Type || g.Visible != w.Visible || g.Width != w.Width || g.Height != w.Height {
+			t.Errorf("layer %d: %q %s visible=%v %dx%d, muốn %q %s visible=%v %dx%d", i,
+				g.Name, g.Type, g.Visible, g.Width, g.Height, w.Name, w.Type, w.Visible, w.Width, w.Height)
+		}
+		if !reflect.DeepEqual(g.Data, w.Data) {
+			t.Errorf("layer %q: data khác nhau", w.Name)
+		}
+		if !reflect.DeepEqual(g.Objects, w.Objects) {
+			t.Errorf("layer %q: objects\n%+v\nmuốn\n%+v", w.Name, g.Objects, w.Objects)
+		}
+	}
+
+	if len(got.Tilesets) != len(want.Tilesets) {
+		t.Fatalf("%d tileset, muốn %d", len(got.Tilesets), len(want.Tilesets))
+	}
+	for i := range want.Tilesets {
+		g, w := got.Tilesets[i], want.Tilesets[i]
+		if g.FirstGID != w.FirstGID || g.Name != w.Name || g.TileCount != w.TileCount || g.Columns != w.Columns ||
+			g.Image != w.Image || g.ImageW != w.ImageW || g.ImageH != w.ImageH {
+			t.Errorf("tileset %d: %+v, muốn %+v", i, g, w)
+		}
+		if !reflect.DeepEqual(g.Tiles, w.Tiles) {
+			t.Errorf("tileset %q: dữ liệu tile khác nhau", w.Name)
+		}
+	}
+
+	gotGrid := NewCollisionGrid(got, got.TileCollides)
+	wantGrid := NewCollisionGrid(want, want.TileCollides)
+	if !reflect.DeepEqual(gotGrid, wantGrid) {
+		t.Errorf("lưới va chạm khác nhau")
+	}
+}
+
+// TestTMXFlipBits kiểm tra bit lật được giữ nguyên khi giải mã mọi kiểu data
+func TestTMXFlipBits(t *testing.T) {
+	for _, path := range []string{"testdata/flipped.json", "testdata/flipped_zlib.tmx", "testdata/flipped_gzip.tmx", "testdata/flipped_csv.tmx"} {
+		tm, err := LoadTilemap(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ground := tm.Layers[0].Data
+		tests := []struct {
+			tx, ty int
+			gid    int
+			flip   TileFlip
+		}{
+			{0, 0, 1, TileFlip{Horizontal: true, Diagonal: true}},
+			{1, 1, 23, TileFlip{}},
+			{2, 2, 23, TileFlip{Horizontal: true}},
+			{3, 2, 24, TileFlip{Vertical: true}},
+			{4, 2, 24, TileFlip{Diagonal: true}},
+			{5, 3, 25, TileFlip{Horizontal: true, Vertical: true, Diagonal: true}},
+		}
+		for _, tt := range tests {
+			gid, flip := SplitGID(ground[tt.ty*tm.Width+tt.tx])
+			if gid != tt.gid || flip != tt.flip {
+				t.Errorf("%s (%d, %d): gid %d %+v, muốn %d %+v", path, tt.tx, tt.ty, gid, flip, tt.gid, tt.flip)
+			}
+		}
+
+		// Tile lật vẫn va chạm theo thuộc tính của tileset, hố ở layer Pits chặn người nhưng không chặn đạn
+		grid := NewCollisionGrid(tm, tm.TileCollides)
+		if !grid.IsSolid(0, 0) || !grid.IsSolid(3, 3) || grid.IsSolid(2, 2) {
+			t.Errorf("%s: sai tường", path)
+		}
+		if !grid.IsSolid(5, 4) || grid.BlocksShot(5*16+8, 4*16+8) {
+			t.Errorf("%s: sai hố", path)
+		}
+	}
+}
@@ -2,62 +2,85 @@ package game
 
 import (
 	"math"
-	"math/rand"
+	"math/rand/v2"
 )
 
-// WaveManager quản lý các wave quái
+// WaveManager quản lý các wave quái.
+// Mỗi wave là 1 lịch spawn: lấy từ kịch bản (Script) nếu có,
+// còn không thì sinh theo công thức endless (càng về sau càng đông và nhanh).
 type WaveManager struct {
 	CurrentWave    int
 	EnemiesPerWave int
 	EnemiesSpawned int
 	WaveComplete   bool
-	SpawnTimer     float64
+	WaveTime       float64 // Thời gian đã trôi từ đầu wave
 	SpawnInterval  float64
 	ScreenWidth    float64
 	ScreenHeight   float64
+	BossEvery      int          // Mỗi BossEvery wave endless có 1 wave boss (0 = không có boss)
+	Script         *WaveScript  // Kịch bản wave (nil = chỉ dùng công thức endless)
+	Schedule       []SpawnEvent // Lịch spawn của wave hiện tại
+	Due            []SpawnEvent // Các lần spawn đã tới giờ, World lấy ra và tạo quái
 }
 
 // NewWaveManager tạo wave manager mới
 func NewWaveManager(screenWidth, screenHeight float64) *WaveManager {
-	return &WaveManager{
-		CurrentWave:    1,
-		EnemiesPerWave: 5,
-		EnemiesSpawned: 0,
-		WaveComplete:   false,
-		SpawnTimer:     0.0,
-		SpawnInterval:  1.0, // Spawn mỗi 1 giây
-		ScreenWidth:    screenWidth,
-		ScreenHeight:   screenHeight,
+	wm := &WaveManager{
+		ScreenWidth:  screenWidth,
+		ScreenHeight: screenHeight,
+		BossEvery:    DefaultBossEvery,
 	}
+	wm.Reset()
+	return wm
 }
 
-// Update cập nhật wave manager
-func (wm *WaveManager) Update() {
+// SetScript đặt kịch bản wave và lên lịch lại wave hiện tại
+func (wm *WaveManager) SetScript(script *WaveScript) {
+	wm.Script = script
+	wm.startWave()
+}
+
+// Update cập nhật wave manager, đưa các lần spawn tới giờ vào Due
+func (wm *WaveManager) Update(clock *Clock) {
 	if wm.WaveComplete {
 		return
 	}
 
-	wm.SpawnTimer += 1.0 / 60.0
+	wm.WaveTime += clock.DT()
 
-	if wm.SpawnTimer >= wm.SpawnInterval && wm.EnemiesSpawned < wm.EnemiesPerWave {
-		wm.SpawnTimer = 0.0
+	for wm.EnemiesSpawned < len(wm.Schedule) && wm.Schedule[wm.EnemiesSpawned].Time <= wm.WaveTime {
+		wm.Due = append(wm.Due, wm.Schedule[wm.EnemiesSpawned])
 		wm.EnemiesSpawned++
 	}
 
-	// Kiểm tra wave hoàn thành (khi đã spawn hết quái)
-	if wm.EnemiesSpawned >= wm.EnemiesPerWave {
-		// Wave chỉ hoàn thành khi tất cả quái đã bị tiêu diệt (được check ở game loop)
+	// Wave chỉ hoàn thành khi tất cả quái đã bị tiêu diệt (được check ở game loop)
+}
+
+// TakeDue trả về và xóa các lần spawn đã tới giờ
+func (wm *WaveManager) TakeDue() []SpawnEvent {
+	due := wm.Due
+	wm.Due = nil
+	return due
+}
+
+// IsBossWave kiểm tra wave hiện tại có boss không
+func (wm *WaveManager) IsBossWave() bool {
+	for _, ev := range wm.Schedule {
+		if ev.Boss {
+			return true
+		}
 	}
+	return false
 }
 
 // GetSpawnPosition trả về vị trí spawn quái ngẫu nhiên xung quanh player
-func (w *WaveManager) GetSpawnPosition(playerX, playerY float64) (float64, float64) {
+func (w *WaveManager) GetSpawnPosition(rng *rand.Rand, playerX, playerY float64) (float64, float64) {
 	// Góc ngẫu nhiên (0 đến 360 độ)
-	angle := rand.Float64() * 2 * math.Pi
+	angle := rng.Float64() * 2 * math.Pi
 
 	// Khoảng cách từ player (ví dụ: cách player từ 300 đến 500 pixel)
 	// Khoảng cách này phải lớn hơn một nửa màn hình để quái xuất hiện từ rìa
-	distance := 150.0 + rand.Float64()*100.0
+	distance := 150.0 + rng.Float64()*100.0
 
 	spawnX := playerX + math.Cos(angle)*distance
 	spawnY := playerY + math.Sin(angle)*distance
@@ -65,22 +88,74 @@ func (w *WaveManager) GetSpawnPosition(playerX, playerY float64) (float64, float
 	return spawnX, spawnY
 }
 
+// GetEdgePosition trả về vị trí ngẫu nhiên trên 1 trong 4 mép bản đồ
+func (w *WaveManager) GetEdgePosition(rng *rand.Rand) (float64, float64) {
+	t := rng.Float64()
+	switch rng.IntN(4) {
+	case 0: // Mép trên
+		return t * w.ScreenWidth, 0
+	case 1: // Mép dưới
+		return t * w.ScreenWidth, w.ScreenHeight
+	case 2: // Mép trái
+		return 0, t * w.ScreenHeight
+	default: // Mép phải
+		return w.ScreenWidth, t * w.ScreenHeight
+	}
+}
+
+// Clear bỏ lịch spawn của wave hiện tại (vd. phòng không có quái)
+func (wm *WaveManager) Clear() {
+	wm.Schedule = nil
+	wm.Due = nil
+	wm.EnemiesSpawned = 0
+	wm.EnemiesPerWave = 0
+}
+
 // StartNextWave bắt đầu wave tiếp theo
 func (wm *WaveManager) StartNextWave() {
 	wm.CurrentWave++
-	wm.EnemiesPerWave = 5 + wm.CurrentWave*2 // Tăng số quái mỗi wave
-	wm.EnemiesSpawned = 0
-	wm.WaveComplete = false
-	wm.SpawnTimer = 0.0
-	wm.SpawnInterval = math.Max(0.3, 1.0-float64(wm.CurrentWave)*0.05) // Spawn nhanh hơn theo wave
+	wm.startWave()
 }
 
 // Reset reset về wave 1
 func (wm *WaveManager) Reset() {
 	wm.CurrentWave = 1
-	wm.EnemiesPerWave = 5
+	wm.startWave()
+}
+
+// startWave lên lịch spawn cho CurrentWave: theo kịch bản nếu có, không thì theo công thức endless
+func (wm *WaveManager) startWave() {
 	wm.EnemiesSpawned = 0
 	wm.WaveComplete = false
-	wm.SpawnTimer = 0.0
-	wm.SpawnInterval = 1.0
+	wm.WaveTime = 0.0
+	wm.Due = nil
+
+	if def, ok := wm.Script.Wave(wm.CurrentWave); ok {
+		wm.Schedule = def.Schedule()
+	} else {
+		wm.Schedule = wm.endlessSchedule()
+	}
+	wm.EnemiesPerWave = len(wm.Schedule)
+}
+
+// endlessSchedule sinh lịch spawn theo công thức: wave 1 có 5 quái, mỗi wave sau thêm 2,
+// spawn nhanh dần và cứ BossEvery wave thì có 1 wave boss
+func (wm *WaveManager) endlessSchedule() []SpawnEvent {
+	wm.SpawnInterval = 1.0 // Spawn mỗi 1 giây
+	count := 5
+	if wm.CurrentWave > 1 {
+		count = 5 + wm.CurrentWave*2                                       // Tăng số quái mỗi wave
+		wm.SpawnInterval = math.Max(0.3, 1.0-float64(wm.CurrentWave)*0.05) // Spawn nhanh hơn theo wave
+	}
+
+	// Wave boss chỉ có đúng 1 con boss
+	if wm.BossEvery > 0 && wm.CurrentWave%wm.BossEvery == 0 {
+		return []SpawnEvent{{Time: wm.SpawnInterval, Boss: true, Where: SpawnAroundPlayer}}
+	}
+
+	events := make([]SpawnEvent, count)
+	for i := range events {
+		events[i] = SpawnEvent{Time: float64(i+1) * wm.SpawnInterval, Where: SpawnAroundPlayer}
+	}
+	return events
 }
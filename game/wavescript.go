@@ -0,0 +1,167 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Các vị trí spawn của 1 nhóm quái (giá trị "where" trong file wave)
+const (
+	SpawnAroundPlayer = "player" // Vòng quanh player (mặc định)
+	SpawnEdge         = "edge"   // Ngẫu nhiên trên mép bản đồ
+	SpawnPointName    = "point"  // Điểm spawn cố định lấy từ map
+)
+
+// maxGroupCount là số quái tối đa của 1 nhóm, Schedule tạo 1 lần spawn cho mỗi con
+const maxGroupCount = 1000
+
+// SpawnPoint là điểm spawn cố định trên map
+type SpawnPoint struct {
+	Name string
+	X, Y float64
+}
+
+// SpawnGroup là 1 nhóm quái cùng loại trong wave
+type SpawnGroup struct {
+	Archetype string  `json:"archetype"` // ID loại quái, bỏ trống = random theo SpawnWeight
+	Boss      string  `json:"boss"`      // ID boss (nhóm boss luôn chỉ có 1 con), "" = không phải boss
+	Count     *int    `json:"count"`
+	Delay     float64 `json:"delay"`    // Giây tính từ đầu wave tới con đầu tiên
+	Interval  float64 `json:"interval"` // Giây giữa 2 con liên tiếp
+	Where     string  `json:"where"`    // SpawnAroundPlayer, SpawnEdge hoặc SpawnPointName
+	Point     string  `json:"point"`    // Tên điểm spawn (where = "point"), bỏ trống = điểm ngẫu nhiên
+}
+
+// WaveDef là định nghĩa 1 wave trong file wave
+type WaveDef struct {
+	Groups []SpawnGroup `json:"groups"`
+}
+
+// WaveScript là kịch bản các wave đọc từ file.
+// Hết kịch bản thì WaveManager quay về công thức endless như cũ.
+type WaveScript struct {
+	Waves []WaveDef `json:"waves"`
+}
+
+// SpawnEvent là 1 lần spawn quái đã lên lịch trong wave
+type SpawnEvent struct {
+	Time      float64 // Giây tính từ đầu wave
+	Archetype string  // ID loại quái hoặc ID boss, "" = để World tự chọn
+	Boss      bool
+	Where     string
+	Point     string
+}
+
+// LoadWaveScript đọc file kịch bản wave (JSON)
+func LoadWaveScript(path string) (*WaveScript, error) {
+	contents, err := ReadAsset(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseWaveScript(path, contents)
+}
+
+// ParseWaveScript đọc kịch bản wave từ nội dung JSON đã có sẵn (ví dụ lưu trong replay),
+// path chỉ dùng để báo lỗi
+func ParseWaveScript(path string, contents []byte) (*WaveScript, error) {
+	var script WaveScript
+	if err := json.Unmarshal(contents, &script); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for i := range script.Waves {
+		for j := range script.Waves[i].Groups {
+			group := &script.Waves[i].Groups[j]
+			group.applyDefaults()
+			if err := group.validate(); err != nil {
+				return nil, fmt.Errorf("%s: wave %d, nhóm %d: %w", path, i+1, j+1, err)
+			}
+		}
+	}
+	return &script, nil
+}
+
+// applyDefaults điền giá trị mặc định cho các trường bị bỏ trống
+func (g *SpawnGroup) applyDefaults() {
+	if g.Where == "" {
+		g.Where = SpawnAroundPlayer
+	}
+	if g.Boss != "" || g.Count == nil {
+		one := 1
+		g.Count = &one
+	}
+}
+
+func (g *SpawnGroup) validate() error {
+	switch g.Where {
+	case SpawnAroundPlayer, SpawnEdge, SpawnPointName:
+	default:
+		return fmt.Errorf("where không hợp lệ %q", g.Where)
+	}
+	if g.Boss != "" && g.Archetype != "" {
+		return fmt.Errorf("không thể có cả archetype và boss")
+	}
+	if *g.Count < 1 || *g.Count > maxGroupCount {
+		return fmt.Errorf("count phải trong khoảng 1..%d", maxGroupCount)
+	}
+	if g.Delay < 0 || g.Interval < 0 {
+		return fmt.Errorf("delay và interval không được âm")
+	}
+	return nil
+}
+
+// Validate kiểm tra mọi ID loại quái và boss trong kịch bản đều có trong archetypes và bosses.
+// Map rỗng được coi như chỉ có loại mặc định, giống SetArchetypes và SetBosses.
+func (s *WaveScript) Validate(archetypes map[string]*EnemyArchetype, bosses map[string]*BossDef) error {
+	for i, wave := range s.Waves {
+		for j, g := range wave.Groups {
+			if err := g.validateIDs(archetypes, bosses); err != nil {
+				return fmt.Errorf("wave %d, nhóm %d: %w", i+1, j+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *SpawnGroup) validateIDs(archetypes map[string]*EnemyArchetype, bosses map[string]*BossDef) error {
+	if g.Archetype != "" && !hasArchetype(archetypes, g.Archetype) {
+		return fmt.Errorf("không có loại quái %q", g.Archetype)
+	}
+	if g.Boss != "" && !hasBoss(bosses, g.Boss) {
+		return fmt.Errorf("không có boss %q", g.Boss)
+	}
+	return nil
+}
+
+// Wave trả về định nghĩa của wave thứ n (bắt đầu từ 1), false nếu kịch bản không có wave đó
+func (s *WaveScript) Wave(n int) (WaveDef, bool) {
+	if s == nil || n < 1 || n > len(s.Waves) {
+		return WaveDef{}, false
+	}
+	return s.Waves[n-1], true
+}
+
+// Schedule trải các nhóm quái của wave thành lịch spawn theo thời gian tăng dần
+func (d WaveDef) Schedule() []SpawnEvent {
+	var events []SpawnEvent
+	for _, g := range d.Groups {
+		for i := 0; i < *g.Count; i++ {
+			ev := SpawnEvent{
+				Time:      g.Delay + float64(i)*g.Interval,
+				Archetype: g.Archetype,
+				Where:     g.Where,
+				Point:     g.Point,
+			}
+			if g.Boss != "" {
+				ev.Archetype = g.Boss
+				ev.Boss = true
+			}
+			events = append(events, ev)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time < events[j].Time
+	})
+	return events
+}
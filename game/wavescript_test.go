@@ -0,0 +1,141 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeAsset ghi contents vào file name trong thư mục tạm và trả về đường dẫn
+func writeAsset(tb testing.TB, name, contents string) string {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadWaveScriptCount(t *testing.T) {
+	tests := []struct {
+		name  string
+		group string
+		count int // 0 = file bị từ chối
+	}{
+		{"bỏ trống count", `{"archetype": "skeleton"}`, 1},
+		{"count khai báo", `{"archetype": "skeleton", "count": 4}`, 4},
+		{"count bằng 0", `{"archetype": "skeleton", "count": 0}`, 0},
+		{"count âm", `{"archetype": "skeleton", "count": -2}`, 0},
+		{"count tối đa", `{"archetype": "skeleton", "count": 1000}`, 1000},
+		{"count quá lớn", `{"archetype": "skeleton", "count": 1000000000}`, 0},
+		{"boss luôn 1 con", `{"boss": "skeleton_king", "count": 3}`, 1},
+	}
+	for _, tt := range tests {
+		path := writeAsset(t, "waves.json", `{"waves": [{"groups": [`+tt.group+`]}]}`)
+		script, err := LoadWaveScript(path)
+		if tt.count == 0 {
+			if err == nil {
+				t.Errorf("%s: không báo lỗi", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := len(script.Waves[0].Schedule()); got != tt.count {
+			t.Errorf("%s: lên lịch %d con, muốn %d", tt.name, got, tt.count)
+		}
+	}
+}
+
+// TestWaveErrorPosition: lỗi chỉ ra wave và nhóm đếm từ 1, trong file wave lẫn trong chapter
+func TestWaveErrorPosition(t *testing.T) {
+	const waves = `[{}, {"groups": [{}, {"count": 0}]}]`
+	_, err := LoadWaveScript(writeAsset(t, "waves.json", `{"waves": `+waves+`}`))
+	if err == nil || !strings.Contains(err.Error(), "wave 2, nhóm 2") {
+		t.Errorf("file wave: lỗi = %v, muốn ở wave 2, nhóm 2", err)
+	}
+
+	dir := t.TempDir()
+	mapPath, err := filepath.Abs("../assets/maps/spawn.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel, err := filepath.Rel(dir, mapPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chapterPath := filepath.Join(dir, "chapter.json")
+	contents := `{"rooms": [{"map": "` + filepath.ToSlash(rel) + `"}, {"map": "` + filepath.ToSlash(rel) + `", "waves": ` + waves + `}]}`
+	if err := os.WriteFile(chapterPath, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadChapter(chapterPath)
+	if err == nil || !strings.Contains(err.Error(), "phòng 2, wave 2, nhóm 2") {
+		t.Errorf("chapter: lỗi = %v, muốn ở phòng 2, wave 2, nhóm 2", err)
+	}
+}
+
+func TestWaveScriptValidate(t *testing.T) {
+	archetypes, err := LoadArchetypes("../assets/data/enemies.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bosses, err := LoadBosses("../assets/data/bosses.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Kịch bản và chapter đi kèm game phải khớp với dữ liệu quái, boss
+	script, err := LoadWaveScript("../assets/data/waves.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := script.Validate(archetypes, bosses); err != nil {
+		t.Error(err)
+	}
+	chapter, err := LoadChapter("../assets/data/chapter1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chapter.Validate(archetypes, bosses); err != nil {
+		t.Error(err)
+	}
+
+	tests := []struct {
+		name       string
+		group      SpawnGroup
+		archetypes map[string]*EnemyArchetype
+		bosses     map[string]*BossDef
+		want       string // "" = hợp lệ
+	}{
+		{"quái có thật", SpawnGroup{Archetype: "orc"}, archetypes, bosses, ""},
+		{"quái random", SpawnGroup{}, archetypes, bosses, ""},
+		{"sai tên quái", SpawnGroup{Archetype: "orcc"}, archetypes, bosses, `"orcc"`},
+		{"sai tên boss", SpawnGroup{Boss: "orc"}, archetypes, bosses, `"orc"`},
+		{"không có dữ liệu: quái mặc định", SpawnGroup{Archetype: DefaultArchetypeID}, nil, nil, ""},
+		{"không có dữ liệu: boss mặc định", SpawnGroup{Boss: DefaultBossID}, nil, nil, ""},
+		{"không có dữ liệu: quái khác", SpawnGroup{Archetype: "orc"}, nil, nil, `"orc"`},
+	}
+	for _, tt := range tests {
+		script := &WaveScript{Waves: []WaveDef{{}, {Groups: []SpawnGroup{{}, tt.group}}}}
+		err := script.Validate(tt.archetypes, tt.bosses)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), "wave 2, nhóm 2") {
+			t.Errorf("%s: lỗi = %v, muốn nhắc tới %s ở wave 2, nhóm 2", tt.name, err, tt.want)
+		}
+	}
+
+	// Chapter báo lỗi kèm file và phòng
+	chapter.Rooms[len(chapter.Rooms)-1].Waves = []WaveDef{{Groups: []SpawnGroup{{Boss: "khong_co"}}}}
+	err = chapter.Validate(archetypes, bosses)
+	if err == nil || !strings.Contains(err.Error(), chapter.Path) || !strings.Contains(err.Error(), "khong_co") {
+		t.Errorf("chapter có boss sai tên: lỗi = %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package game
+
+import (
+	"fmt"
+	"math"
+)
+
+// Các kiểu bắn của vũ khí quái
+const (
+	PatternAimed  = "aimed"  // 1 viên nhắm thẳng vào player
+	PatternSpread = "spread" // Count viên tỏa hình quạt quanh hướng player
+	PatternRing   = "ring"   // Count viên tỏa đều 360 độ
+)
+
+// SpriteEnemyProjectile là sprite mặc định của đạn quái
+const SpriteEnemyProjectile = "shuriken"
+
+// WeaponDef là định nghĩa vũ khí bắn xa của 1 loại quái
+type WeaponDef struct {
+	Pattern  string  `json:"pattern"`
+	Count    int     `json:"count"`    // Số viên mỗi lần bắn (spread, ring)
+	Spread   float64 `json:"spread"`   // Tổng góc tỏa (độ) của spread
+	Speed    float64 `json:"speed"`    // px mỗi giây
+	Damage   float64 `json:"damage"`   // Sát thương mỗi viên
+	Cooldown float64 `json:"cooldown"` // Giây giữa 2 lần bắn
+	Range    float64 `json:"range"`    // Chỉ bắn khi player trong tầm này
+	Sprite   string  `json:"sprite"`
+	Size     float64 `json:"size"` // Kích thước hitbox của viên đạn (px)
+}
+
+// applyDefaults điền giá trị mặc định cho các trường bị bỏ trống
+func (w *WeaponDef) applyDefaults() {
+	if w.Pattern == "" {
+		w.Pattern = PatternAimed
+	}
+	if w.Count == 0 {
+		w.Count = 1
+	}
+	if w.Speed == 0 {
+		w.Speed = 150
+	}
+	if w.Cooldown == 0 {
+		w.Cooldown = 2
+	}
+	if w.Range == 0 {
+		w.Range = 300
+	}
+	if w.Sprite == "" {
+		w.Sprite = SpriteEnemyProjectile
+	}
+	if w.Size == 0 {
+		w.Size = 8
+	}
+}
+
+func (w *WeaponDef) validate() error {
+	switch w.Pattern {
+	case PatternAimed, PatternSpread, PatternRing:
+	default:
+		return fmt.Errorf("pattern không hợp lệ %q", w.Pattern)
+	}
+	if w.Count < 1 {
+		return fmt.Errorf("count phải >= 1")
+	}
+	if w.Damage <= 0 {
+		return fmt.Errorf("damage phải > 0")
+	}
+	if w.Speed <= 0 || w.Cooldown <= 0 || w.Range <= 0 || w.Size <= 0 {
+		return fmt.Errorf("speed, cooldown, range và size phải > 0")
+	}
+	if w.Spread < 0 {
+		return fmt.Errorf("spread không được âm")
+	}
+	return nil
+}
+
+// Fire bắn 1 loạt đạn từ tâm (x, y) về phía (targetX, targetY) theo pattern của vũ khí
+func (w *WeaponDef) Fire(x, y, targetX, targetY float64) []*Projectile {
+	aim := math.Atan2(targetY-y, targetX-x)
+
+	var angles []float64
+	switch w.Pattern {
+	case PatternSpread:
+		spread := w.Spread * math.Pi / 180
+		if w.Count == 1 {
+			angles = append(angles, aim)
+			break
+		}
+		step := spread / float64(w.Count-1)
+		for i := 0; i < w.Count; i++ {
+			angles = append(angles, aim-spread/2+float64(i)*step)
+		}
+	case PatternRing:
+		step := 2 * math.Pi / float64(w.Count)
+		for i := 0; i < w.Count; i++ {
+			angles = append(angles, aim+float64(i)*step)
+		}
+	default:
+		angles = append(angles, aim)
+	}
+
+	shots := make([]*Projectile, 0, len(angles))
+	for _, angle := range angles {
+		shots = append(shots, NewProjectileAngle(w.Sprite, x, y, angle, w.Speed, w.Damage, w.Size, FactionEnemy))
+	}
+	return shots
+}
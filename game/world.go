@@ -0,0 +1,838 @@
+package game
+
+import (
+	"log"
+	"math"
+	"math/rand/v2"
+)
+
+// Trạng thái của World
+const (
+	StatePlaying = iota
+	StateSkillSelect
+	StateChapterComplete // Đã dọn xong phòng cuối của chapter, mô phỏng dừng lại
+)
+
+// Multishot bắn lặp lại sau mỗi khoảng trễ này (giây)
+const multishotDelay = 8.0 / 60.0
+
+// Input là ảnh chụp input của người chơi trong 1 bước mô phỏng.
+// World không tự đọc bàn phím, nên có thể chạy trong test hoặc không có cửa sổ.
+type Input struct {
+	MoveX, MoveY float64 // Hướng di chuyển mỗi trục (-1..1), chưa chuẩn hóa
+	SkillPick    int     // 1, 2, 3 khi chọn kỹ năng; 0 nếu không chọn
+	Reroll       bool    // Đổi bộ kỹ năng (R)
+	Interact     bool    // Tương tác, vd. cổng chuyển map (E)
+	OpenSkills   bool    // Mở menu kỹ năng (L, để test)
+}
+
+// IsMoving kiểm tra người chơi có đang giữ phím di chuyển không
+func (in Input) IsMoving() bool {
+	return in.MoveX != 0 || in.MoveY != 0
+}
+
+// Sprite ID mặc định của các entity, renderer tra ảnh theo các ID này
+const (
+	SpritePlayer     = "ninja"
+	SpriteEnemy      = "skeleton"
+	SpriteProjectile = "arrow"
+	SpritePotion     = "potion"
+)
+
+type delayedProjectile struct {
+	Delay   float64 // Thời gian chờ còn lại (giây)
+	TargetX float64
+	TargetY float64
+}
+
+// World là lõi mô phỏng của game: sở hữu player, quái, đạn, bình máu và wave.
+// World không vẽ và không đọc input trực tiếp, mỗi bước nhận 1 Input,
+// nên package game không phụ thuộc ebiten và chạy được trong go test không cần cửa sổ.
+type World struct {
+	Player       *Player
+	Enemies      []*Enemy
+	Dying        []*Enemy // Quái đã bị hạ, còn giữ lại để chạy hết animation chết (không còn va chạm)
+	Projectiles  []*Projectile
+	Potions      []*Potion
+	Wave         *WaveManager
+	Tilemap      *TilemapJSON
+	Collision    *CollisionGrid // Tường chặn di chuyển và đạn (nil nếu không có map)
+	Clock        *Clock
+	Archetypes   map[string]*EnemyArchetype // Các loại quái có thể spawn
+	Sprites      *SpriteLibrary             // Clip và sheet của các sprite (nil = mọi sprite là ảnh thường)
+	Bosses       map[string]*BossDef        // Các boss, lần lượt xuất hiện ở các wave boss
+	Boss         *Enemy                     // Boss đang đánh (nil nếu không có)
+	WaveScript   *WaveScript                // Kịch bản wave (nil = công thức endless)
+	Data         GameData                   // Nội dung file quái, boss, wave đang dùng (replay lưu kèm)
+	Seed         uint64                     // Seed của lượt chơi, cùng seed + cùng input => cùng kết quả
+	Rand         *rand.Rand                 // Nguồn random duy nhất của mô phỏng (spawn, drop, kỹ năng)
+	State        int                        // StatePlaying, StateSkillSelect hoặc StateChapterComplete
+	SkillOptions []Skill                    // Các kỹ năng đang hiển thị để chọn
+	MapWidth     float64
+	MapHeight    float64
+
+	// Các object khai báo trong object layer của map
+	SpawnPoints    []SpawnPoint   // Các điểm spawn cố định
+	Gates          []*Gate        // Các cổng chuyển map
+	Triggers       []*Trigger     // Các vùng trigger
+	Events         []TriggerEvent // Sự kiện trigger chưa được game xử lý
+	PlayerStartX   float64        // Điểm xuất phát của player (nếu HasPlayerStart)
+	PlayerStartY   float64
+	HasPlayerStart bool
+
+	// Chapter nhiều phòng (nil = chơi 1 map với wave endless)
+	Chapter     *ChapterDef
+	Room        int  // Chỉ số phòng đang chơi
+	RoomCleared bool // Đã dọn sạch wave của phòng, cổng mở
+	Stats       RunStats
+
+	delayedProjectiles []delayedProjectile
+
+	enemyGrid   *SpatialHash // Broadphase của quái còn sống, dựng lại mỗi bước sau khi quái di chuyển
+	gridEnemies []*Enemy     // Quái theo ID trong enemyGrid
+	gridHits    []int        // Bộ nhớ dùng lại cho kết quả truy vấn enemyGrid
+}
+
+// NewWorld tạo world mới trên tilemap cho trước với seed random
+func NewWorld(tilemap *TilemapJSON, seed uint64) *World {
+	w := &World{
+		Tilemap: tilemap,
+		Clock:   NewClock(DefaultTickRate),
+		Seed:    seed,
+		Rand:    NewRand(seed),
+	}
+	w.SetArchetypes(nil)
+	w.SetBosses(nil)
+	w.setTilemap(tilemap)
+	return w
+}
+
+// setTilemap đổi map của world: kích thước, object và lưới va chạm
+func (w *World) setTilemap(tilemap *TilemapJSON) {
+	w.Tilemap = tilemap
+	w.MapWidth, w.MapHeight = 0, 0
+	w.Collision = nil
+	if tilemap != nil {
+		w.MapWidth = float64(tilemap.Width * tilemap.TileW)
+		w.MapHeight = float64(tilemap.Height * tilemap.TileH)
+		w.Collision = NewCollisionGrid(tilemap, tilemap.TileCollides)
+	}
+	w.loadMapObjects()
+}
+
+// StreamChunks nạp data và va chạm của các chunk quanh vùng camera (x, y, width, height) pixel,
+// giải phóng chunk ở xa (map vô hạn). Map thường không làm gì.
+func (w *World) StreamChunks(x, y, width, height, margin float64) error {
+	if w.Tilemap == nil {
+		return nil
+	}
+	w.Collision.Stream(x, y, width, height, margin)
+	return w.Tilemap.StreamChunks(x, y, width, height, margin)
+}
+
+// SetSprites đặt thư viện sprite và gắn lại clip, khung va chạm cho player và quái đang có
+// (vd sau khi ảnh được load lại)
+func (w *World) SetSprites(sprites *SpriteLibrary) {
+	w.Sprites = sprites
+	if w.Player != nil {
+		w.fitPlayer(w.Player)
+	}
+	for _, list := range [][]*Enemy{w.Enemies, w.Dying} {
+		for _, e := range list {
+			w.fitEnemy(e)
+		}
+	}
+}
+
+func (w *World) fitPlayer(p *Player) {
+	w.fitSprite(p.Sprite, p.Anim, &p.X, &p.Y, &p.Width, &p.Height, &p.Fit)
+}
+
+func (w *World) fitEnemy(e *Enemy) {
+	w.fitSprite(e.Sprite, e.Anim, &e.X, &e.Y, &e.Width, &e.Height, &e.Fit)
+}
+
+// fitSprite gắn bộ clip của sprite vào anim và tính lại khung va chạm (x, y, width, height) theo sheet
+// của sprite. Khung đổi chiều cao thì giữ nguyên vị trí chân (giữa cạnh dưới khung).
+func (w *World) fitSprite(sprite string, anim *Animator, x, y, width, height *float64, fit *SpriteFit) {
+	if anim != nil {
+		anim.Clips = w.Sprites.Clips(sprite)
+	}
+	nw, nh, f := w.Sprites.Fit(sprite, *width, *height)
+	*x += (*width - nw) / 2
+	*y += *height - nh
+	*width, *height, *fit = nw, nh, f
+}
+
+// SetArchetypes đặt danh sách loại quái. Nếu rỗng thì dùng loại mặc định.
+func (w *World) SetArchetypes(archetypes map[string]*EnemyArchetype) {
+	if len(archetypes) == 0 {
+		def := DefaultArchetype()
+		archetypes = map[string]*EnemyArchetype{def.ID: def}
+	}
+	w.Archetypes = archetypes
+}
+
+// SetBosses đặt danh sách boss. Nếu rỗng thì dùng boss mặc định.
+func (w *World) SetBosses(bosses map[string]*BossDef) {
+	if len(bosses) == 0 {
+		def := DefaultBoss()
+		bosses = map[string]*BossDef{def.ID: def}
+	}
+	w.Bosses = bosses
+}
+
+// SetWaveScript đặt kịch bản wave, áp dụng luôn cho wave hiện tại
+func (w *World) SetWaveScript(script *WaveScript) {
+	w.WaveScript = script
+	if w.Wave != nil {
+		w.Wave.SetScript(script)
+	}
+}
+
+// NewRand tạo nguồn random xác định từ seed
+func NewRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+}
+
+// Reset đặt lại world với player cho trước (xóa quái, đạn, bình máu và wave).
+// Nguồn random cũng được tạo lại từ Seed để lượt chơi mới lặp lại được.
+// Khi chơi chapter thì quay lại phòng đầu tiên.
+func (w *World) Reset(player *Player) {
+	w.Rand = NewRand(w.Seed)
+	w.Player = player
+	w.fitPlayer(player)
+	w.Stats = RunStats{}
+	w.State = StatePlaying
+	if w.Chapter != nil {
+		w.enterRoom(0)
+		return
+	}
+
+	w.clearEntities()
+	for _, t := range w.Triggers {
+		t.Inside = false
+		t.Fired = false
+	}
+	w.Wave = NewWaveManager(w.MapWidth, w.MapHeight)
+	w.Wave.SetScript(w.WaveScript)
+}
+
+// clearEntities xóa quái, đạn, bình máu và sự kiện chưa xử lý
+func (w *World) clearEntities() {
+	w.Enemies = []*Enemy{}
+	w.Dying = nil
+	w.Potions = []*Potion{}
+	w.Projectiles = []*Projectile{}
+	w.Boss = nil
+	w.Events = nil
+	w.delayedProjectiles = nil
+	w.indexEnemies()
+}
+
+// Step chạy đúng 1 bước mô phỏng với input cho trước
+func (w *World) Step(in Input) {
+	// Nếu nhấn phím L thì hiện menu kỹ năng (để test)
+	if in.OpenSkills {
+		w.OpenSkillSelect()
+	}
+
+	switch w.State {
+	case StateSkillSelect:
+		w.handleSkillSelection(in) // Xử lý khi người chơi bấm 1, 2, 3
+	case StateChapterComplete:
+		// Màn hình kết quả, không mô phỏng gì thêm
+	default:
+		w.simulate(in)
+	}
+
+	w.Clock.Tick()
+}
+
+func (w *World) simulate(in Input) {
+	w.Stats.Time += w.Clock.DT()
+	w.handleTeleportGate(in)
+	w.handleMovement(in)
+	w.Player.Update(w.Clock)
+	w.updateTriggers()
+	w.Wave.Update(w.Clock)
+
+	w.spawnEnemiesIfNeeded()
+
+	pcx, pcy := w.Player.GetCenter()
+	ctx := &BehaviorContext{
+		DT:            w.Clock.DT(),
+		PlayerX:       w.Player.X,
+		PlayerY:       w.Player.Y,
+		PlayerCenterX: pcx,
+		PlayerCenterY: pcy,
+		MapWidth:      w.MapWidth,
+		MapHeight:     w.MapHeight,
+		Collision:     w.Collision,
+		Rand:          w.Rand,
+	}
+	for _, e := range w.Enemies {
+		e.Update(ctx)
+	}
+	w.indexEnemies()
+	w.handleContactDamage()
+	w.Projectiles = append(w.Projectiles, ctx.Shots...)
+	w.spawnSummons(ctx.Summons)
+
+	if !in.IsMoving() {
+		w.handleAutoAttack()
+	}
+	w.updateProjectiles()
+	w.updateDelayedProjectiles()
+	w.updateDying()
+	w.cleanupEntities()
+	w.handlePotions()
+
+	w.handleWaveComplete()
+}
+
+// OpenSkillSelect dừng mô phỏng và hiện menu chọn kỹ năng
+func (w *World) OpenSkillSelect() {
+	w.State = StateSkillSelect
+	w.randomizeSkillOptions()
+}
+
+func (w *World) handleSkillSelection(in Input) {
+	// Reroll khi nhấn R
+	if in.Reroll {
+		w.randomizeSkillOptions()
+	}
+
+	if in.SkillPick >= 1 && in.SkillPick <= len(w.SkillOptions) {
+		w.Player.LearnSkill(w.SkillOptions[in.SkillPick-1])
+		w.State = StatePlaying
+	}
+}
+
+func (w *World) randomizeSkillOptions() {
+	// Tạo bản sao danh sách skill để shuffle
+	shuffled := make([]Skill, len(AllSkills))
+	copy(shuffled, AllSkills)
+
+	// Shuffle
+	w.Rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	// Lấy 3 skill đầu tiên (hoặc ít hơn nếu tổng skill < 3)
+	count := 3
+	if len(shuffled) < 3 {
+		count = len(shuffled)
+	}
+	w.SkillOptions = shuffled[:count]
+}
+
+func (w *World) handleMovement(in Input) {
+	dx, dy := in.MoveX, in.MoveY
+	w.Player.Action.Moving = false
+
+	if dx != 0 || dy != 0 {
+		// Giữ nguyên chuẩn hóa (Normalization) để đi chéo không bị nhanh quá mức
+		norm := math.Sqrt(dx*dx + dy*dy)
+		dx /= norm
+		dy /= norm
+
+		w.Player.Move(w.Clock, dx, dy, w.MapWidth, w.MapHeight, w.Collision)
+	}
+}
+
+func (w *World) handlePotions() {
+	filteredPotions := w.Potions[:0]
+	for _, p := range w.Potions {
+		// Kiểm tra va chạm giữa Player và Potion
+		if w.Player.CheckCollision(p.X, p.Y, p.Width, p.Height) {
+			// Hồi máu cho player, không vượt quá MaxHealth
+			w.Player.Health += p.Heal
+			if w.Player.Health > w.Player.MaxHealth {
+				w.Player.Health = w.Player.MaxHealth
+			}
+			log.Println("Đã ăn bình máu! HP hiện tại:", w.Player.Health)
+			continue // Không thêm vào danh sách mới (tương đương với việc xóa)
+		}
+		filteredPotions = append(filteredPotions, p)
+	}
+	w.Potions = filteredPotions
+}
+
+// onEnemyKilled xử lý khi 1 con quái vừa bị hạ: boss thì trao thưởng, quái thường thì roll rơi đồ
+func (w *World) onEnemyKilled(e *Enemy) {
+	w.Stats.Kills++
+	if e == w.Boss {
+		w.Stats.BossesDefeated++
+		w.onBossDefeated(e)
+		return
+	}
+	w.rollDrops(e)
+}
+
+// onBossDefeated trao phần thưởng chắc chắn của boss và xóa quái con còn sót lại
+func (w *World) onBossDefeated(e *Enemy) {
+	boss := AsBoss(e)
+	w.Boss = nil
+	log.Printf("Đã hạ %s!", boss.Def.Name)
+
+	for _, other := range w.Enemies {
+		if other != e && other.IsAlive() {
+			other.TakeDamage(other.Health)
+		}
+	}
+
+	reward := boss.Def.Reward
+	cx, cy := e.GetCenter()
+	for i := 0; i < reward.Potions; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(reward.Potions)
+		w.spawnPotion(cx-8+math.Cos(angle)*24, cy-8+math.Sin(angle)*24)
+	}
+	if reward.Skill {
+		w.OpenSkillSelect()
+	}
+}
+
+// rollDrops roll bảng rơi đồ của quái vừa chết
+func (w *World) rollDrops(e *Enemy) {
+	drops := DefaultArchetype().Drops
+	if e.Archetype != nil {
+		drops = e.Archetype.Drops
+	}
+	for _, d := range drops {
+		if w.Rand.Float64() >= d.Chance {
+			continue
+		}
+		switch d.Item {
+		case DropPotion:
+			w.spawnPotion(e.X, e.Y)
+		}
+	}
+}
+
+func (w *World) spawnPotion(x, y float64) {
+	w.Potions = append(w.Potions, NewPotion(SpritePotion, x, y))
+}
+
+func (w *World) handleAutoAttack() {
+	target := w.FindNearestEnemy()
+	if target == nil || !w.Player.CanAttack() {
+		return
+	}
+
+	px, py := w.Player.GetCenter()
+	ex, ey := target.GetCenter()
+
+	// Logic bắn đạn chính (đã gộp cả Volley + Multishot)
+	w.fireAtTarget(ex, ey)
+
+	// Nếu có kỹ năng DiagonalArrow (Bắn chéo 3 tia)
+	if w.Player.HasSkill(DiagonalArrow) {
+		// 1. Tính góc hiện tại từ người chơi đến quái vật (Radian)
+		angle := math.Atan2(ey-py, ex-px)
+
+		// 2. Tính tọa độ mục tiêu giả định cho tia bên TRÁI (Lệch -30 độ)
+		angleLeft := angle - (math.Pi / 6)     // Pi/6 tương đương 30 độ
+		exLeft := px + math.Cos(angleLeft)*200 // 200 là tầm xa giả định để định hướng
+		eyLeft := py + math.Sin(angleLeft)*200
+		w.fireAtTarget(exLeft, eyLeft)
+
+		// 3. Tính tọa độ mục tiêu giả định cho tia bên PHẢI (Lệch +30 độ)
+		angleRight := angle + (math.Pi / 6)
+		exRight := px + math.Cos(angleRight)*200
+		eyRight := py + math.Sin(angleRight)*200
+		w.fireAtTarget(exRight, eyRight)
+	}
+
+	// Đánh dấu người chơi đã tấn công để tính cooldown (tốc độ đánh), quay mặt về phía quái
+	w.Player.Attack(ex-px, ey-py)
+}
+
+// fireAtTarget thực hiện quy trình bắn vào 1 điểm mục tiêu
+// Bao gồm: Bắn ngay lập tức (spawnVolley) + Lên lịch bắn trễ (Multishot)
+func (w *World) fireAtTarget(targetX, targetY float64) {
+	// 1. Bắn ngay lập tức (Xử lý cả ParallelShot bên trong spawnVolley)
+	w.spawnVolley(targetX, targetY)
+
+	// 2. Xử lý Multishot (Bắn lặp lại sau delay)
+	multiCount := w.Player.GetSkillCount(Multishot)
+	for i := 1; i <= multiCount; i++ {
+		w.delayedProjectiles = append(w.delayedProjectiles, delayedProjectile{
+			Delay:   float64(i) * multishotDelay,
+			TargetX: targetX,
+			TargetY: targetY,
+		})
+	}
+}
+
+func (w *World) updateDelayedProjectiles() {
+	activeDelayed := w.delayedProjectiles[:0]
+	for _, dp := range w.delayedProjectiles {
+		dp.Delay -= w.Clock.DT()
+		if dp.Delay <= 0 {
+			// Khi hết thời gian chờ, bắn volley (để áp dụng cả ParallelShot cho phát bắn trễ này)
+			w.spawnVolley(dp.TargetX, dp.TargetY)
+		} else {
+			activeDelayed = append(activeDelayed, dp)
+		}
+	}
+	w.delayedProjectiles = activeDelayed
+}
+
+// spawnProjectile bắn 1 viên đạn đơn (cơ bản)
+func (w *World) spawnProjectile(targetX, targetY float64) {
+	px, py := w.Player.GetCenter()
+	p := NewProjectile(SpriteProjectile, px-4, py-4, targetX, targetY, 270, w.Player.AttackDamage)
+
+	if w.Player.HasSkill(PiercingShot) {
+		p.IsPiercing = true
+	}
+
+	w.Projectiles = append(w.Projectiles, p)
+}
+
+// spawnVolley xử lý việc bắn đạn song song (ParallelShot)
+// Nếu không có skill ParallelShot, nó chỉ bắn 1 viên (gọi spawnProjectile)
+// Nếu có N skill, nó bắn N+1 viên song song
+func (w *World) spawnVolley(targetX, targetY float64) {
+	parallelCount := w.Player.GetSkillCount(ParallelShot)
+	if parallelCount == 0 {
+		w.spawnProjectile(targetX, targetY)
+		return
+	}
+
+	px, py := w.Player.GetCenter()
+	// Vector hướng
+	dx := targetX - px
+	dy := targetY - py
+	length := math.Hypot(dx, dy)
+	dx /= length
+	dy /= length
+
+	// Vector vuông góc
+	perpX := -dy
+	perpY := dx
+
+	// Tổng số đạn = 1 (gốc) + parallelCount
+	ctx := parallelCount + 1
+	spacing := 5.0 // Khoảng cách giữa các viên đạn
+
+	// Tính toán vị trí bắt đầu để chùm đạn cân đối ở giữa
+	// Ví dụ: 2 viên -> offset -5 và +5
+	// 3 viên -> offset -10, 0, +10
+	startOffset := -(float64(ctx-1) * spacing) / 2.0
+
+	for i := 0; i < ctx; i++ {
+		offset := startOffset + float64(i)*spacing
+
+		// Tọa độ bắn ra (offset theo vector vuông góc)
+		spawnX := px + perpX*offset
+		spawnY := py + perpY*offset
+
+		// Tọa độ đích cũng phải offset tương ứng để đạn bay song song
+		destX := targetX + perpX*offset
+		destY := targetY + perpY*offset
+
+		p := NewProjectile(
+			SpriteProjectile,
+			spawnX-4, spawnY-4, // Trừ 4 để căn giữa tâm đạn
+			destX, destY,
+			270,
+			w.Player.AttackDamage,
+		)
+		if w.Player.HasSkill(PiercingShot) {
+			p.IsPiercing = true
+		}
+		w.Projectiles = append(w.Projectiles, p)
+	}
+}
+
+func (w *World) updateProjectiles() {
+	for _, p := range w.Projectiles {
+		p.Update(w.Clock, w.MapWidth, w.MapHeight)
+		if !p.Active {
+			continue
+		}
+
+		// Đạn chạm tường thì mất (xét tâm viên đạn cho khỏi vướng mép tường), bay qua hố thì không sao
+		if w.Collision.BlocksShot(p.X+p.Width/2, p.Y+p.Height/2) {
+			p.Active = false
+			continue
+		}
+
+		// Đạn quái chỉ trúng player
+		if p.Faction == FactionEnemy {
+			if w.Player.CheckCollision(p.X, p.Y, p.Width, p.Height) {
+				w.Player.TakeDamage(p.Damage)
+				p.Active = false
+			}
+			continue
+		}
+
+		// Trúng quái đầu tiên (theo thứ tự trong Enemies) còn sống
+		id, hit := w.enemyGrid.First(p.X, p.Y, p.Width, p.Height, func(id int) bool {
+			return w.gridEnemies[id].IsAlive()
+		})
+		if hit {
+			e := w.gridEnemies[id]
+			e.TakeDamage(p.Damage)
+			p.Active = false
+			if !e.IsAlive() {
+				w.onEnemyKilled(e)
+			}
+		}
+	}
+}
+
+func (w *World) cleanupEntities() {
+	filteredProj := w.Projectiles[:0]
+	for _, p := range w.Projectiles {
+		if p.Active {
+			filteredProj = append(filteredProj, p)
+		}
+	}
+	w.Projectiles = filteredProj
+
+	filteredEnemies := w.Enemies[:0]
+	for _, e := range w.Enemies {
+		if e.IsAlive() {
+			filteredEnemies = append(filteredEnemies, e)
+		} else if e.Anim != nil {
+			e.Anim.Play(ClipDeath)
+			w.Dying = append(w.Dying, e)
+		}
+	}
+	w.Enemies = filteredEnemies
+}
+
+// updateDying chạy animation chết của quái đã bị hạ, chạy xong thì bỏ hẳn
+func (w *World) updateDying() {
+	filtered := w.Dying[:0]
+	for _, e := range w.Dying {
+		if e.UpdateDeath(w.Clock.DT()) {
+			filtered = append(filtered, e)
+		}
+	}
+	w.Dying = filtered
+}
+
+func (w *World) spawnEnemiesIfNeeded() {
+	// mỗi lần spawn tới giờ theo lịch của wave, thêm enemy mới
+	for _, ev := range w.Wave.TakeDue() {
+		if ev.Boss {
+			w.spawnBoss(ev)
+			continue
+		}
+		a, ok := w.Archetypes[ev.Archetype]
+		if !ok {
+			a = w.pickArchetype()
+		}
+		width, height, _ := w.Sprites.Fit(a.Sprite, a.Width, a.Height)
+		x, y := w.spawnPosition(ev, width, height)
+		w.addEnemy(a.NewEnemy(x, y), width, height)
+	}
+}
+
+// addEnemy thêm quái vừa sinh vào world với khung va chạm width x height đã tính theo sprite
+// (xem SpriteLibrary.Fit)
+func (w *World) addEnemy(e *Enemy, width, height float64) *Enemy {
+	e.Width, e.Height = width, height
+	w.fitEnemy(e)
+	w.Enemies = append(w.Enemies, e)
+	return e
+}
+
+// spawnBoss tạo boss theo lịch spawn. Nếu lịch không chỉ định boss
+// thì các boss lần lượt xuất hiện theo thứ tự ID.
+func (w *World) spawnBoss(ev SpawnEvent) {
+	def, ok := w.Bosses[ev.Archetype]
+	if !ok {
+		ids := SortedBossIDs(w.Bosses)
+		n := w.Wave.CurrentWave - 1
+		if w.Wave.BossEvery > 0 {
+			n = w.Wave.CurrentWave/w.Wave.BossEvery - 1
+		}
+		def = w.Bosses[ids[max(n, 0)%len(ids)]]
+	}
+
+	width, height, _ := w.Sprites.Fit(def.Sprite, def.Width, def.Height)
+	x, y := w.spawnPosition(ev, width, height)
+	w.Boss = w.addEnemy(def.NewEnemy(x, y), width, height)
+	log.Printf("Boss %s xuất hiện!", def.Name)
+}
+
+// spawnAttempts là số lần thử tìm chỗ spawn không dính tường
+const spawnAttempts = 8
+
+// spawnPosition chọn vị trí spawn theo ev.Where.
+// Vị trí luôn được kéo vào trong bản đồ và tránh tường,
+// quái kẹt ngoài bản đồ hoặc trong tường không đi được và làm kẹt wave.
+func (w *World) spawnPosition(ev SpawnEvent, width, height float64) (float64, float64) {
+	var x, y float64
+	for i := 0; i < spawnAttempts; i++ {
+		x, y = w.spawnCandidate(ev, width, height)
+		if !w.Collision.Overlaps(x, y, width, height) {
+			return x, y
+		}
+	}
+
+	// Không tìm được chỗ trống: dùng điểm spawn hoặc điểm xuất phát của map
+	switch {
+	case len(w.SpawnPoints) > 0:
+		x, y = w.pickSpawnPoint("")
+	case w.HasPlayerStart:
+		x, y = w.PlayerStartX, w.PlayerStartY
+	default:
+		return x, y
+	}
+	return w.clampToMap(x-width/2, y-height/2, width, height)
+}
+
+// spawnCandidate chọn 1 vị trí spawn ngẫu nhiên theo ev.Where (chưa xét tường)
+func (w *World) spawnCandidate(ev SpawnEvent, width, height float64) (float64, float64) {
+	var x, y float64
+	switch {
+	case ev.Where == SpawnEdge:
+		x, y = w.Wave.GetEdgePosition(w.Rand)
+	case ev.Where == SpawnPointName && len(w.SpawnPoints) > 0:
+		// Điểm spawn là tâm của quái
+		x, y = w.pickSpawnPoint(ev.Point)
+		x -= width / 2
+		y -= height / 2
+	default:
+		x, y = w.Wave.GetSpawnPosition(w.Rand, w.Player.X, w.Player.Y)
+	}
+	return w.clampToMap(x, y, width, height)
+}
+
+// pickSpawnPoint trả về điểm spawn theo tên, hoặc 1 điểm ngẫu nhiên nếu tên trống/không tồn tại
+func (w *World) pickSpawnPoint(name string) (float64, float64) {
+	if name != "" {
+		for _, sp := range w.SpawnPoints {
+			if sp.Name == name {
+				return sp.X, sp.Y
+			}
+		}
+	}
+	sp := w.SpawnPoints[w.Rand.IntN(len(w.SpawnPoints))]
+	return sp.X, sp.Y
+}
+
+// spawnSummons tạo quái con theo yêu cầu của boss
+func (w *World) spawnSummons(summons []SummonRequest) {
+	for _, s := range summons {
+		a, ok := w.Archetypes[s.Archetype]
+		if !ok {
+			a = w.pickArchetype()
+		}
+		width, height, _ := w.Sprites.Fit(a.Sprite, a.Width, a.Height)
+		x, y := w.clampToMap(s.X-width/2, s.Y-height/2, width, height)
+		w.indexEnemy(w.addEnemy(a.NewEnemy(x, y), width, height))
+	}
+}
+
+// pickArchetype chọn ngẫu nhiên 1 loại quái theo SpawnWeight
+func (w *World) pickArchetype() *EnemyArchetype {
+	ids := SortedArchetypeIDs(w.Archetypes)
+	total := 0.0
+	for _, id := range ids {
+		total += w.Archetypes[id].SpawnWeight
+	}
+	if total <= 0 {
+		if a, ok := w.Archetypes[DefaultArchetypeID]; ok {
+			return a
+		}
+		return w.Archetypes[ids[0]]
+	}
+
+	roll := w.Rand.Float64() * total
+	for _, id := range ids {
+		roll -= w.Archetypes[id].SpawnWeight
+		if roll < 0 {
+			return w.Archetypes[id]
+		}
+	}
+	return w.Archetypes[ids[len(ids)-1]]
+}
+
+// FindNearestEnemy trả về quái còn sống gần player nhất (nil nếu không có).
+// Cùng khoảng cách thì chọn quái đứng trước trong Enemies.
+func (w *World) FindNearestEnemy() *Enemy {
+	if w.enemyGrid == nil {
+		w.indexEnemies()
+	}
+	px, py := w.Player.GetCenter()
+	id, ok := w.enemyGrid.Nearest(px, py, func(id int) (float64, bool) {
+		e := w.gridEnemies[id]
+		return e.GetDistanceTo(px, py), e.IsAlive()
+	})
+	if !ok {
+		return nil
+	}
+	return w.gridEnemies[id]
+}
+
+// indexEnemies dựng lại lưới quái còn sống theo vị trí hiện tại.
+// ID trong lưới theo thứ tự của Enemies nên truy vấn giữ đúng thứ tự duyệt như trước.
+func (w *World) indexEnemies() {
+	if w.enemyGrid == nil {
+		w.enemyGrid = NewSpatialHash(w.MapWidth, w.MapHeight, DefaultCellSize)
+	} else {
+		w.enemyGrid.Reset(w.MapWidth, w.MapHeight)
+	}
+	clear(w.gridEnemies)
+	w.gridEnemies = w.gridEnemies[:0]
+	for _, e := range w.Enemies {
+		if e.IsAlive() {
+			w.indexEnemy(e)
+		}
+	}
+}
+
+// indexEnemy thêm 1 quái vừa spawn vào lưới
+func (w *World) indexEnemy(e *Enemy) {
+	if w.enemyGrid == nil {
+		return
+	}
+	w.enemyGrid.Insert(e.X, e.Y, e.Width, e.Height)
+	w.gridEnemies = append(w.gridEnemies, e)
+}
+
+// handleContactDamage trừ máu player theo sát thương mỗi giây của mọi quái đang chạm vào player
+func (w *World) handleContactDamage() {
+	p := w.Player
+	w.gridHits = w.enemyGrid.Query(p.X, p.Y, p.Width, p.Height, w.gridHits[:0])
+	for _, id := range w.gridHits {
+		if e := w.gridEnemies[id]; e.IsAlive() {
+			p.TakeDamage(e.Damage * w.Clock.DT())
+		}
+	}
+}
+
+// handleWaveComplete sang wave kế tiếp khi wave hiện tại đã spawn hết và không còn quái.
+// Khi chơi chapter, xong wave cuối của phòng thì phòng được dọn sạch thay vì sang wave endless.
+func (w *World) handleWaveComplete() {
+	if w.Wave.WaveComplete {
+		return
+	}
+	if w.Wave.EnemiesSpawned < w.Wave.EnemiesPerWave || len(w.Enemies) > 0 {
+		return
+	}
+	if room := w.CurrentRoom(); room != nil && w.Wave.CurrentWave >= len(room.Waves) {
+		// Boss cuối vừa cho chọn kỹ năng: chọn xong mới dọn phòng, kẻo màn hình kết quả chapter đè mất phần thưởng
+		if w.State == StateSkillSelect {
+			return
+		}
+		w.Wave.WaveComplete = true
+		w.onRoomCleared()
+		return
+	}
+	w.Wave.StartNextWave()
+}
+
+// clampToMap kéo vị trí (góc trên trái) của vật thể kích thước (width, height) vào trong bản đồ
+func (w *World) clampToMap(x, y, width, height float64) (float64, float64) {
+	x = math.Max(0, math.Min(x, w.MapWidth-width))
+	y = math.Max(0, math.Min(y, w.MapHeight-height))
+	return x, y
+}
@@ -0,0 +1,196 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// readTestData đọc nội dung các file quái, boss, wave trong assets
+func readTestData(tb testing.TB) GameData {
+	tb.Helper()
+	var data GameData
+	for _, f := range []struct {
+		path string
+		dst  *[]byte
+	}{
+		{"../assets/data/enemies.json", &data.Enemies},
+		{"../assets/data/bosses.json", &data.Bosses},
+		{"../assets/data/waves.json", &data.Waves},
+	} {
+		contents, err := os.ReadFile(f.path)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		*f.dst = contents
+	}
+	return data
+}
+
+// parseTestData đọc quái, boss, wave từ nội dung file
+func parseTestData(tb testing.TB, data GameData) (map[string]*EnemyArchetype, map[string]*BossDef, *WaveScript) {
+	tb.Helper()
+	archetypes, err := ParseArchetypes("enemies.json", data.Enemies)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	bosses, err := ParseBosses("bosses.json", data.Bosses)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	script, err := ParseWaveScript("waves.json", data.Waves)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return archetypes, bosses, script
+}
+
+// newTestWorld tạo world như game thật: map spawn, dữ liệu quái, boss, wave (và chapter nếu có)
+// trong assets, player đứng ở điểm xuất phát của map
+func newTestWorld(tb testing.TB, seed uint64, chapterPath string) *World {
+	tb.Helper()
+	data := readTestData(tb)
+	archetypes, bosses, script := parseTestData(tb, data)
+	var (
+		chapter *ChapterDef
+		err     error
+	)
+	if chapterPath != "" {
+		if chapter, err = LoadChapter(chapterPath); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	w := NewWorld(loadTestTilemap(tb), seed)
+	w.SetArchetypes(archetypes)
+	w.SetBosses(bosses)
+	w.SetWaveScript(script)
+	w.Data = data
+	w.SetChapter(chapter)
+	w.Reset(NewPlayer(SpritePlayer, w.PlayerStartX-8, w.PlayerStartY-8, 1e5, DefaultPlayerSpeed, 20, 1))
+	return w
+}
+
+// scriptedInputs sinh n input giả lập người chơi: đổi hướng chạy sau mỗi nửa giây, thỉnh thoảng
+// đứng lại để bắn, chọn kỹ năng và tương tác với cổng
+func scriptedInputs(n int, seed uint64) []Input {
+	rng := NewRand(seed)
+	inputs := make([]Input, n)
+	var cur Input
+	for i := range inputs {
+		if i%30 == 0 {
+			cur = Input{}
+			if rng.IntN(3) > 0 {
+				cur.MoveX = float64(rng.IntN(3) - 1)
+				cur.MoveY = float64(rng.IntN(3) - 1)
+			}
+		}
+		in := cur
+		if i%45 == 0 {
+			in.SkillPick = 1 + rng.IntN(3)
+		}
+		in.Interact = i%120 == 0
+		inputs[i] = in
+	}
+	return inputs
+}
+
+// worldSnapshot ghi trạng thái mô phỏng thành chuỗi để so 2 world
+func worldSnapshot(w *World) string {
+	var b strings.Builder
+	p := w.Player
+	fmt.Fprintf(&b, "frame %d state %d room %d wave %d stats %+v\n", w.Clock.Frame, w.State, w.Room, w.Wave.CurrentWave, w.Stats)
+	fmt.Fprintf(&b, "player %v %v %v %v %v\n", p.X, p.Y, p.Health, p.AttackDamage, p.AttackSpeed)
+	for _, e := range w.Enemies {
+		fmt.Fprintf(&b, "enemy %s %v %v %v\n", e.Sprite, e.X, e.Y, e.Health)
+	}
+	if w.Boss != nil {
+		fmt.Fprintf(&b, "boss %s %v %v %v\n", w.Boss.Sprite, w.Boss.X, w.Boss.Y, w.Boss.Health)
+	}
+	for _, pr := range w.Projectiles {
+		fmt.Fprintf(&b, "shot %v %v %v\n", pr.X, pr.Y, pr.Faction)
+	}
+	for _, po := range w.Potions {
+		fmt.Fprintf(&b, "potion %v %v\n", po.X, po.Y)
+	}
+	for _, s := range w.SkillOptions {
+		fmt.Fprintf(&b, "skill %v\n", s.Type)
+	}
+	return b.String()
+}
+
+// TestWorldDeterministic chạy 2 world cùng seed với cùng chuỗi input, trạng thái phải giống hệt nhau ở mọi bước
+func TestWorldDeterministic(t *testing.T) {
+	for _, chapter := range []string{"", "../assets/data/chapter1.json"} {
+		const steps = 60 * 60
+		a := newTestWorld(t, 42, chapter)
+		b := newTestWorld(t, 42, chapter)
+		inputs := scriptedInputs(steps, 7)
+		maxEnemies, shots := 0, 0
+		for i, in := range inputs {
+			a.Step(in)
+			b.Step(in)
+			if sa, sb := worldSnapshot(a), worldSnapshot(b); sa != sb {
+				t.Fatalf("chapter %q: bước %d khác nhau:\n%s\n---\n%s", chapter, i, sa, sb)
+			}
+			maxEnemies = max(maxEnemies, len(a.Enemies))
+			shots = max(shots, len(a.Projectiles))
+		}
+		// Đảm bảo mô phỏng thật sự có quái và đạn, không so 2 world trống
+		if maxEnemies == 0 || shots == 0 || a.Stats.Kills == 0 {
+			t.Errorf("chapter %q: mô phỏng quá ít (quái %d, đạn %d, hạ %d)", chapter, maxEnemies, shots, a.Stats.Kills)
+		}
+
+		// Seed khác phải cho lượt chơi khác
+		c := newTestWorld(t, 43, chapter)
+		for _, in := range inputs {
+			c.Step(in)
+		}
+		if worldSnapshot(a) == worldSnapshot(c) {
+			t.Errorf("chapter %q: seed 42 và 43 cho cùng kết quả", chapter)
+		}
+	}
+}
+
+// TestBossRewardInFinalRoom hạ boss có thưởng kỹ năng ở wave cuối của phòng cuối:
+// người chơi phải được chọn kỹ năng trước khi chapter kết thúc
+func TestBossRewardInFinalRoom(t *testing.T) {
+	boss := DefaultBoss()
+	boss.Reward = BossReward{Skill: true}
+	one := 1
+	chapter := &ChapterDef{
+		Name: "test",
+		Rooms: []*RoomDef{{
+			Waves:   []WaveDef{{Groups: []SpawnGroup{{Boss: boss.ID, Count: &one}}}},
+			Tilemap: loadTestTilemap(t),
+		}},
+	}
+
+	w := NewWorld(loadTestTilemap(t), 1)
+	w.SetBosses(map[string]*BossDef{boss.ID: boss})
+	w.SetChapter(chapter)
+	w.Reset(NewPlayer(SpritePlayer, w.PlayerStartX-8, w.PlayerStartY-8, 1e9, DefaultPlayerSpeed, 20, 1))
+	for i := 0; i < 60*60 && w.State == StatePlaying; i++ {
+		if w.Boss != nil {
+			w.Boss.Health = 1 // Phát bắn đầu tiên trúng là hạ
+		}
+		w.Step(Input{})
+	}
+	if w.State != StateSkillSelect || w.Stats.BossesDefeated != 1 {
+		t.Fatalf("sau khi hạ boss: state %d, hạ %d boss, muốn đang chọn kỹ năng", w.State, w.Stats.BossesDefeated)
+	}
+	if w.RoomCleared {
+		t.Error("phòng đã dọn xong trong lúc còn chọn kỹ năng")
+	}
+
+	skills := len(w.Player.Skills)
+	w.Step(Input{SkillPick: 1})
+	w.Step(Input{})
+	if len(w.Player.Skills) != skills+1 {
+		t.Error("chưa nhận được kỹ năng thưởng")
+	}
+	if w.State != StateChapterComplete || !w.RoomCleared {
+		t.Errorf("chọn kỹ năng xong: state %d, muốn hoàn thành chapter", w.State)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	g "pixcel-game/game"
+	"pixcel-game/render"
+	"pixcel-game/systems"
+)
+
+// enableHotReload bật chế độ dev: theo dõi thư mục assets trong dir (thư mục đang làm nguồn asset)
+func (gme *ArcheroGame) enableHotReload(dir string) error {
+	watcher, err := systems.NewFileWatcher(dir, assetsBase)
+	if err != nil {
+		return err
+	}
+	gme.watcher = watcher
+	log.Printf("hot reload: dang theo doi %s", watcher.Dir)
+	return nil
+}
+
+// handleHotReload load lại các asset vừa thay đổi trên đĩa vào game đang chạy.
+// Player, quái, đạn và tiến độ wave được giữ nguyên; asset lỗi thì giữ bản cũ và chỉ ghi log.
+func (gme *ArcheroGame) handleHotReload(elapsed float64) {
+	if gme.watcher == nil {
+		return
+	}
+	changed := gme.watcher.Update(elapsed)
+	if len(changed) == 0 {
+		return
+	}
+	log.Printf("hot reload: %s", strings.Join(changed, ", "))
+
+	var data, maps, images bool
+	for _, p := range changed {
+		switch {
+		case p == g.AssetPath(chapterPath):
+			// Chapter chứa map của các phòng nên load lại như map
+			maps = true
+		case strings.HasPrefix(p, assetsBase+"/data/"):
+			data = true
+		case strings.HasPrefix(p, assetsBase+"/maps/"):
+			maps = true
+		case strings.HasPrefix(p, assetsBase+"/images/"):
+			images = true
+		}
+	}
+	if data {
+		gme.reloadData()
+	}
+	if maps {
+		gme.reloadMaps()
+	}
+	// Data và map mới có thể dùng sprite hoặc tileset chưa load
+	if data || maps || images {
+		gme.reloadImages()
+	}
+}
+
+// reloadData load lại định nghĩa quái, boss và kịch bản wave. Quái đang sống giữ chỉ số cũ,
+// quái spawn sau dùng định nghĩa mới. Nội dung file mới cũng thay vào world.Data để replay ghi sau đó khớp.
+func (gme *ArcheroGame) reloadData() {
+	world := gme.world
+	if contents, err := g.ReadAsset(enemiesPath); err != nil {
+		log.Printf("hot reload: %v", err)
+	} else if archetypes, err := g.ParseArchetypes(enemiesPath, contents); err != nil {
+		log.Printf("hot reload: %v", err)
+	} else {
+		world.SetArchetypes(archetypes)
+		world.Data.Enemies = contents
+	}
+	if contents, err := g.ReadAsset(bossesPath); err != nil {
+		log.Printf("hot reload: %v", err)
+	} else if bosses, err := g.ParseBosses(bossesPath, contents); err != nil {
+		log.Printf("hot reload: %v", err)
+	} else if err := g.ValidateMinions(bosses, world.Archetypes); err != nil {
+		log.Printf("hot reload: %s: %v", bossesPath, err)
+	} else {
+		world.SetBosses(bosses)
+		world.Data.Bosses = contents
+	}
+	if contents, err := g.ReadAsset(wavesPath); err != nil {
+		log.Printf("hot reload: %v", err)
+	} else if script, err := g.ParseWaveScript(wavesPath, contents); err != nil {
+		log.Printf("hot reload: %v", err)
+	} else if err := script.Validate(world.Archetypes, world.Bosses); err != nil {
+		log.Printf("hot reload: %s: %v", wavesPath, err)
+	} else {
+		world.ReloadWaveScript(script)
+		world.Data.Waves = contents
+	}
+}
+
+// reloadMaps load lại map đang chơi (hoặc cả chapter) mà không đưa player về điểm xuất phát
+func (gme *ArcheroGame) reloadMaps() {
+	world := gme.world
+	if world.Chapter != nil {
+		chapter, err := g.LoadChapter(world.Chapter.Path)
+		if err == nil {
+			err = chapter.Validate(world.Archetypes, world.Bosses)
+		}
+		if err != nil {
+			log.Printf("hot reload: %v", err)
+			return
+		}
+		world.ReloadChapter(chapter)
+	} else if world.Tilemap != nil {
+		tilemap, err := g.LoadTilemap(world.Tilemap.Path)
+		if err != nil {
+			log.Printf("hot reload: %v", err)
+			return
+		}
+		world.ReloadTilemap(tilemap)
+	}
+	// Cùng phòng nên camera không cần nhảy như khi chuyển map
+	gme.tilemap = world.Tilemap
+	gme.renderer.InvalidateTileCache()
+}
+
+// reloadImages load lại toàn bộ ảnh và sprite sheet vào bộ mới, chỉ thay bộ cũ khi đã build
+// thành công (lỗi thì ảnh, clip và khung va chạm cũ được giữ nguyên)
+func (gme *ArcheroGame) reloadImages() {
+	world := gme.world
+	assets := render.NewAssets(assetFS)
+	sprites := g.NewSpriteLibrary()
+	loadImages(assets, sprites, world.Tilemap, world.Chapter, world.Archetypes, world.Bosses)
+	if err := assets.Build(); err != nil {
+		log.Printf("hot reload: giu anh cu, thieu asset:\n%v", err)
+		assets.Deallocate()
+		return
+	}
+	gme.assets.Deallocate()
+	gme.assets = assets
+	gme.renderer.UseAssets(assets)
+	gme.renderer.InvalidateTileCache()
+	world.SetSprites(sprites)
+}
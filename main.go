@@ -1,12 +1,17 @@
 package main
 
 import (
+	"archive/zip"
+	"embed"
+	"flag"
 	"fmt"
 	"image/color"
+	"io/fs"
 	"log"
-	"math"
-	"math/rand/v2"
+	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
@@ -14,6 +19,7 @@ import (
 
 	"pixcel-game/game" // alias để dùng constant skill
 	g "pixcel-game/game"
+	"pixcel-game/render"
 	"pixcel-game/systems"
 )
 
@@ -25,58 +31,51 @@ const (
 // đường dẫn assets lấy từ dự án rpg-in-golang
 const assetsBase = "assets"
 
-const TeleportGateTileID = 159 // có thể cần đổi lại đúng tile cổng trong map sau
+// embeddedAssets là thư mục assets được nhúng vào binary, dùng khi không truyền -assets
+//
+//go:embed assets
+var embeddedAssets embed.FS
 
-const (
-	StatePlaying = iota
-	StateSkillSelect
-)
+// assetFS là nguồn asset của game (embed, thư mục trên đĩa hoặc bundle zip)
+var assetFS fs.FS = embeddedAssets
 
-type ArcheroGame struct {
-	player              *g.Player
-	enemies             []*g.Enemy
-	projectiles         []*g.Projectile
-	potions             []*Potion
-	wave                *g.WaveManager
-	camera              *systems.Camera
-	tilemap             *g.TilemapJSON
-	tilesetImg          *ebiten.Image
-	playerImg           *ebiten.Image
-	enemyImg            *ebiten.Image
-	projectileImg       *ebiten.Image
-	potionImg           *ebiten.Image
-	saveData            *systems.GameData
-	mapWidthPx          float64
-	mapHeightPx         float64
-	gameState           int          // Lưu trạng thái hiện tại
-	currentSkillOptions []game.Skill // Các kỹ năng đang hiển thị để chọn
-	delayedProjectiles  []DelayedProjectile
-}
+// map bắt đầu của game
+var spawnMapPath = filepath.Join(assetsBase, "maps", "spawn.json")
 
-type DelayedProjectile struct {
-	DelayFrames int
-	TargetX     float64
-	TargetY     float64
-}
+// file định nghĩa các loại quái
+var enemiesPath = filepath.Join(assetsBase, "data", "enemies.json")
 
-type Potion struct {
-	X, Y   float64
-	Img    *ebiten.Image
-	Width  float64
-	Height float64
-}
+// file định nghĩa các boss
+var bossesPath = filepath.Join(assetsBase, "data", "bosses.json")
 
-func (p *Potion) draw(screen *ebiten.Image, cameraX, cameraY float64) {
-	if p.Img == nil {
-		return
-	}
-	opts := &ebiten.DrawImageOptions{}
-	opts.GeoM.Translate(p.X-cameraX, p.Y-cameraY)
-	screen.DrawImage(p.Img, opts)
-}
+// file kịch bản wave (hết kịch bản thì dùng công thức endless)
+var wavesPath = filepath.Join(assetsBase, "data", "waves.json")
+
+// file chapter (chuỗi phòng), rỗng = chơi 1 map với wave endless
+var chapterPath = filepath.Join(assetsBase, "data", "chapter1.json")
 
-func NewArcheroGame() *ArcheroGame {
+type ArcheroGame struct {
+	world        *g.World
+	renderer     *render.Renderer
+	camera       *systems.Camera
+	saveData     *systems.GameData
+	pendingInput g.Input         // Input đã đọc nhưng chưa được bước mô phỏng nào tiêu thụ
+	lastUpdate   time.Time       // Thời điểm Update trước (để tính thời gian thực đã trôi)
+	recording    *g.Replay       // Replay đang ghi (nil nếu không ghi)
+	replay       *g.ReplayCursor // Replay đang phát thay cho bàn phím (nil nếu chơi thật)
+	replayDone   bool
+	message      string               // Thông báo từ trigger trên map
+	messageUntil float64              // Thời điểm (Clock.Time) ẩn thông báo
+	tilemap      *g.TilemapJSON       // Map đang hiển thị, để biết khi world chuyển phòng
+	assets       *render.Assets       // Nguồn ảnh, dùng để load tileset của map load sau
+	watcher      *systems.FileWatcher // Theo dõi thư mục assets ở chế độ dev (nil = tắt hot reload)
+}
+
+// NewArcheroGame tạo game mới. Nếu replay khác nil thì game phát lại replay đó
+// (dùng seed và trạng thái ban đầu trong replay) thay vì đọc bàn phím.
+func NewArcheroGame(seed uint64, replay *g.Replay) *ArcheroGame {
 	data, err := systems.LoadGameData()
+	hasSave := err == nil
 	if err != nil {
 		log.Printf("khong load duoc save, dung default: %v", err)
 		data = &systems.GameData{
@@ -91,501 +90,423 @@ func NewArcheroGame() *ArcheroGame {
 		}
 	}
 
-	playerImg, _, err := ebitenutil.NewImageFromFile(filepath.Join(assetsBase, "images", "ninja.png"))
-	if err != nil {
-		log.Fatal(err)
-	}
-	enemyImg, _, err := ebitenutil.NewImageFromFile(filepath.Join(assetsBase, "images", "skeleton.png"))
-	if err != nil {
-		log.Fatal(err)
+	renderer := render.NewRenderer()
+	assets := render.NewAssets(assetFS)
+
+	mapPath := spawnMapPath
+	if replay != nil && replay.Map != "" {
+		mapPath = replay.Map
 	}
-	tilesetImg, _, err := ebitenutil.NewImageFromFile(filepath.Join(assetsBase, "images", "TilesetFloor.png"))
+	tilemap, err := g.LoadTilemap(mapPath)
 	if err != nil {
-		log.Fatal(err)
+		assets.Fail(err)
 	}
-	projectileImg, _, err := ebitenutil.NewImageFromFile(filepath.Join(assetsBase, "images", "arrow.png"))
-	if err != nil {
-		log.Printf("khong load duoc projectile img, su dung nil: %v", err)
+
+	gameData, archetypes, bosses, waveScript := loadGameData(replay)
+	chapter, err := loadChapter(replay)
+	if err == nil && chapter != nil {
+		if err = chapter.Validate(archetypes, bosses); err != nil {
+			chapter = nil
+		}
 	}
-	potionImg, _, err := ebitenutil.NewImageFromFile(filepath.Join(assetsBase, "images", "potion.png"))
 	if err != nil {
-		log.Printf("khong load duoc potion img: %v", err)
+		log.Printf("khong load duoc chapter, choi 1 map: %v", err)
 	}
 
-	tilemap, err := g.NewTilemapJSON(filepath.Join(assetsBase, "maps", "spawn.json"))
-	if err != nil {
-		log.Fatal(err)
+	sprites := g.NewSpriteLibrary()
+	loadImages(assets, sprites, tilemap, chapter, archetypes, bosses)
+
+	// Báo 1 lần mọi asset bị thiếu thay vì dừng ở lỗi đầu tiên
+	if err := assets.Build(); err != nil {
+		log.Fatalf("thieu asset:\n%v", err)
 	}
+	renderer.UseAssets(assets)
+	log.Printf("da load %d anh vao %d trang atlas", len(assets.Images()), assets.Pages())
 
 	game := &ArcheroGame{
-		playerImg:     playerImg,
-		enemyImg:      enemyImg,
-		projectileImg: projectileImg,
-		potionImg:     potionImg,
-		tilesetImg:    tilesetImg,
-		tilemap:       tilemap,
-		saveData:      data,
+		world:    g.NewWorld(tilemap, seed),
+		renderer: renderer,
+		assets:   assets,
+		saveData: data,
+	}
+	game.world.SetSprites(sprites)
+	game.world.SetArchetypes(archetypes)
+	game.world.SetBosses(bosses)
+	game.world.SetWaveScript(waveScript)
+	game.world.Data = gameData
+	game.world.SetChapter(chapter)
+
+	// Chưa có save thì bắt đầu ở điểm xuất phát khai báo trong map (tâm của player 16x16)
+	if !hasSave && game.world.HasPlayerStart {
+		data.PlayerX = game.world.PlayerStartX - 8
+		data.PlayerY = game.world.PlayerStartY - 8
+	}
+
+	if replay != nil {
+		game.world = replay.NewWorld(tilemap, sprites)
+		game.world.SetArchetypes(archetypes)
+		game.world.SetBosses(bosses)
+		game.world.SetWaveScript(waveScript)
+		game.world.Data = gameData
+		game.world.SetChapter(chapter)
+		game.replay = &g.ReplayCursor{Replay: replay}
+		game.camera = systems.NewCamera(screenWidth, screenHeight)
+		return game
 	}
 
-	game.mapWidthPx = float64(tilemap.Width * tilemap.TileW)
-	game.mapHeightPx = float64(tilemap.Height * tilemap.TileH)
-
 	game.resetStateFromSave()
 	return game
 }
 
-func (gme *ArcheroGame) resetStateFromSave() {
-	gme.player = g.NewPlayer(
-		gme.playerImg,
-		gme.saveData.PlayerX,
-		gme.saveData.PlayerY,
-		gme.saveData.MaxHealth,
-		3.2,
-		gme.saveData.AttackDamage,
-		gme.saveData.AttackSpeed,
-	)
-	gme.enemies = []*g.Enemy{}
-	gme.potions = []*Potion{}
-	gme.projectiles = []*g.Projectile{}
-	gme.wave = g.NewWaveManager(gme.mapWidthPx, gme.mapHeightPx)
-	gme.camera = systems.NewCamera(screenWidth, screenHeight)
-}
-
-func (gme *ArcheroGame) Update() error {
-	// Nếu nhấn phím L thì hiện menu kỹ năng (để test)
-	if inpututil.IsKeyJustPressed(ebiten.KeyL) {
-		gme.gameState = StateSkillSelect
-		gme.randomizeSkillOptions()
+// loadImages đăng ký vào assets mọi ảnh game cần: player, quái mặc định, đạn, bình máu,
+// tileset của các map và sprite của các loại quái, boss. Clip và sheet của sprite được đăng ký vào sprites.
+func loadImages(assets *render.Assets, sprites *g.SpriteLibrary, tilemap *g.TilemapJSON, chapter *g.ChapterDef,
+	archetypes map[string]*g.EnemyArchetype, bosses map[string]*g.BossDef) {
+	if err := loadSpriteImage(assets, sprites, g.SpritePlayer); err != nil {
+		assets.Fail(err)
 	}
-
-	if gme.gameState == StateSkillSelect {
-		gme.handleSkillSelection() // Hàm xử lý khi người chơi bấm 1, 2, 3
-		return nil                 // Dừng các logic di chuyển/bắn đạn khi đang chọn kỹ năng
+	if err := loadSpriteImage(assets, sprites, g.SpriteEnemy); err != nil {
+		assets.Fail(err)
 	}
-
-	gme.handleTeleportGate()
-
-	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
-		return ebiten.Termination
+	if err := assets.Try(g.SpriteProjectile, filepath.Join(assetsBase, "images", "arrow.png")); err != nil {
+		log.Printf("khong load duoc projectile img, su dung nil: %v", err)
+	}
+	if err := assets.Try(g.SpritePotion, filepath.Join(assetsBase, "images", "potion.png")); err != nil {
+		log.Printf("khong load duoc potion img: %v", err)
 	}
 
-	gme.handleMovement()
-	gme.player.Update()
-	gme.wave.Update()
-
-	gme.spawnEnemiesIfNeeded()
+	if tilemap != nil {
+		loadTilesetImages(assets, tilemap)
+	}
+	if chapter != nil {
+		for _, room := range chapter.Rooms {
+			loadTilesetImages(assets, room.Tilemap)
+		}
+	}
 
-	for _, e := range gme.enemies {
-		prevAlive := e.IsAlive()
-		e.Update(gme.player.X, gme.player.Y, gme.mapWidthPx, gme.mapHeightPx)
-		if e.IsAlive() && e.CheckCollision(gme.player.X, gme.player.Y, gme.player.Width, gme.player.Height) {
-			gme.player.TakeDamage(5)
+	// Load sprite của các loại quái (và đạn của chúng)
+	loadSprite := func(sprite, id string) {
+		if assets.Has(sprite) {
+			return
 		}
-		// Nếu enemy vừa chết trong frame này thì có thể drop potion
-		if prevAlive && !e.IsAlive() {
-			if rand.Float64() < 0.3 {
-				gme.spawnPotion(e.X, e.Y)
-			}
+		if err := loadSpriteImage(assets, sprites, sprite); err != nil {
+			assets.Fail(fmt.Errorf("sprite %q cua quai %q: %w", sprite, id, err))
 		}
 	}
-
-	gme.handleAutoAttack()
-	gme.updateProjectiles()
-	gme.updateDelayedProjectiles()
-	gme.cleanupEntities()
-	gme.handlePotions()
-
-	gme.updateCamera()
-	gme.handleWaveComplete()
-	gme.handleSaveLoad()
-
-	return nil
-}
-
-func (gme *ArcheroGame) handleSkillSelection() {
-	// Reroll khi nhấn R
-	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
-		gme.randomizeSkillOptions()
+	for _, id := range g.SortedArchetypeIDs(archetypes) {
+		a := archetypes[id]
+		loadSprite(a.Sprite, id)
+		if a.Weapon != nil {
+			loadSprite(a.Weapon.Sprite, id)
+		}
 	}
+	for _, id := range g.SortedBossIDs(bosses) {
+		loadSprite(bosses[id].Sprite, id)
+	}
+}
 
-	if inpututil.IsKeyJustPressed(ebiten.Key1) {
-		gme.player.LearnSkill(gme.currentSkillOptions[0])
-		gme.gameState = StatePlaying
+// loadSpriteImage load ảnh của sprite trong assets/images vào assets (ID = sprite).
+// Sheet JSON hoặc file Aseprite được đăng ký vào sprites (xem SpriteLibrary.Load),
+// bóng của sheet có ID render.ShadowID(sprite). Không có thì dùng <sprite>.png.
+func loadSpriteImage(assets *render.Assets, sprites *g.SpriteLibrary, sprite string) error {
+	dir := filepath.Join(assetsBase, "images")
+	img, err := sprites.Load(dir, sprite)
+	if err != nil {
+		return err
 	}
-	if inpututil.IsKeyJustPressed(ebiten.Key2) {
-		gme.player.LearnSkill(gme.currentSkillOptions[1])
-		gme.gameState = StatePlaying
+	if img == nil {
+		return assets.Try(sprite, filepath.Join(dir, sprite+".png"))
 	}
-	if inpututil.IsKeyJustPressed(ebiten.Key3) {
-		gme.player.LearnSkill(gme.currentSkillOptions[2])
-		gme.gameState = StatePlaying
+	if sheet := sprites.Sheet(sprite); sheet != nil && sheet.Shadow != nil {
+		assets.Add(render.ShadowID(sprite), sheet.Shadow)
 	}
+	assets.Add(sprite, img)
+	return nil
 }
 
-func (gme *ArcheroGame) randomizeSkillOptions() {
-	// Tạo bản sao danh sách skill để shuffle
-	shuffled := make([]game.Skill, len(game.AllSkills))
-	copy(shuffled, game.AllSkills)
-
-	// Shuffle
-	rand.Shuffle(len(shuffled), func(i, j int) {
-		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
-	})
-
-	// Lấy 3 skill đầu tiên (hoặc ít hơn nếu tổng skill < 3)
-	count := 3
-	if len(shuffled) < 3 {
-		count = len(shuffled)
+// loadTilesetImages đăng ký ảnh của mọi tileset trong map vào assets (ID = đường dẫn ảnh).
+// Ảnh lỗi được assets ghi lại và báo ở lần Build kế tiếp.
+func loadTilesetImages(assets *render.Assets, tilemap *g.TilemapJSON) {
+	for _, ts := range tilemap.Tilesets {
+		if ts.Image == "" {
+			continue
+		}
+		if err := assets.Try(ts.Image, ts.Image); err != nil {
+			assets.Fail(fmt.Errorf("tileset %q: %w", ts.Name, err))
+		}
 	}
-	gme.currentSkillOptions = shuffled[:count]
 }
 
-func (gme *ArcheroGame) handleMovement() {
-	var dx, dy float64
-
-	// 1. Nhận diện cả WASD và phím mũi tên cho nhạy
-	if ebiten.IsKeyPressed(ebiten.KeyW) || ebiten.IsKeyPressed(ebiten.KeyUp) {
-		dy -= 1
+// loadGameData load quái, boss và kịch bản wave: phát replay có lưu dữ liệu thì dùng đúng dữ liệu đó,
+// ngược lại đọc các file trong assets. File nào lỗi thì world dùng dữ liệu mặc định và nội dung file đó
+// bị bỏ khỏi data, để replay ghi sau này lưu đúng dữ liệu world đang dùng.
+func loadGameData(replay *g.Replay) (data g.GameData, archetypes map[string]*g.EnemyArchetype,
+	bosses map[string]*g.BossDef, waveScript *g.WaveScript) {
+	fromReplay := replay != nil && replay.Data != nil
+	if fromReplay {
+		data = *replay.Data
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyS) || ebiten.IsKeyPressed(ebiten.KeyDown) {
-		dy += 1
+	// Dữ liệu trong replay có thể trống (lúc ghi world dùng dữ liệu mặc định), file trên đĩa thì phải có
+	read := func(path string, saved []byte) ([]byte, error) {
+		if fromReplay {
+			return saved, nil
+		}
+		return g.ReadAsset(path)
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyA) || ebiten.IsKeyPressed(ebiten.KeyLeft) {
-		dx -= 1
+
+	enemies, err := read(enemiesPath, data.Enemies)
+	if err == nil && enemies != nil {
+		archetypes, err = g.ParseArchetypes(enemiesPath, enemies)
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyD) || ebiten.IsKeyPressed(ebiten.KeyRight) {
-		dx += 1
+	if err != nil {
+		log.Printf("khong load duoc enemies, dung quai mac dinh: %v", err)
+		archetypes, enemies = nil, nil
 	}
+	data.Enemies = enemies
 
-	if dx != 0 || dy != 0 {
-		// 2. Giữ nguyên chuẩn hóa (Normalization) để đi chéo không bị nhanh quá mức
-		norm := math.Sqrt(dx*dx + dy*dy)
-		dx /= norm
-		dy /= norm
-
-		// 3. Tăng tốc độ truyền vào (nếu p.Speed đang thấp)
-		// Bạn có thể thử nhân trực tiếp ở đây để test:
-		// gme.player.Move(dx * 1.5, dy * 1.5, gme.mapWidthPx, gme.mapHeightPx)
-
-		gme.player.Move(dx, dy, gme.mapWidthPx, gme.mapHeightPx)
+	bossData, err := read(bossesPath, data.Bosses)
+	if err == nil && bossData != nil {
+		if bosses, err = g.ParseBosses(bossesPath, bossData); err == nil {
+			if err = g.ValidateMinions(bosses, archetypes); err != nil {
+				err = fmt.Errorf("%s: %w", bossesPath, err)
+			}
+		}
 	}
-}
+	if err != nil {
+		log.Printf("khong load duoc bosses, dung boss mac dinh: %v", err)
+		bosses, bossData = nil, nil
+	}
+	data.Bosses = bossData
 
-func (gme *ArcheroGame) handlePotions() {
-	filteredPotions := gme.potions[:0]
-	for _, p := range gme.potions {
-		// Kiểm tra va chạm giữa Player và Potion
-		if gme.player.CheckCollision(p.X, p.Y, p.Width, p.Height) {
-			// Hồi máu cho player (ví dụ 20 HP), không vượt quá MaxHealth
-			gme.player.Health += 20
-			if gme.player.Health > gme.player.MaxHealth {
-				gme.player.Health = gme.player.MaxHealth
+	waves, err := read(wavesPath, data.Waves)
+	if err == nil && waves != nil {
+		if waveScript, err = g.ParseWaveScript(wavesPath, waves); err == nil {
+			if err = waveScript.Validate(archetypes, bosses); err != nil {
+				err = fmt.Errorf("%s: %w", wavesPath, err)
 			}
-			log.Println("Đã ăn bình máu! HP hiện tại:", gme.player.Health)
-			continue // Không thêm vào danh sách mới (tương đương với việc xóa)
 		}
-		filteredPotions = append(filteredPotions, p)
 	}
-	gme.potions = filteredPotions
-}
-
-func (gme *ArcheroGame) spawnPotion(x, y float64) {
-	pot := &Potion{
-		X:      x,
-		Y:      y,
-		Img:    gme.potionImg,
-		Width:  16, // Điều chỉnh kích thước tùy theo asset của bạn
-		Height: 16,
+	if err != nil {
+		log.Printf("khong load duoc kich ban wave, dung wave endless: %v", err)
+		waveScript, waves = nil, nil
 	}
-	gme.potions = append(gme.potions, pot)
+	data.Waves = waves
+	return data, archetypes, bosses, waveScript
 }
 
-func (gme *ArcheroGame) handleAutoAttack() {
-	// Nếu đang di chuyển thì không bắn (đặc trưng của Archero)
-	if ebiten.IsKeyPressed(ebiten.KeyW) || ebiten.IsKeyPressed(ebiten.KeyS) ||
-		ebiten.IsKeyPressed(ebiten.KeyA) || ebiten.IsKeyPressed(ebiten.KeyD) ||
-		ebiten.IsKeyPressed(ebiten.KeyUp) || ebiten.IsKeyPressed(ebiten.KeyDown) ||
-		ebiten.IsKeyPressed(ebiten.KeyLeft) || ebiten.IsKeyPressed(ebiten.KeyRight) {
-		return
+// loadChapter load chapter để chơi: phát replay thì dùng đúng chapter lưu trong replay,
+// ngược lại đọc file chapterPath (nil nếu không chơi chapter)
+func loadChapter(replay *g.Replay) (*g.ChapterDef, error) {
+	if replay != nil {
+		return replay.LoadChapter()
 	}
-
-	target := gme.findNearestEnemy()
-	if target == nil || !gme.player.CanAttack() {
-		return
+	if chapterPath == "" {
+		return nil, nil
 	}
+	return g.LoadChapter(chapterPath)
+}
 
-	px, py := gme.player.GetCenter()
-	ex, ey := target.GetCenter()
-
-	// Vector hướng
-	dx := ex - px
-	dy := ey - py
-	len := math.Hypot(dx, dy)
-	dx /= len
-	dy /= len
-
-	// Vector vuông góc (không còn dùng cho multishot, nhưng có thể giữ nếu cần tính toán khác - hiện tại xóa để fix lint)
-	// perpX := -dy
-	// perpY := dx
-
-	// offset := 4.0
-
-	// Logic bắn đạn chính (đã gộp cả Volley + Multishot)
-	gme.fireAtTarget(ex, ey)
+// startRecording bắt đầu ghi replay từ trạng thái hiện tại của world
+func (gme *ArcheroGame) startRecording() {
+	gme.recording = g.NewReplay(gme.world, spawnMapPath)
+}
 
-	// Nếu có kỹ năng DiagonalArrow (Bắn chéo 3 tia)
-	if gme.player.HasSkill(game.DiagonalArrow) {
-		// 1. Tính góc hiện tại từ người chơi đến quái vật (Radian)
-		angle := math.Atan2(ey-py, ex-px)
+func (gme *ArcheroGame) resetStateFromSave() {
+	gme.world.Reset(g.NewPlayer(
+		g.SpritePlayer,
+		gme.saveData.PlayerX,
+		gme.saveData.PlayerY,
+		gme.saveData.MaxHealth,
+		g.DefaultPlayerSpeed,
+		gme.saveData.AttackDamage,
+		gme.saveData.AttackSpeed,
+	))
+	gme.camera = systems.NewCamera(screenWidth, screenHeight)
+}
 
-		// 2. Tính tọa độ mục tiêu giả định cho tia bên TRÁI (Lệch -30 độ)
-		angleLeft := angle - (math.Pi / 6)     // Pi/6 tương đương 30 độ
-		exLeft := px + math.Cos(angleLeft)*200 // 200 là tầm xa giả định để định hướng
-		eyLeft := py + math.Sin(angleLeft)*200
-		gme.fireAtTarget(exLeft, eyLeft) // Dùng fireAtTarget thay vì spawnProjectile
+func (gme *ArcheroGame) Update() error {
+	elapsed := gme.measureElapsed()
 
-		// 3. Tính tọa độ mục tiêu giả định cho tia bên PHẢI (Lệch +30 độ)
-		angleRight := angle + (math.Pi / 6)
-		exRight := px + math.Cos(angleRight)*200
-		eyRight := py + math.Sin(angleRight)*200
-		gme.fireAtTarget(exRight, eyRight)
+	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
+		return ebiten.Termination
 	}
 
-	// 3. Đánh dấu người chơi đã tấn công để tính cooldown (tốc độ đánh)
-	gme.player.Attack()
-}
+	gme.pollInput()
+	gme.handleHotReload(elapsed)
 
-// fireAtTarget thực hiện quy trình bắn vào 1 điểm mục tiêu
-// Bao gồm: Bắn ngay lập tức (spawnVolley) + Lên lịch bắn trễ (Multishot)
-func (gme *ArcheroGame) fireAtTarget(targetX, targetY float64) {
-	// 1. Bắn ngay lập tức (Xử lý cả ParallelShot bên trong spawnVolley)
-	gme.spawnVolley(targetX, targetY)
-
-	// 2. Xử lý Multishot (Bắn lặp lại sau delay)
-	multiCount := gme.player.GetSkillCount(game.Multishot)
-	if multiCount > 0 {
-		for i := 1; i <= multiCount; i++ {
-			gme.addDelayedProjectile(i*8, targetX, targetY)
+	// Chạy số bước mô phỏng tương ứng với thời gian thực đã trôi qua
+	steps := gme.world.Clock.Advance(elapsed)
+	for i := 0; i < steps; i++ {
+		in, ok := gme.nextInput()
+		if !ok {
+			break
 		}
+		gme.world.Step(in)
+		if gme.recording != nil {
+			gme.recording.Record(in)
+		}
+		gme.updateCamera()
 	}
-}
 
-func (gme *ArcheroGame) spawnOffsetProjectile(
-	px, py, ex, ey, ox, oy float64,
-) {
-	p := g.NewProjectile(
-		gme.projectileImg,
-		px-4+ox,
-		py-4+oy,
-		ex+ox,
-		ey+oy,
-		4.5,
-		gme.player.AttackDamage,
-	)
-	gme.projectiles = append(gme.projectiles, p)
-}
+	gme.handleMapChange()
+	gme.handleTriggerEvents()
+	gme.handleChapterResults()
+	gme.handleSaveLoad()
 
-func (gme *ArcheroGame) addDelayedProjectile(delay int, targetX, targetY float64) {
-	gme.delayedProjectiles = append(gme.delayedProjectiles, DelayedProjectile{
-		DelayFrames: delay,
-		TargetX:     targetX,
-		TargetY:     targetY,
-	})
+	return nil
 }
 
-func (gme *ArcheroGame) updateDelayedProjectiles() {
-	activeDelayed := gme.delayedProjectiles[:0]
-	for _, dp := range gme.delayedProjectiles {
-		dp.DelayFrames--
-		if dp.DelayFrames <= 0 {
-			// Khi hết thời gian chờ, bắn volley (để áp dụng cả ParallelShot cho phát bắn trễ này)
-			gme.spawnVolley(dp.TargetX, dp.TargetY)
-		} else {
-			activeDelayed = append(activeDelayed, dp)
-		}
+// handleMapChange load ảnh tileset và đặt lại camera khi world vừa chuyển sang map khác
+func (gme *ArcheroGame) handleMapChange() {
+	if gme.world.Tilemap == gme.tilemap {
+		return
 	}
-	gme.delayedProjectiles = activeDelayed
-}
-
-// spawnProjectile bắn 1 viên đạn đơn (cơ bản)
-func (gme *ArcheroGame) spawnProjectile(targetX, targetY float64) {
-	px, py := gme.player.GetCenter()
-	p := g.NewProjectile(gme.projectileImg, px-4, py-4, targetX, targetY, 4.5, gme.player.AttackDamage)
-
-	// Nếu có kỹ năng xuyên thấu (Piercing), bạn có thể thiết lập ở đây
-	if gme.player.HasSkill(game.PiercingShot) {
-		p.IsPiercing = true
+	gme.tilemap = gme.world.Tilemap
+	loadTilesetImages(gme.assets, gme.tilemap)
+	if err := gme.assets.Build(); err != nil {
+		log.Printf("khong load duoc tileset: %v", err)
 	}
+	gme.renderer.UseAssets(gme.assets)
 
-	gme.projectiles = append(gme.projectiles, p)
+	// Camera nhảy thẳng tới player thay vì trượt từ vị trí ở map cũ
+	px, py := gme.world.Player.GetCenter()
+	gme.camera.X = clamp(px-screenWidth/2, 0, gme.world.MapWidth-screenWidth)
+	gme.camera.Y = clamp(py-screenHeight/2, 0, gme.world.MapHeight-screenHeight)
 }
 
-// spawnVolley xử lý việc bắn đạn song song (ParallelShot)
-// Nếu không có skill ParallelShot, nó chỉ bắn 1 viên (gọi spawnProjectile)
-// Nếu có N skill, nó bắn N+1 viên song song
-func (gme *ArcheroGame) spawnVolley(targetX, targetY float64) {
-	parallelCount := gme.player.GetSkillCount(game.ParallelShot)
-	if parallelCount == 0 {
-		gme.spawnProjectile(targetX, targetY)
+// handleChapterResults chơi lại từ đầu khi nhấn Enter ở màn hình kết quả chapter.
+// Đang ghi hoặc phát replay thì không cho chơi lại, vì Reset không phải là input được ghi.
+func (gme *ArcheroGame) handleChapterResults() {
+	if gme.world.State != game.StateChapterComplete || !inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
 		return
 	}
-
-	px, py := gme.player.GetCenter()
-	// Vector hướng
-	dx := targetX - px
-	dy := targetY - py
-	len := math.Hypot(dx, dy)
-	dx /= len
-	dy /= len
-
-	// Vector vuông góc
-	perpX := -dy
-	perpY := dx
-
-	// Tổng số đạn = 1 (gốc) + parallelCount
-	ctx := parallelCount + 1
-	spacing := 5.0 // Khoảng cách giữa các viên đạn
-
-	// Tính toán vị trí bắt đầu để chùm đạn cân đối ở giữa
-	// Ví dụ: 2 viên -> offset -5 và +5
-	// 3 viên -> offset -10, 0, +10
-	startOffset := -(float64(ctx-1) * spacing) / 2.0
-
-	for i := 0; i < ctx; i++ {
-		offset := startOffset + float64(i)*spacing
-
-		// Tọa độ bắn ra (offset theo vector vuông góc)
-		spawnX := px + perpX*offset
-		spawnY := py + perpY*offset
-
-		// Tọa độ đích cũng phải offset tương ứng để đạn bay song song
-		destX := targetX + perpX*offset
-		destY := targetY + perpY*offset
-
-		// Tạo projectile thủ công (hoặc refactor spawnProjectile để nhận tọa độ t)
-		// Ở đây ta gọi NewProjectile trực tiếp
-		p := g.NewProjectile(
-			gme.projectileImg,
-			spawnX-4, spawnY-4, // Trừ 4 để căn giữa tâm đạn (giả sử đạn 8x8 ??? actually 16x16 but logic varies)
-			destX, destY,
-			4.5,
-			gme.player.AttackDamage,
-		)
-		if gme.player.HasSkill(game.PiercingShot) {
-			p.IsPiercing = true
-		}
-		gme.projectiles = append(gme.projectiles, p)
+	if gme.recording != nil || gme.replay != nil {
+		log.Println("không thể chơi lại khi đang ghi hoặc phát replay")
+		return
 	}
+	gme.resetStateFromSave()
 }
 
-func (gme *ArcheroGame) updateProjectiles() {
-	for _, p := range gme.projectiles {
-		p.Update(gme.mapWidthPx, gme.mapHeightPx)
-		if !p.Active {
-			continue
-		}
-		for _, e := range gme.enemies {
-			if !e.IsAlive() {
-				continue
-			}
-			if p.CheckCollision(e.X, e.Y, e.Width, e.Height) {
-				e.TakeDamage(p.Damage)
-				p.Active = false
-				break
-			}
+// handleTriggerEvents xử lý các sự kiện trigger trên map
+func (gme *ArcheroGame) handleTriggerEvents() {
+	for _, ev := range gme.world.TakeEvents() {
+		switch ev.Event {
+		case "message":
+			// Hiện dòng chữ trong thuộc tính "text" của trigger trong 3 giây
+			gme.message = ev.Trigger.Area.StringProperty("text")
+			gme.messageUntil = gme.world.Clock.Time + 3
+		default:
+			log.Printf("trigger %q: sự kiện %q chưa được xử lý", ev.Trigger.Name, ev.Event)
 		}
 	}
 }
 
-func (gme *ArcheroGame) cleanupEntities() {
-	filteredProj := gme.projectiles[:0]
-	for _, p := range gme.projectiles {
-		if p.Active {
-			filteredProj = append(filteredProj, p)
-		}
+// measureElapsed trả về thời gian thực (giây) kể từ lần Update trước
+func (gme *ArcheroGame) measureElapsed() float64 {
+	now := time.Now()
+	elapsed := gme.world.Clock.DT()
+	if !gme.lastUpdate.IsZero() {
+		elapsed = now.Sub(gme.lastUpdate).Seconds()
 	}
-	gme.projectiles = filteredProj
+	gme.lastUpdate = now
+	return elapsed
+}
 
-	filteredEnemies := gme.enemies[:0]
-	for _, e := range gme.enemies {
-		if e.IsAlive() {
-			filteredEnemies = append(filteredEnemies, e)
+// nextInput trả về input cho bước mô phỏng kế tiếp: lấy từ replay nếu đang phát lại,
+// ngược lại lấy từ bàn phím. Trả về false khi replay đã hết.
+func (gme *ArcheroGame) nextInput() (g.Input, bool) {
+	if gme.replay != nil {
+		in, ok := gme.replay.Next()
+		if !ok && !gme.replayDone {
+			log.Printf("replay kết thúc sau %d bước", gme.replay.Pos)
+			gme.replayDone = true
 		}
+		return in, ok
 	}
-	gme.enemies = filteredEnemies
-}
 
-func (gme *ArcheroGame) spawnEnemiesIfNeeded() {
-	// mỗi khi wave tăng EnemiesSpawned, thêm enemy mới
-	for len(gme.enemies) < gme.wave.EnemiesSpawned {
-		x, y := gme.wave.GetSpawnPosition(gme.player.X, gme.player.Y)
-		// log.Printf("Spawned enemy tại: x=%.2f, y=%.2f", x, y)
-		enemy := g.NewEnemy(gme.enemyImg, x, y, 30, 1.2, 5, 400)
-		gme.enemies = append(gme.enemies, enemy)
-	}
+	in := gme.pendingInput
+	// Các phím bấm 1 lần chỉ được tiêu thụ bởi bước đầu tiên
+	gme.pendingInput = g.Input{MoveX: in.MoveX, MoveY: in.MoveY}
+	return in, true
 }
 
-func (gme *ArcheroGame) findNearestEnemy() *g.Enemy {
-	px, py := gme.player.GetCenter()
-	var best *g.Enemy
-	bestDist := math.MaxFloat64
-	for _, e := range gme.enemies {
-		if !e.IsAlive() {
-			continue
-		}
-		dist := e.GetDistanceTo(px, py)
-		if dist < bestDist {
-			bestDist = dist
-			best = e
-		}
+// pollInput đọc bàn phím và gộp vào pendingInput.
+// Phím giữ (di chuyển) lấy theo trạng thái mới nhất, phím bấm 1 lần được giữ lại
+// cho tới khi có bước mô phỏng tiêu thụ nó.
+func (gme *ArcheroGame) pollInput() {
+	in := &gme.pendingInput
+	in.MoveX, in.MoveY = 0, 0
+
+	// Nhận diện cả WASD và phím mũi tên cho nhạy
+	if ebiten.IsKeyPressed(ebiten.KeyW) || ebiten.IsKeyPressed(ebiten.KeyUp) {
+		in.MoveY -= 1
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyS) || ebiten.IsKeyPressed(ebiten.KeyDown) {
+		in.MoveY += 1
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyA) || ebiten.IsKeyPressed(ebiten.KeyLeft) {
+		in.MoveX -= 1
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyD) || ebiten.IsKeyPressed(ebiten.KeyRight) {
+		in.MoveX += 1
 	}
-	return best
-}
 
-func (gme *ArcheroGame) handleWaveComplete() {
-	if gme.wave.EnemiesSpawned >= gme.wave.EnemiesPerWave && len(gme.enemies) == 0 {
-		gme.wave.StartNextWave()
+	if inpututil.IsKeyJustPressed(ebiten.KeyL) {
+		in.OpenSkills = true
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
+		in.Reroll = true
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyE) {
+		in.Interact = true
+	}
+	if in.SkillPick == 0 {
+		switch {
+		case inpututil.IsKeyJustPressed(ebiten.Key1):
+			in.SkillPick = 1
+		case inpututil.IsKeyJustPressed(ebiten.Key2):
+			in.SkillPick = 2
+		case inpututil.IsKeyJustPressed(ebiten.Key3):
+			in.SkillPick = 3
+		}
 	}
 }
 
 func (gme *ArcheroGame) updateCamera() {
-	px, py := gme.player.GetCenter()
+	px, py := gme.world.Player.GetCenter()
 	gme.camera.SetFollowTarget(px, py)
-	gme.camera.Update()
+	gme.camera.Update(gme.world.Clock.DT())
 
 	// Clamp camera để không lộ ra ngoài map
-	gme.camera.X = clamp(gme.camera.X, 0, gme.mapWidthPx-screenWidth)
-	gme.camera.Y = clamp(gme.camera.Y, 0, gme.mapHeightPx-screenHeight)
+	gme.camera.X = clamp(gme.camera.X, 0, gme.world.MapWidth-screenWidth)
+	gme.camera.Y = clamp(gme.camera.Y, 0, gme.world.MapHeight-screenHeight)
+
+	// Map vô hạn: nạp chunk quanh camera, giải phóng chunk ở xa
+	c := gme.camera
+	if err := gme.world.StreamChunks(c.X, c.Y, c.Width, c.Height, g.DefaultStreamMargin); err != nil {
+		log.Printf("khong nap duoc chunk: %v", err)
+	}
 }
 
 func (gme *ArcheroGame) Draw(screen *ebiten.Image) {
 	screen.Fill(color.RGBA{80, 160, 200, 255})
-	g.DrawTilemap(screen, gme.tilemap, gme.tilesetImg, gme.camera.X, gme.camera.Y)
+	gme.renderer.DrawTilemap(screen, gme.world.Tilemap, gme.camera, gme.world.Clock.Time)
 
 	// 2. CHÈN VÀO ĐÂY: Nếu đang trong trạng thái chọn kỹ năng thì mới vẽ menu
-	if gme.gameState == StateSkillSelect {
+	if gme.world.State == game.StateSkillSelect {
 		// Giả sử bạn truyền vào 3 kỹ năng ngẫu nhiên
-		game.DrawSkillMenu(screen, gme.currentSkillOptions)
-	}
-
-	for _, e := range gme.enemies {
-		e.Draw(screen, gme.camera.X, gme.camera.Y)
+		render.DrawSkillMenu(screen, gme.world.SkillOptions)
 	}
 
-	// Vẽ bình máu
-	for _, pot := range gme.potions {
-		pot.draw(screen, gme.camera.X, gme.camera.Y)
-	}
-
-	gme.player.Draw(screen, gme.camera.X, gme.camera.Y)
-
-	for _, p := range gme.projectiles {
-		p.Draw(screen, gme.camera.X, gme.camera.Y)
-	}
+	gme.renderer.DrawWorld(screen, gme.world, gme.camera.X, gme.camera.Y)
 
 	gme.drawUI(screen)
 	gme.drawTeleportGateHint(screen)
-	ebitenutil.DebugPrint(screen, fmt.Sprintf("TPS: %0.2f", ebiten.ActualTPS()))
+	if gme.world.State == game.StateChapterComplete {
+		render.DrawChapterResults(screen, gme.world)
+	}
+	stats := gme.renderer.TileStats
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("TPS: %0.2f  Map draws: %d", ebiten.ActualTPS(), stats.DrawCalls))
 	// ebitenutil.DebugPrintAt(screen, "Vui lòng tắt bộ gõ Tiếng Việt (chuyển sang E) để di chuyển mượt mà bằng WASD", 10, 500)
 }
 
@@ -599,7 +520,7 @@ func (gme *ArcheroGame) drawUI(screen *ebiten.Image) {
 	barH := 12.0
 	x := 20.0
 	y := 20.0
-	ratio := gme.player.Health / gme.player.MaxHealth
+	ratio := gme.world.Player.Health / gme.world.Player.MaxHealth
 	if ratio < 0 {
 		ratio = 0
 	}
@@ -607,36 +528,54 @@ func (gme *ArcheroGame) drawUI(screen *ebiten.Image) {
 	ebitenutil.DrawRect(screen, x, y, barW*ratio, barH, color.RGBA{0, 200, 80, 255})
 
 	ebitenutil.DebugPrintAt(screen, "HP", int(x), int(y)-12)
-	ebitenutil.DebugPrintAt(screen, "Wave: "+itoa(gme.wave.CurrentWave), int(x), int(y)+20)
+	ebitenutil.DebugPrintAt(screen, "Wave: "+itoa(gme.world.Wave.CurrentWave), int(x), int(y)+20)
 	ebitenutil.DebugPrintAt(screen, "F5: Save | F9: Load | L: Skills | ESC: Quit", int(x), int(y)+36)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Seed: %d", gme.world.Seed), int(x), int(y)+52)
+	if chapter := gme.world.Chapter; chapter != nil {
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Phong: %d/%d", gme.world.Room+1, len(chapter.Rooms)), int(x), int(y)+68)
+	}
 
+	gme.drawBossBar(screen)
+
+	if gme.message != "" && gme.world.Clock.Time < gme.messageUntil {
+		ebitenutil.DebugPrintAt(screen, gme.message, int(x), screenHeight-40)
+	}
 }
 
-// Phát hiện cổng chuyển map và xử lý nhấn E
-func (gme *ArcheroGame) handleTeleportGate() {
-	gateTile := TeleportGateTileID
-	playerX := int((gme.player.X + gme.player.Width/2) / float64(gme.tilemap.TileW))
-	playerY := int((gme.player.Y + gme.player.Height/2) / float64(gme.tilemap.TileH))
-	for _, layer := range gme.tilemap.Layers {
-		if playerY >= 0 && playerY < layer.Height && playerX >= 0 && playerX < layer.Width {
-			tileID := layer.Data[playerY*layer.Width+playerX]
-			if tileID == gateTile {
-				if inpututil.IsKeyJustPressed(ebiten.KeyE) {
-					// Demo: chỉ hiện thông báo, có thể load map mới ở đây
-					log.Println("Chuyển sang map mới!")
-				}
-			}
-		}
+// drawBossBar vẽ thanh máu boss ở giữa phía trên màn hình khi đang có boss
+func (gme *ArcheroGame) drawBossBar(screen *ebiten.Image) {
+	boss := g.AsBoss(gme.world.Boss)
+	if boss == nil {
+		return
+	}
+	e := gme.world.Boss
+
+	barW := 400.0
+	barH := 10.0
+	x := (screenWidth - barW) / 2
+	y := 24.0
+	ratio := clamp(e.Health/e.MaxHealth, 0, 1)
+	ebitenutil.DrawRect(screen, x-2, y-2, barW+4, barH+4, color.RGBA{20, 20, 20, 255})
+	ebitenutil.DrawRect(screen, x, y, barW, barH, color.RGBA{60, 0, 0, 255})
+	ebitenutil.DrawRect(screen, x, y, barW*ratio, barH, color.RGBA{200, 40, 200, 255})
+
+	// Vạch đánh dấu ngưỡng đổi phase
+	for _, phase := range boss.Def.Phases[1:] {
+		ebitenutil.DrawRect(screen, x+barW*phase.Threshold-1, y, 2, barH, color.RGBA{255, 255, 255, 255})
 	}
+
+	label := fmt.Sprintf("%s - Phase %d/%d", boss.Def.Name, boss.Phase+1, len(boss.Def.Phases))
+	ebitenutil.DebugPrintAt(screen, label, int(x), int(y)-16)
 }
 
 func (gme *ArcheroGame) handleSaveLoad() {
 	if inpututil.IsKeyJustPressed(ebiten.KeyF5) {
-		gme.saveData.PlayerX = gme.player.X
-		gme.saveData.PlayerY = gme.player.Y
-		gme.saveData.MaxHealth = gme.player.MaxHealth
-		gme.saveData.AttackDamage = gme.player.AttackDamage
-		gme.saveData.AttackSpeed = gme.player.AttackSpeed
+		player := gme.world.Player
+		gme.saveData.PlayerX = player.X
+		gme.saveData.PlayerY = player.Y
+		gme.saveData.MaxHealth = player.MaxHealth
+		gme.saveData.AttackDamage = player.AttackDamage
+		gme.saveData.AttackSpeed = player.AttackSpeed
 		if err := systems.SaveGameData(gme.saveData); err != nil {
 			log.Printf("save failed: %v", err)
 		} else {
@@ -644,6 +583,10 @@ func (gme *ArcheroGame) handleSaveLoad() {
 		}
 	}
 	if inpututil.IsKeyJustPressed(ebiten.KeyF9) {
+		if gme.recording != nil || gme.replay != nil {
+			log.Println("không thể load khi đang ghi hoặc phát replay")
+			return
+		}
 		data, err := systems.LoadGameData()
 		if err != nil {
 			log.Printf("load failed: %v", err)
@@ -668,32 +611,116 @@ func itoa(v int) string {
 	return fmt.Sprintf("%d", v)
 }
 
-// Vẽ gợi ý chuyển map nếu player đang đứng ở tile cổng
+// Vẽ gợi ý chuyển map nếu player đang đứng trong vùng cổng
 func (gme *ArcheroGame) drawTeleportGateHint(screen *ebiten.Image) {
-	gateTile := TeleportGateTileID
-	playerX := int((gme.player.X + gme.player.Width/2) / float64(gme.tilemap.TileW))
-	playerY := int((gme.player.Y + gme.player.Height/2) / float64(gme.tilemap.TileH))
-
-	for _, layer := range gme.tilemap.Layers {
-		if playerY >= 0 && playerY < layer.Height && playerX >= 0 && playerX < layer.Width {
-			tileID := layer.Data[playerY*layer.Width+playerX]
-			if tileID == gateTile {
-				msg := "Ấn E để chuyển bản đồ!"
-				x := int(gme.player.X - gme.camera.X)
-				y := int(gme.player.Y - gme.camera.Y - 24)
-				ebitenutil.DebugPrintAt(screen, msg, x-8, y)
-			}
+	if gme.world.CurrentGate() == nil {
+		return
+	}
+	player := gme.world.Player
+	msg := "Ấn E để chuyển bản đồ!"
+	if !gme.world.GateOpen() {
+		msg = "Cong dang khoa, ha het quai!"
+	}
+	x := int(player.X - gme.camera.X)
+	y := int(player.Y - gme.camera.Y - 24)
+	ebitenutil.DebugPrintAt(screen, msg, x-8, y)
+}
+
+// openAssets mở nguồn asset: rỗng = assets nhúng trong binary, file .zip = bundle tạo bởi
+// cmd/bundle, còn lại là thư mục chứa thư mục assets
+func openAssets(src string) (fs.FS, error) {
+	switch {
+	case src == "":
+		return embeddedAssets, nil
+	case strings.HasSuffix(strings.ToLower(src), ".zip"):
+		// Reader giữ file mở suốt lượt chơi
+		return zip.OpenReader(src)
+	default:
+		if _, err := os.Stat(filepath.Join(src, assetsBase)); err != nil {
+			return nil, err
 		}
+		return os.DirFS(src), nil
 	}
 }
 
+// dailySeed trả về seed chung cho cả ngày (dạng YYYYMMDD) để mọi người chơi cùng 1 lượt
+func dailySeed(t time.Time) uint64 {
+	y, m, d := t.Date()
+	return uint64(y*10000 + int(m)*100 + d)
+}
+
 func main() {
+	seed := flag.Uint64("seed", 0, "seed random của lượt chơi (0 = ngẫu nhiên)")
+	daily := flag.Bool("daily", false, "dùng seed của thử thách hằng ngày")
+	recordPath := flag.String("record", "", "ghi input của lượt chơi ra file replay")
+	replayPath := flag.String("replay", "", "phát lại file replay thay cho bàn phím")
+	tileCache := flag.Bool("tilecache", true, "vẽ map bằng các vùng tile dựng sẵn thay vì từng tile")
+	chapter := flag.String("chapter", chapterPath, "file chapter (rỗng = chơi 1 map với wave endless)")
+	assetsSrc := flag.String("assets", "", "nguồn asset: bundle .zip hoặc thư mục chứa assets (rỗng = assets nhúng trong binary)")
+	dev := flag.Bool("dev", false, "chế độ dev: theo dõi thư mục assets trên đĩa và load lại khi file thay đổi")
+	flag.Parse()
+	chapterPath = *chapter
+
+	if *dev {
+		// Hot reload đọc thẳng từ đĩa thay vì assets nhúng
+		if *assetsSrc == "" {
+			*assetsSrc = "."
+		}
+		if strings.HasSuffix(strings.ToLower(*assetsSrc), ".zip") {
+			log.Fatal("-dev can thu muc assets tren dia, khong dung duoc voi bundle .zip")
+		}
+	}
+
+	fsys, err := openAssets(*assetsSrc)
+	if err != nil {
+		log.Fatalf("khong mo duoc assets: %v", err)
+	}
+	assetFS = fsys
+	g.SetAssetFS(fsys)
+
+	var replay *g.Replay
+	if *replayPath != "" {
+		replay, err = g.LoadReplay(*replayPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	switch {
+	case replay != nil:
+		*seed = replay.Seed
+	case *daily:
+		*seed = dailySeed(time.Now())
+	case *seed == 0:
+		*seed = uint64(time.Now().UnixNano())
+	}
+	log.Printf("seed: %d", *seed)
+
 	ebiten.SetWindowSize(screenWidth, screenHeight)
 	ebiten.SetWindowTitle("Pixcel Archero-like")
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 
-	game := NewArcheroGame()
+	game := NewArcheroGame(*seed, replay)
+	game.renderer.CacheTiles = *tileCache
+	if *recordPath != "" && replay == nil {
+		game.startRecording()
+	}
+	if *dev {
+		if err := game.enableHotReload(*assetsSrc); err != nil {
+			log.Fatal(err)
+		}
+		if *recordPath != "" || replay != nil {
+			log.Println("canh bao: sua asset khi dang ghi/phat replay se lam replay khong khop")
+		}
+	}
 	if err := ebiten.RunGame(game); err != nil {
 		log.Fatal(err)
 	}
+
+	if game.recording != nil {
+		if err := g.SaveReplay(*recordPath, game.recording); err != nil {
+			log.Fatalf("không ghi được replay: %v", err)
+		}
+		log.Printf("đã ghi replay %d bước vào %s", len(game.recording.Inputs), *recordPath)
+	}
 }
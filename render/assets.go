@@ -0,0 +1,210 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/png" // Giải mã ảnh PNG
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"pixcel-game/game"
+	"pixcel-game/systems"
+)
+
+// AtlasIndexPath là nơi bundle lưu atlas dựng sẵn lúc build (xem cmd/bundle)
+const AtlasIndexPath = "assets/atlas/atlas.json"
+
+// shadowSuffix là hậu tố ID của ảnh bóng, vd "orc/shadow" là bóng của sprite "orc"
+const shadowSuffix = "/shadow"
+
+// ShadowID trả về ID của ảnh bóng của sprite
+func ShadowID(sprite string) string {
+	return sprite + shadowSuffix
+}
+
+// Assets quản lý ảnh của game: đọc từ 1 fs.FS (embed, thư mục hoặc bundle zip),
+// cache theo ID, xếp các ảnh vào atlas và gom mọi asset lỗi để báo 1 lần.
+//
+// Cách dùng: gọi Load/Add cho mọi ảnh cần thiết, rồi Build để xếp atlas,
+// sau đó lấy ảnh bằng Image. Ảnh có trong atlas dựng sẵn của bundle thì không phải giải mã lại.
+type Assets struct {
+	FS        fs.FS
+	AtlasSize int // Cạnh tối đa của trang atlas xếp lúc chạy
+
+	images   map[string]*ebiten.Image // Ảnh đã sẵn sàng theo ID
+	pending  map[string]image.Image   // Ảnh đã giải mã, chờ xếp atlas ở Build
+	prebuilt map[string]*ebiten.Image // Ảnh trong atlas dựng sẵn, theo đường dẫn
+	pages    []*ebiten.Image          // Các trang atlas đã tạo (tính cả atlas dựng sẵn)
+	missing  []error
+}
+
+// NewAssets tạo bộ quản lý asset đọc từ fsys. Nếu fsys có atlas dựng sẵn thì atlas đó được nạp luôn.
+func NewAssets(fsys fs.FS) *Assets {
+	a := &Assets{
+		FS:        fsys,
+		AtlasSize: systems.DefaultAtlasSize,
+		images:    map[string]*ebiten.Image{},
+		pending:   map[string]image.Image{},
+		prebuilt:  map[string]*ebiten.Image{},
+	}
+	if _, err := fs.Stat(fsys, AtlasIndexPath); err == nil {
+		if err := a.loadPrebuilt(); err != nil {
+			a.Fail(fmt.Errorf("atlas dựng sẵn: %w", err))
+		}
+	}
+	return a
+}
+
+// loadPrebuilt nạp các trang atlas dựng sẵn và cắt sẵn ảnh con theo index
+func (a *Assets) loadPrebuilt() error {
+	contents, err := fs.ReadFile(a.FS, AtlasIndexPath)
+	if err != nil {
+		return err
+	}
+	var index systems.AtlasIndex
+	if err := json.Unmarshal(contents, &index); err != nil {
+		return fmt.Errorf("%s: %w", AtlasIndexPath, err)
+	}
+	if err := index.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", AtlasIndexPath, err)
+	}
+
+	pages := make([]*ebiten.Image, len(index.Pages))
+	for i, name := range index.Pages {
+		img, err := a.decode(path.Join(path.Dir(AtlasIndexPath), name))
+		if err != nil {
+			return err
+		}
+		pages[i] = ebiten.NewImageFromImage(img)
+	}
+	for id, e := range index.Entries {
+		a.prebuilt[id] = pages[e.Page].SubImage(e.Rect()).(*ebiten.Image)
+	}
+	a.pages = append(a.pages, pages...)
+	return nil
+}
+
+// decode đọc và giải mã 1 file ảnh trong FS
+func (a *Assets) decode(p string) (image.Image, error) {
+	f, err := a.FS.Open(game.AssetPath(p))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	return img, nil
+}
+
+// Load đăng ký ảnh id đọc từ file p. ID đã có thì bỏ qua (cache).
+// Lỗi được ghi lại để Build báo chung, đồng thời trả về cho nơi gọi.
+func (a *Assets) Load(id, p string) error {
+	err := a.Try(id, p)
+	if err != nil {
+		a.Fail(fmt.Errorf("ảnh %q: %w", id, err))
+	}
+	return err
+}
+
+// Try giống Load nhưng lỗi không bị tính là thiếu asset (dùng cho ảnh không bắt buộc)
+func (a *Assets) Try(id, p string) error {
+	if a.Has(id) {
+		return nil
+	}
+	if img, ok := a.prebuilt[game.AssetPath(p)]; ok {
+		a.images[id] = img
+		return nil
+	}
+	img, err := a.decode(p)
+	if err != nil {
+		return err
+	}
+	a.pending[id] = img
+	return nil
+}
+
+// Add đăng ký ảnh id tạo sẵn trong bộ nhớ (vd sheet ghép từ file Aseprite hoặc strip)
+func (a *Assets) Add(id string, img image.Image) {
+	if !a.Has(id) {
+		a.pending[id] = img
+	}
+}
+
+// Has kiểm tra ID đã được đăng ký chưa
+func (a *Assets) Has(id string) bool {
+	_, ready := a.images[id]
+	_, waiting := a.pending[id]
+	return ready || waiting
+}
+
+// Fail ghi nhận 1 asset lỗi (vd file map hoặc dữ liệu không đọc được) để báo chung ở Build
+func (a *Assets) Fail(err error) {
+	a.missing = append(a.missing, err)
+}
+
+// Build xếp các ảnh đang chờ vào atlas rồi trả về lỗi gộp của mọi asset bị thiếu/hỏng
+// kể từ lần Build trước (nil nếu đủ). Có thể gọi lại sau khi Load thêm ảnh,
+// các ảnh mới được xếp vào trang mới.
+func (a *Assets) Build() error {
+	if len(a.pending) > 0 {
+		atlas := systems.PackAtlas(a.pending, a.AtlasSize)
+		pages := make([]*ebiten.Image, len(atlas.Pages))
+		for i, p := range atlas.Pages {
+			pages[i] = ebiten.NewImageFromImage(p)
+		}
+		for id, e := range atlas.Entries {
+			a.images[id] = pages[e.Page].SubImage(e.Rect()).(*ebiten.Image)
+		}
+		a.pages = append(a.pages, pages...)
+		a.pending = map[string]image.Image{}
+	}
+	err := errors.Join(a.missing...)
+	a.missing = nil
+	return err
+}
+
+// Image trả về ảnh theo ID (nil nếu chưa Build hoặc không load được)
+func (a *Assets) Image(id string) *ebiten.Image {
+	return a.images[id]
+}
+
+// Images trả về mọi ảnh đã sẵn sàng theo ID
+func (a *Assets) Images() map[string]*ebiten.Image {
+	return a.images
+}
+
+// Pages trả về số trang atlas đang dùng
+func (a *Assets) Pages() int {
+	return len(a.pages)
+}
+
+// Deallocate giải phóng các trang atlas, gọi khi bộ asset đã được thay bằng bộ mới (vd hot reload)
+func (a *Assets) Deallocate() {
+	for _, page := range a.pages {
+		page.Deallocate()
+	}
+	a.pages = nil
+	a.images = map[string]*ebiten.Image{}
+	a.prebuilt = map[string]*ebiten.Image{}
+}
+
+// UseAssets thay ảnh của renderer bằng mọi ảnh đã sẵn sàng của a: ảnh bóng (xem ShadowID)
+// vào Shadows, còn lại vào Images
+func (r *Renderer) UseAssets(a *Assets) {
+	r.Images = map[string]*ebiten.Image{}
+	r.Shadows = map[string]*ebiten.Image{}
+	for id, img := range a.Images() {
+		if sprite, ok := strings.CutSuffix(id, shadowSuffix); ok {
+			r.Shadows[sprite] = img
+		} else {
+			r.Images[id] = img
+		}
+	}
+}
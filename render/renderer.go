@@ -0,0 +1,207 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"pixcel-game/game"
+)
+
+// Renderer vẽ các entity của package game bằng ebiten.
+// Entity chỉ giữ sprite ID, Renderer tra ảnh tương ứng trong Images.
+type Renderer struct {
+	Images map[string]*ebiten.Image
+	// Shadows là ảnh bóng của sprite có sheet metadata, cùng bố cục frame với ảnh trong Images
+	Shadows map[string]*ebiten.Image
+
+	// CacheTiles bật vẽ sẵn tile layer vào ảnh offscreen theo từng vùng (xem tilecache.go)
+	CacheTiles bool
+	// TileStats là thống kê lần vẽ map gần nhất
+	TileStats TileStats
+
+	tileCache tileCache
+	tileTime  float64 // Thời điểm (giây) dùng để chọn frame của tile có animation
+}
+
+// NewRenderer tạo renderer rỗng
+func NewRenderer() *Renderer {
+	return &Renderer{
+		Images:  map[string]*ebiten.Image{},
+		Shadows: map[string]*ebiten.Image{},
+	}
+}
+
+// Image trả về ảnh theo sprite ID (nil nếu chưa load)
+func (r *Renderer) Image(id string) *ebiten.Image {
+	return r.Images[id]
+}
+
+// animRect trả về vùng frame hiện tại của animator trên spritesheet.
+// Không có animation thì dùng ô 16x16 đầu tiên như cũ.
+func animRect(anim *game.Animator) image.Rectangle {
+	if anim != nil {
+		if f, ok := anim.Frame(); ok {
+			return image.Rect(f.X, f.Y, f.X+f.W, f.Y+f.H)
+		}
+	}
+	return image.Rect(0, 0, 16, 16)
+}
+
+// drawCharacter vẽ frame hiện tại của nhân vật có khung va chạm (x, y, w, h) và trả về
+// tọa độ y (màn hình) của mép trên khung để đặt thanh máu.
+// Sprite thường được co giãn vừa khung va chạm. Sprite có sheet metadata thì đặt theo fit
+// (tính trong package game từ hitbox của sheet): hitbox nằm đúng trên khung va chạm,
+// bóng vẽ trước, quay trái thì lật quanh origin.
+func (r *Renderer) drawCharacter(screen *ebiten.Image, sprite string, anim *game.Animator, action game.Action,
+	fit game.SpriteFit, x, y, w, h, cameraX, cameraY float64, tint ebiten.ColorScale) float64 {
+	img := r.Image(sprite)
+	if img == nil {
+		return y - cameraY
+	}
+	rect := animRect(anim)
+	opts := &ebiten.DrawImageOptions{}
+	opts.ColorScale = tint
+
+	sheet := fit.Sheet
+	if sheet == nil {
+		opts.GeoM.Scale(w/float64(rect.Dx()), h/float64(rect.Dy()))
+		opts.GeoM.Translate(x-cameraX, y-cameraY)
+		screen.DrawImage(subImage(img, rect), opts)
+		return y - cameraY
+	}
+
+	opts.GeoM.Translate(-sheet.Origin.X, -sheet.Origin.Y)
+	if sheet.FlipLeft && action.FacingLeft {
+		opts.GeoM.Scale(-1, 1)
+	}
+	opts.GeoM.Scale(fit.Scale, fit.Scale)
+	opts.GeoM.Translate(x+fit.OriginX-cameraX, y+fit.OriginY-cameraY)
+
+	if shadow := r.Shadows[sprite]; shadow != nil {
+		shadowOpts := &ebiten.DrawImageOptions{GeoM: opts.GeoM}
+		screen.DrawImage(subImage(shadow, rect), shadowOpts)
+	}
+	screen.DrawImage(subImage(img, rect), opts)
+	return y - cameraY
+}
+
+// DrawPlayer vẽ player lên màn hình
+func (r *Renderer) DrawPlayer(screen *ebiten.Image, p *game.Player, cameraX, cameraY float64) {
+	top := r.drawCharacter(screen, p.Sprite, p.Anim, p.Action, p.Fit, p.X, p.Y, p.Width, p.Height, cameraX, cameraY, ebiten.ColorScale{})
+	DrawHealthBar(screen, p.X-cameraX, top-8, p.Width, 3, p.Health/p.MaxHealth, false)
+}
+
+// DrawEnemy vẽ enemy lên màn hình. Quái đã bị hạ (trong World.Dying) chỉ vẽ animation chết.
+func (r *Renderer) DrawEnemy(screen *ebiten.Image, e *game.Enemy, cameraX, cameraY float64) {
+	alive := e.IsAlive()
+	boss := game.AsBoss(e)
+	if alive && boss != nil && boss.Telegraphing() {
+		r.drawTelegraph(screen, e, boss, cameraX, cameraY)
+	}
+
+	// Boss nhấp nháy đỏ khi đang báo hiệu đòn
+	var tint ebiten.ColorScale
+	if alive && boss != nil && boss.Telegraphing() && int(boss.Timer*10)%2 == 0 {
+		tint.Scale(1, 0.3, 0.3, 1)
+	}
+	// Quái to (boss) thì hình được phóng theo khung va chạm
+	top := r.drawCharacter(screen, e.Sprite, e.Anim, e.Action, e.Fit, e.X, e.Y, e.Width, e.Height, cameraX, cameraY, tint)
+	if !alive {
+		return
+	}
+	// Boss có thanh máu riêng trên UI
+	if boss == nil {
+		DrawHealthBar(screen, e.X-cameraX, top-5, e.Width, 2, e.Health/e.MaxHealth, true)
+	}
+}
+
+// drawTelegraph vẽ cảnh báo đòn sắp tung ra của boss:
+// đường lao tới cho charge, vòng tròn quanh boss cho ring và summon
+func (r *Renderer) drawTelegraph(screen *ebiten.Image, e *game.Enemy, boss *game.Boss, cameraX, cameraY float64) {
+	cx, cy := e.GetCenter()
+	sx, sy := float32(cx-cameraX), float32(cy-cameraY)
+	warn := color.RGBA{255, 40, 40, 160}
+
+	switch boss.Attack.Kind {
+	case game.AttackCharge:
+		length := boss.Attack.Speed * boss.Attack.Duration
+		ex := sx + float32(boss.AimX*length)
+		ey := sy + float32(boss.AimY*length)
+		vector.StrokeLine(screen, sx, sy, ex, ey, float32(e.Width/2), warn, false)
+	case game.AttackRing:
+		vector.StrokeCircle(screen, sx, sy, float32(e.Width), 3, warn, false)
+	case game.AttackSummon:
+		vector.StrokeCircle(screen, sx, sy, float32(e.Width), 2, color.RGBA{160, 60, 255, 160}, false)
+	}
+}
+
+// DrawProjectile vẽ projectile lên màn hình
+func (r *Renderer) DrawProjectile(screen *ebiten.Image, p *game.Projectile, cameraX, cameraY float64) {
+	img := r.Image(p.Sprite)
+	if !p.Active || img == nil {
+		return
+	}
+
+	opts := &ebiten.DrawImageOptions{}
+
+	// Tính góc xoay dựa trên vector vận tốc
+	angle := math.Atan2(p.VY, p.VX)
+
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+
+	// Dời tâm về giữa ảnh để xoay
+	opts.GeoM.Translate(-float64(w)/2, -float64(h)/2)
+
+	// Scale ảnh về đúng kích thước projectile (arrow 32px -> 16px)
+	opts.GeoM.Scale(p.Width/float64(w), p.Height/float64(h))
+
+	// Xoay ảnh (giả sử ảnh gốc mũi tên hướng sang PHẢI -> 0 độ)
+	// Nếu nó hướng lên thì +Pi/2. Nếu hướng chéo thì +Pi/4.
+	// User report: "nằm ngang" (sai hướng). Thử bỏ offset (giả sử asset gốc đã xoay đúng hoặc là hướng phải).
+	// Nếu Asset là Arrow01(32x32), thường là chéo 45 độ (Up-Right).
+	// Hãy thử -Pi/4 (để xoay nó về 0 rồi +angle) nếu nó là chéo.
+	// Nhưng user bảo "nằm ngang", có thể nó đang bị xoay 90 độ.
+	// Thử dùng angle thuần túy trước.
+	opts.GeoM.Rotate(angle)
+
+	// Dời về vị trí hiển thị (tâm của projectile)
+	opts.GeoM.Translate(p.X+p.Width/2-cameraX, p.Y+p.Height/2-cameraY)
+
+	screen.DrawImage(img, opts)
+}
+
+// DrawPotion vẽ bình máu lên màn hình
+func (r *Renderer) DrawPotion(screen *ebiten.Image, p *game.Potion, cameraX, cameraY float64) {
+	img := r.Image(p.Sprite)
+	if img == nil {
+		return
+	}
+	opts := &ebiten.DrawImageOptions{}
+	opts.GeoM.Translate(p.X-cameraX, p.Y-cameraY)
+	screen.DrawImage(img, opts)
+}
+
+// DrawWorld vẽ toàn bộ entity trong world theo thứ tự: quái đang chết, quái, bình máu, player, đạn
+func (r *Renderer) DrawWorld(screen *ebiten.Image, w *game.World, cameraX, cameraY float64) {
+	for _, e := range w.Dying {
+		r.DrawEnemy(screen, e, cameraX, cameraY)
+	}
+	for _, e := range w.Enemies {
+		r.DrawEnemy(screen, e, cameraX, cameraY)
+	}
+
+	// Vẽ bình máu
+	for _, pot := range w.Potions {
+		r.DrawPotion(screen, pot, cameraX, cameraY)
+	}
+
+	r.DrawPlayer(screen, w.Player, cameraX, cameraY)
+
+	for _, p := range w.Projectiles {
+		r.DrawProjectile(screen, p, cameraX, cameraY)
+	}
+}
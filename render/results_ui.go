@@ -0,0 +1,50 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"pixcel-game/game"
+)
+
+// DrawChapterResults vẽ màn hình kết quả khi qua chapter
+func DrawChapterResults(screen *ebiten.Image, w *game.World) {
+	// Vẽ lớp phủ tối phủ kín màn hình
+	bounds := screen.Bounds()
+	sw, sh := float32(bounds.Dx()), float32(bounds.Dy())
+	vector.DrawFilledRect(screen, float32(bounds.Min.X), float32(bounds.Min.Y), sw, sh, color.RGBA{0, 0, 0, 200}, false)
+
+	// Bảng kết quả ở giữa màn hình
+	const panelW, panelH = 300, 260
+	x := float32(bounds.Min.X) + (sw-panelW)/2
+	y := float32(bounds.Min.Y) + (sh-panelH)/2
+	vector.DrawFilledRect(screen, x, y, panelW, panelH, color.RGBA{40, 40, 80, 255}, false)
+
+	name := ""
+	rooms := 0
+	if w.Chapter != nil {
+		name = w.Chapter.Name
+		rooms = len(w.Chapter.Rooms)
+	}
+	secs := int(w.Stats.Time)
+	lines := []string{
+		"CHAPTER HOAN THANH!",
+		name,
+		"",
+		fmt.Sprintf("Phong:     %d/%d", w.Stats.RoomsCleared, rooms),
+		fmt.Sprintf("Quai ha:   %d", w.Stats.Kills),
+		fmt.Sprintf("Boss ha:   %d", w.Stats.BossesDefeated),
+		fmt.Sprintf("Thoi gian: %d:%02d", secs/60, secs%60),
+		fmt.Sprintf("HP:        %.0f/%.0f", w.Player.Health, w.Player.MaxHealth),
+		fmt.Sprintf("Ky nang:   %d", len(w.Player.Skills)),
+		"",
+		"Nhan Enter de choi lai",
+	}
+	for i, line := range lines {
+		ebitenutil.DebugPrintAt(screen, line, int(x+30), int(y+20)+i*20)
+	}
+}
@@ -1,4 +1,4 @@
-package game
+package render
 
 import (
 	"image/color"
@@ -6,9 +6,11 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"pixcel-game/game"
 )
 
-func DrawSkillMenu(screen *ebiten.Image, skills []Skill) {
+func DrawSkillMenu(screen *ebiten.Image, skills []game.Skill) {
 	// Vẽ lớp phủ tối
 	vector.DrawFilledRect(
 		screen,
@@ -0,0 +1,142 @@
+package render
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"pixcel-game/game"
+	"pixcel-game/systems"
+)
+
+// TileStats là thống kê 1 lần vẽ map, dùng để so sánh vẽ từng tile với vẽ vùng cache
+type TileStats struct {
+	DrawCalls int // Số lần DrawImage lên màn hình
+	Tiles     int // Số tile vẽ thẳng (không qua cache)
+	Regions   int // Số vùng cache được vẽ
+	Built     int // Số vùng cache vừa dựng trong lần vẽ này
+	Cached    int // Số vùng cache đang giữ trong bộ nhớ
+}
+
+// tileCache giữ ảnh con của tile và ảnh vẽ sẵn của các vùng, gắn với 1 map
+type tileCache struct {
+	tilemap *game.TilemapJSON
+	tiles   map[tileKey]*ebiten.Image
+	regions map[regionKey]*tileRegion
+}
+
+type tileKey struct {
+	ts    *game.Tileset
+	local int
+}
+
+// regionKey là vùng cache: layer và tile góc trên trái của vùng
+type regionKey struct {
+	layer, x, y int
+}
+
+// tileRegion là ảnh vẽ sẵn của 1 vùng (nil nếu vùng trống) và khung pixel của ảnh trên map.
+// Tile có animation không vẽ sẵn mà được vẽ lại mỗi lần ở trên ảnh.
+type tileRegion struct {
+	img        *ebiten.Image
+	x, y, w, h float64
+	animated   []animatedTile
+}
+
+// animatedTile là 1 tile có animation trong vùng cache, (x, y) là góc trên trái ô trên map
+type animatedTile struct {
+	x, y float64
+	id   int
+}
+
+// use gắn cache với map đang vẽ, đổi map thì bỏ cache cũ
+func (c *tileCache) use(tilemap *game.TilemapJSON) {
+	if c.tilemap == tilemap && c.tiles != nil {
+		return
+	}
+	c.clear()
+	c.tilemap = tilemap
+}
+
+// clear giải phóng toàn bộ ảnh đã cache
+func (c *tileCache) clear() {
+	for _, reg := range c.regions {
+		if reg.img != nil {
+			reg.img.Deallocate()
+		}
+	}
+	c.tilemap = nil
+	c.tiles = map[tileKey]*ebiten.Image{}
+	c.regions = map[regionKey]*tileRegion{}
+}
+
+// InvalidateTileCache bỏ toàn bộ cache tile, gọi khi data hoặc ảnh tileset của map thay đổi
+func (r *Renderer) InvalidateTileCache() {
+	r.tileCache.clear()
+}
+
+// drawTilemapCached vẽ map bằng các vùng dựng sẵn: mỗi vùng trong camera chỉ tốn 1 lần DrawImage
+func (r *Renderer) drawTilemapCached(screen *ebiten.Image, tilemap *game.TilemapJSON, camera *systems.Camera) {
+	tilemap.EachVisibleRegion(camera.X, camera.Y, camera.Width, camera.Height, func(region game.TileRegion) {
+		r.drawRegion(screen, tilemap, r.region(tilemap, region), camera)
+	})
+	r.evictRegions(camera)
+	r.TileStats.Cached = len(r.tileCache.regions)
+}
+
+// region trả về ảnh cache của vùng, dựng mới nếu chưa có.
+// Ảnh cao thêm phần overdraw phía trên cho tile cao.
+func (r *Renderer) region(tilemap *game.TilemapJSON, region game.TileRegion) *tileRegion {
+	key := regionKey{region.Layer, region.X, region.Y}
+	if reg, ok := r.tileCache.regions[key]; ok {
+		return reg
+	}
+
+	tileW, tileH := tilemap.TileW, tilemap.TileH
+	overdraw := tilemap.TileOverdraw()
+	reg := &tileRegion{
+		x: float64(region.X * tileW),
+		y: float64(region.Y*tileH - overdraw),
+		w: float64(region.Width * tileW),
+		h: float64(region.Height*tileH + overdraw),
+	}
+	region.EachTile(tilemap, func(tx, ty, id int) {
+		if tileAnimated(tilemap, id) {
+			reg.animated = append(reg.animated, animatedTile{x: float64(tx * tileW), y: float64(ty * tileH), id: id})
+			return
+		}
+		if reg.img == nil {
+			reg.img = ebiten.NewImage(region.Width*tileW, region.Height*tileH+overdraw)
+		}
+		r.drawTile(reg.img, tilemap, id, float64((tx-region.X)*tileW), float64((ty-region.Y)*tileH+overdraw))
+	})
+	r.tileCache.regions[key] = reg
+	r.TileStats.Built++
+	return reg
+}
+
+// drawRegion vẽ ảnh vùng cache lên màn hình, rồi vẽ các tile có animation của vùng
+func (r *Renderer) drawRegion(screen *ebiten.Image, tilemap *game.TilemapJSON, reg *tileRegion, camera *systems.Camera) {
+	if reg.img != nil {
+		opts := ebiten.DrawImageOptions{}
+		opts.GeoM.Translate(reg.x-camera.X, reg.y-camera.Y)
+		screen.DrawImage(reg.img, &opts)
+		r.TileStats.DrawCalls++
+		r.TileStats.Regions++
+	}
+	for _, t := range reg.animated {
+		r.drawScreenTile(screen, tilemap, t.id, t.x-camera.X, t.y-camera.Y)
+	}
+}
+
+// evictRegions giải phóng vùng cache ở xa camera (cùng khoảng đệm với việc stream chunk)
+func (r *Renderer) evictRegions(camera *systems.Camera) {
+	const margin = 2 * game.DefaultStreamMargin
+	for key, reg := range r.tileCache.regions {
+		if camera.Visible(reg.x-margin, reg.y-margin, reg.w+2*margin, reg.h+2*margin) {
+			continue
+		}
+		if reg.img != nil {
+			reg.img.Deallocate()
+		}
+		delete(r.tileCache.regions, key)
+	}
+}
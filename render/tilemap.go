@@ -0,0 +1,108 @@
+package render
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"pixcel-game/game"
+	"pixcel-game/systems"
+)
+
+// DrawTilemap vẽ phần map nằm trong camera.
+// Mỗi tile được vẽ bằng ảnh của tileset chứa nó (Images tra theo đường dẫn ảnh của tileset).
+// Chỉ các ô trong camera được vẽ (xem TilemapJSON.EachVisibleTile).
+// Nếu bật CacheTiles thì vẽ các vùng đã dựng sẵn thay vì từng tile.
+// Tile có animation trong tileset chạy theo now (giây, thời gian của game clock).
+func (r *Renderer) DrawTilemap(screen *ebiten.Image, tilemap *game.TilemapJSON, camera *systems.Camera, now float64) {
+	r.TileStats = TileStats{}
+	if tilemap == nil {
+		return
+	}
+	r.tileTime = now
+	r.tileCache.use(tilemap)
+	if r.CacheTiles {
+		r.drawTilemapCached(screen, tilemap, camera)
+		return
+	}
+
+	tileW := float64(tilemap.TileW)
+	tileH := float64(tilemap.TileH)
+	tilemap.EachVisibleTile(camera.X, camera.Y, camera.Width, camera.Height, func(_, tx, ty, id int) {
+		r.drawScreenTile(screen, tilemap, id, float64(tx)*tileW-camera.X, float64(ty)*tileH-camera.Y)
+	})
+}
+
+// drawScreenTile vẽ 1 tile thẳng lên màn hình và cập nhật thống kê
+func (r *Renderer) drawScreenTile(screen *ebiten.Image, tilemap *game.TilemapJSON, id int, cellX, cellY float64) {
+	if r.drawTile(screen, tilemap, id, cellX, cellY) {
+		r.TileStats.DrawCalls++
+		r.TileStats.Tiles++
+	}
+}
+
+// drawTile vẽ tile có GID thô id vào ô lưới có góc trên trái (cellX, cellY) trên dst.
+// Trả về false nếu không có ảnh để vẽ.
+func (r *Renderer) drawTile(dst *ebiten.Image, tilemap *game.TilemapJSON, id int, cellX, cellY float64) bool {
+	ts, local := tilemap.TilesetFor(id)
+	if ts == nil {
+		return false
+	}
+	img := r.tileImage(ts, ts.AnimatedTile(local, r.tileTime))
+	if img == nil {
+		return false
+	}
+
+	opts := ebiten.DrawImageOptions{}
+	_, flip := game.SplitGID(id)
+	if flip.Any() {
+		applyFlip(&opts.GeoM, flip, float64(ts.TileW), float64(ts.TileH))
+	}
+	// Tile cao hơn ô lưới (vd. cây) được căn theo đáy ô như trong Tiled
+	opts.GeoM.Translate(cellX, cellY+float64(tilemap.TileH-ts.TileH))
+	dst.DrawImage(img, &opts)
+	return true
+}
+
+// tileAnimated kiểm tra tile có animation không (không được vẽ sẵn vào vùng cache)
+func tileAnimated(tilemap *game.TilemapJSON, id int) bool {
+	info := tilemap.TileInfo(id)
+	return info != nil && info.Animated()
+}
+
+// tileImage trả về ảnh con của tile trong ảnh tileset, cắt 1 lần rồi dùng lại
+func (r *Renderer) tileImage(ts *game.Tileset, local int) *ebiten.Image {
+	key := tileKey{ts: ts, local: local}
+	if img, ok := r.tileCache.tiles[key]; ok {
+		return img
+	}
+	var sub *ebiten.Image
+	if img := r.Image(ts.Image); img != nil {
+		x0, y0, x1, y1 := ts.SourceRect(local)
+		sub = subImage(img, image.Rect(x0, y0, x1, y1))
+	}
+	// Chưa có ảnh thì không lưu, để lần sau thử lại khi ảnh được load
+	if sub != nil {
+		r.tileCache.tiles[key] = sub
+	}
+	return sub
+}
+
+// applyFlip lật/xoay tile quanh tâm theo thứ tự của Tiled: chéo trước, rồi ngang, rồi dọc
+func applyFlip(geo *ebiten.GeoM, flip game.TileFlip, w, h float64) {
+	geo.Translate(-w/2, -h/2)
+	if flip.Diagonal {
+		// Lật chéo = đổi trục x và y: xoay 90 độ rồi lật ngang
+		geo.Rotate(math.Pi / 2)
+		geo.Scale(-1, 1)
+		w, h = h, w
+	}
+	if flip.Horizontal {
+		geo.Scale(-1, 1)
+	}
+	if flip.Vertical {
+		geo.Scale(1, -1)
+	}
+	geo.Translate(w/2, h/2)
+}
@@ -1,6 +1,7 @@
-package game
+package render
 
 import (
+	"image"
 	"image/color"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -27,3 +28,9 @@ func DrawHealthBar(screen *ebiten.Image, x, y, width, height float64, ratio floa
 		vector.DrawFilledRect(screen, float32(x), float32(y), float32(width*ratio), float32(height), healthColor, false)
 	}
 }
+
+// subImage cắt vùng rect (tính từ góc trên trái của img) ra khỏi img.
+// Ảnh lấy từ atlas là ảnh con có Bounds().Min khác (0, 0) nên phải dời rect theo.
+func subImage(img *ebiten.Image, rect image.Rectangle) *ebiten.Image {
+	return img.SubImage(rect.Add(img.Bounds().Min)).(*ebiten.Image)
+}
@@ -0,0 +1,132 @@
+package systems
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"sort"
+)
+
+// DefaultAtlasSize là cạnh tối đa (pixel) của 1 trang atlas
+const DefaultAtlasSize = 2048
+
+// atlasPadding là khoảng trống giữa các ảnh trong trang, để khi co giãn không lem màu ảnh bên cạnh
+const atlasPadding = 1
+
+// AtlasEntry là vị trí 1 ảnh trong atlas
+type AtlasEntry struct {
+	Page int `json:"page"`
+	X    int `json:"x"`
+	Y    int `json:"y"`
+	W    int `json:"w"`
+	H    int `json:"h"`
+}
+
+// Rect trả về vùng của ảnh trên trang
+func (e AtlasEntry) Rect() image.Rectangle {
+	return image.Rect(e.X, e.Y, e.X+e.W, e.Y+e.H)
+}
+
+// AtlasIndex là file JSON mô tả atlas dựng sẵn (lúc build bundle)
+type AtlasIndex struct {
+	Pages   []string              `json:"pages"`   // File ảnh của từng trang, tương đối so với file index
+	Entries map[string]AtlasEntry `json:"entries"` // ID ảnh -> vị trí
+}
+
+// Atlas là các trang ảnh đã xếp và vị trí của từng ảnh theo ID
+type Atlas struct {
+	Pages   []*image.NRGBA
+	Entries map[string]AtlasEntry
+}
+
+// PackAtlas xếp các ảnh vào các trang có cạnh tối đa size theo từng kệ (shelf):
+// ảnh cao trước, xếp từ trái sang phải, hết chỗ thì xuống kệ mới, hết trang thì mở trang mới.
+// Ảnh lớn hơn cả trang được đặt riêng 1 trang vừa khít. Trang được cắt bớt phần thừa.
+func PackAtlas(images map[string]image.Image, size int) *Atlas {
+	if size <= 0 {
+		size = DefaultAtlasSize
+	}
+	ids := make([]string, 0, len(images))
+	for id := range images {
+		ids = append(ids, id)
+	}
+	// Thứ tự cố định để cùng bộ ảnh luôn cho ra cùng atlas
+	sort.Slice(ids, func(i, j int) bool {
+		hi, hj := images[ids[i]].Bounds().Dy(), images[ids[j]].Bounds().Dy()
+		if hi != hj {
+			return hi > hj
+		}
+		return ids[i] < ids[j]
+	})
+
+	atlas := &Atlas{Entries: make(map[string]AtlasEntry, len(ids))}
+	var used []image.Point // Kích thước đã dùng của từng trang
+	page, x, y, shelfH := -1, 0, 0, 0
+	newPage := func(w, h int) {
+		atlas.Pages = append(atlas.Pages, image.NewNRGBA(image.Rect(0, 0, w, h)))
+		used = append(used, image.Point{})
+		page, x, y, shelfH = len(atlas.Pages)-1, 0, 0, 0
+	}
+
+	for _, id := range ids {
+		b := images[id].Bounds()
+		w, h := b.Dx(), b.Dy()
+		if w > size || h > size {
+			// Ảnh quá lớn: trang riêng, trang đang xếp dở vẫn dùng tiếp cho ảnh sau
+			cur, cx, cy, cs := page, x, y, shelfH
+			newPage(w, h)
+			atlas.place(id, images[id], page, 0, 0, used)
+			page, x, y, shelfH = cur, cx, cy, cs
+			continue
+		}
+		if page >= 0 && x+w > size {
+			// Hết chỗ trên kệ: xuống kệ mới
+			x, y, shelfH = 0, y+shelfH, 0
+		}
+		if page < 0 || y+h > size {
+			newPage(size, size)
+		}
+		atlas.place(id, images[id], page, x, y, used)
+		x += w + atlasPadding
+		shelfH = max(shelfH, h+atlasPadding)
+	}
+
+	for i, p := range atlas.Pages {
+		if used[i].X < p.Bounds().Dx() || used[i].Y < p.Bounds().Dy() {
+			atlas.Pages[i] = p.SubImage(image.Rect(0, 0, used[i].X, used[i].Y)).(*image.NRGBA)
+		}
+	}
+	return atlas
+}
+
+// place vẽ ảnh vào trang tại (x, y) và ghi lại vị trí
+func (a *Atlas) place(id string, img image.Image, page, x, y int, used []image.Point) {
+	b := img.Bounds()
+	rect := image.Rect(x, y, x+b.Dx(), y+b.Dy())
+	draw.Draw(a.Pages[page], rect, img, b.Min, draw.Src)
+	a.Entries[id] = AtlasEntry{Page: page, X: x, Y: y, W: b.Dx(), H: b.Dy()}
+	used[page].X = max(used[page].X, rect.Max.X)
+	used[page].Y = max(used[page].Y, rect.Max.Y)
+}
+
+// Index tạo file index cho atlas, trang thứ i được lưu với tên pageName(i)
+func (a *Atlas) Index(pageName func(i int) string) AtlasIndex {
+	index := AtlasIndex{Entries: a.Entries}
+	for i := range a.Pages {
+		index.Pages = append(index.Pages, pageName(i))
+	}
+	return index
+}
+
+// Validate kiểm tra index không trỏ tới trang không tồn tại
+func (idx *AtlasIndex) Validate() error {
+	for id, e := range idx.Entries {
+		if e.Page < 0 || e.Page >= len(idx.Pages) {
+			return fmt.Errorf("ảnh %q nằm ở trang %d không tồn tại", id, e.Page)
+		}
+		if e.W <= 0 || e.H <= 0 {
+			return fmt.Errorf("ảnh %q có kích thước %dx%d", id, e.W, e.H)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package systems
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+// solidImage tạo ảnh w x h tô 1 màu, gốc bounds lệch khỏi (0, 0) để kiểm tra PackAtlas đọc đúng Min
+func solidImage(w, h int, c color.NRGBA) image.Image {
+	img := image.NewNRGBA(image.Rect(3, 5, 3+w, 5+h))
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestPackAtlas(t *testing.T) {
+	const size = 64
+	images := map[string]image.Image{}
+	colors := map[string]color.NRGBA{}
+	sizes := [][2]int{{16, 16}, {30, 10}, {8, 40}, {63, 5}, {20, 20}, {20, 20}, {1, 1}, {64, 64}, {100, 12}, {33, 33}}
+	for i, s := range sizes {
+		id := fmt.Sprintf("img%d", i)
+		colors[id] = color.NRGBA{R: uint8(20 * i), G: uint8(255 - 20*i), B: 7, A: 255}
+		images[id] = solidImage(s[0], s[1], colors[id])
+	}
+
+	atlas := PackAtlas(images, size)
+	if len(atlas.Entries) != len(images) {
+		t.Fatalf("atlas có %d ảnh, muốn %d", len(atlas.Entries), len(images))
+	}
+	index := atlas.Index(func(i int) string { return fmt.Sprintf("page%d.png", i) })
+	if err := index.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for id, e := range atlas.Entries {
+		img := images[id]
+		if e.W != img.Bounds().Dx() || e.H != img.Bounds().Dy() {
+			t.Errorf("%s: kích thước %dx%d, muốn %v", id, e.W, e.H, img.Bounds().Size())
+		}
+		page := atlas.Pages[e.Page]
+		if !e.Rect().In(page.Bounds()) {
+			t.Errorf("%s: vùng %v nằm ngoài trang %d %v", id, e.Rect(), e.Page, page.Bounds())
+		}
+		// Ảnh vừa trang thì trang không được vượt size, ảnh quá lớn có trang riêng
+		if e.W > size || e.H > size {
+			if e.X != 0 || e.Y != 0 || page.Bounds().Size() != img.Bounds().Size() {
+				t.Errorf("%s: ảnh quá lớn phải có trang riêng vừa khít, được %v trên trang %v", id, e.Rect(), page.Bounds())
+			}
+		} else if page.Bounds().Dx() > size || page.Bounds().Dy() > size {
+			t.Errorf("trang %d rộng %v, tối đa %d", e.Page, page.Bounds().Size(), size)
+		}
+		// Pixel được chép đúng từ ảnh gốc
+		for _, p := range []image.Point{{e.X, e.Y}, {e.X + e.W - 1, e.Y + e.H - 1}} {
+			if got := page.NRGBAAt(p.X, p.Y); got != colors[id] {
+				t.Errorf("%s: pixel %v trên trang là %v, muốn %v", id, p, got, colors[id])
+			}
+		}
+	}
+
+	// Không 2 ảnh nào cùng trang đè lên nhau, kể cả phần đệm giữa 2 ảnh
+	for a, ea := range atlas.Entries {
+		for b, eb := range atlas.Entries {
+			if a >= b || ea.Page != eb.Page {
+				continue
+			}
+			// Nới mỗi vùng thêm nửa phần đệm về mọi phía (nhân đôi tọa độ để khỏi lẻ): 2 vùng cách nhau đủ đệm thì không chạm
+			grow := func(r image.Rectangle) image.Rectangle {
+				return image.Rect(2*r.Min.X-atlasPadding, 2*r.Min.Y-atlasPadding, 2*r.Max.X+atlasPadding, 2*r.Max.Y+atlasPadding)
+			}
+			if grow(ea.Rect()).Overlaps(grow(eb.Rect())) {
+				t.Errorf("%s %v và %s %v đè nhau trên trang %d", a, ea.Rect(), b, eb.Rect(), ea.Page)
+			}
+		}
+	}
+
+	// Cùng bộ ảnh luôn cho cùng cách xếp
+	if again := PackAtlas(images, size); !reflect.DeepEqual(again.Entries, atlas.Entries) {
+		t.Error("xếp lại cùng bộ ảnh cho kết quả khác")
+	}
+}
@@ -1,12 +1,14 @@
 package systems
 
+import "math"
+
 // Camera quản lý viewport
 type Camera struct {
-	X, Y     float64
-	Width    float64
-	Height   float64
-	FollowX  float64
-	FollowY  float64
+	X, Y    float64
+	Width   float64
+	Height  float64
+	FollowX float64
+	FollowY float64
 }
 
 // NewCamera tạo camera mới
@@ -21,15 +23,17 @@ func NewCamera(width, height float64) *Camera {
 	}
 }
 
-// Update cập nhật vị trí camera (smooth follow)
-func (c *Camera) Update() {
+// Update cập nhật vị trí camera (smooth follow), dt tính bằng giây
+func (c *Camera) Update(dt float64) {
 	// Smooth camera follow
 	targetX := c.FollowX - c.Width/2
 	targetY := c.FollowY - c.Height/2
 
 	// Linear interpolation cho smooth movement
-	c.X += (targetX - c.X) * 0.1
-	c.Y += (targetY - c.Y) * 0.1
+	// (mỗi 1/60 giây tiến 10% quãng đường, quy đổi theo dt để không phụ thuộc TPS)
+	t := 1 - math.Pow(0.9, dt*60)
+	c.X += (targetX - c.X) * t
+	c.Y += (targetY - c.Y) * t
 }
 
 // SetFollowTarget đặt target để camera follow
@@ -37,3 +41,8 @@ func (c *Camera) SetFollowTarget(x, y float64) {
 	c.FollowX = x
 	c.FollowY = y
 }
+
+// Visible kiểm tra vùng (x, y, w, h) trên map có lọt vào viewport không
+func (c *Camera) Visible(x, y, w, h float64) bool {
+	return x < c.X+c.Width && x+w > c.X && y < c.Y+c.Height && y+h > c.Y
+}
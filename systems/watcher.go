@@ -0,0 +1,91 @@
+package systems
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DefaultWatchInterval là khoảng thời gian (giây) giữa 2 lần quét thư mục
+const DefaultWatchInterval = 0.5
+
+// fileStamp là dấu hiệu để biết file đã đổi: thời điểm sửa và kích thước
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// FileWatcher theo dõi các file trong 1 thư mục bằng cách quét định kỳ (không cần API của hệ điều hành).
+// Đường dẫn trả về tính tương đối so với Base và dùng dấu /, vd "assets/maps/spawn.json".
+type FileWatcher struct {
+	Base     string  // Thư mục gốc của đường dẫn trả về
+	Dir      string  // Thư mục con cần theo dõi, tương đối so với Base
+	Interval float64 // Giây giữa 2 lần quét
+
+	files   map[string]fileStamp
+	elapsed float64
+}
+
+// NewFileWatcher tạo watcher cho thư mục base/dir và ghi nhận trạng thái hiện tại của các file
+func NewFileWatcher(base, dir string) (*FileWatcher, error) {
+	fw := &FileWatcher{Base: base, Dir: dir, Interval: DefaultWatchInterval}
+	files, err := fw.scan()
+	if err != nil {
+		return nil, err
+	}
+	fw.files = files
+	return fw, nil
+}
+
+// Update đếm thời gian, tới lượt quét thì trả về các file đã được sửa, thêm mới hoặc bị xóa
+// kể từ lần quét trước (đã sắp xếp). Lỗi khi quét (vd đang lưu dở) được bỏ qua tới lần sau.
+func (fw *FileWatcher) Update(dt float64) []string {
+	fw.elapsed += dt
+	if fw.elapsed < fw.Interval {
+		return nil
+	}
+	fw.elapsed = 0
+	changed, _ := fw.Poll()
+	return changed
+}
+
+// Poll quét thư mục ngay và trả về các file đã thay đổi kể từ lần quét trước
+func (fw *FileWatcher) Poll() ([]string, error) {
+	files, err := fw.scan()
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for p, stamp := range files {
+		if old, ok := fw.files[p]; !ok || old != stamp {
+			changed = append(changed, p)
+		}
+	}
+	for p := range fw.files {
+		if _, ok := files[p]; !ok {
+			changed = append(changed, p)
+		}
+	}
+	fw.files = files
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// scan đọc trạng thái của mọi file trong thư mục
+func (fw *FileWatcher) scan() (map[string]fileStamp, error) {
+	files := map[string]fileStamp{}
+	err := fs.WalkDir(os.DirFS(fw.Base), filepath.ToSlash(fw.Dir), func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[p] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return files, err
+}
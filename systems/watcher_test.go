@@ -0,0 +1,89 @@
+package systems
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFileWatcherPoll(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "assets", "data")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, contents string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("enemies.json", "{}")
+	write("waves.json", "{}")
+
+	fw, err := NewFileWatcher(base, "assets/data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		change func()
+		want   []string
+	}{
+		{"không đổi gì", func() {}, nil},
+		{"thêm file trong thư mục con", func() { write("sub/bosses.json", "{}") }, []string{"assets/data/sub/bosses.json"}},
+		{"đổi kích thước", func() { write("enemies.json", `{"enemies": []}`) }, []string{"assets/data/enemies.json"}},
+		{"đổi thời điểm sửa", func() {
+			if err := os.Chtimes(filepath.Join(dir, "waves.json"), past, past); err != nil {
+				t.Fatal(err)
+			}
+		}, []string{"assets/data/waves.json"}},
+		{"xóa file", func() {
+			if err := os.Remove(filepath.Join(dir, "enemies.json")); err != nil {
+				t.Fatal(err)
+			}
+		}, []string{"assets/data/enemies.json"}},
+		{"thêm và xóa cùng lúc", func() {
+			write("chapter1.json", "{}")
+			if err := os.Remove(filepath.Join(dir, "sub", "bosses.json")); err != nil {
+				t.Fatal(err)
+			}
+		}, []string{"assets/data/chapter1.json", "assets/data/sub/bosses.json"}},
+		{"không đổi gì sau các thay đổi", func() {}, nil},
+	}
+	for _, tt := range tests {
+		tt.change()
+		changed, err := fw.Poll()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(changed, tt.want) {
+			t.Errorf("%s: thay đổi = %q, muốn %q", tt.name, changed, tt.want)
+		}
+	}
+}
+
+// TestFileWatcherUpdate: Update chỉ quét khi đủ Interval
+func TestFileWatcherUpdate(t *testing.T) {
+	base := t.TempDir()
+	fw, err := NewFileWatcher(base, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "map.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if changed := fw.Update(fw.Interval / 2); changed != nil {
+		t.Errorf("quét trước khi đủ Interval: %q", changed)
+	}
+	if changed := fw.Update(fw.Interval / 2); !reflect.DeepEqual(changed, []string{"map.json"}) {
+		t.Errorf("thay đổi = %q, muốn [map.json]", changed)
+	}
+	if changed := fw.Update(fw.Interval); changed != nil {
+		t.Errorf("cây không đổi vẫn báo thay đổi: %q", changed)
+	}
+}